@@ -11,12 +11,14 @@ import (
 	"github.com/tasnimzotder/langz/internal/codegen"
 	"github.com/tasnimzotder/langz/internal/lexer"
 	"github.com/tasnimzotder/langz/internal/lsp"
+	"github.com/tasnimzotder/langz/internal/modules"
 	"github.com/tasnimzotder/langz/internal/parser"
+	"github.com/tasnimzotder/langz/internal/sema"
 )
 
 func main() {
 	if len(os.Args) < 2 {
-		fmt.Fprintln(os.Stderr, "Usage: langz <build|run|fmt|lsp> <file.lz>")
+		fmt.Fprintln(os.Stderr, "Usage: langz <build|run|fmt|lsp|vendor> <file.lz>")
 		os.Exit(1)
 	}
 
@@ -36,6 +38,12 @@ func main() {
 		return
 	}
 
+	// vendor takes optional entry files, defaulting to the whole project
+	if command == "vendor" {
+		vendor(os.Args[2:])
+		return
+	}
+
 	if len(os.Args) < 3 {
 		fmt.Fprintln(os.Stderr, "Usage: langz <build|run|fmt> <file.lz>")
 		os.Exit(1)
@@ -63,25 +71,36 @@ func main() {
 	}
 
 	tokens := lexer.New(string(source)).Tokenize()
-	prog, parseErrs := parser.New(tokens).ParseAllErrors()
+	prog, parseErrs := parser.NewFile(tokens, inputFile).ParseAllErrors()
 	if len(parseErrs) > 0 {
 		formatAllParseErrors(string(source), inputFile, parseErrs)
 		os.Exit(1)
 	}
 
 	// Resolve imports before codegen
-	baseDir := filepath.Dir(inputFile)
-	absInput, _ := filepath.Abs(inputFile)
-	visited := map[string]bool{absInput: true}
-	if err := resolveImports(prog, baseDir, visited); err != nil {
-		fmt.Fprintf(os.Stderr, "%s: %v\n", inputFile, err)
+	resolver, err := modules.NewResolver(inputFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if importErrors := modules.Resolve(prog, inputFile, resolver); len(importErrors) > 0 {
+		for _, e := range importErrors {
+			fmt.Fprintln(os.Stderr, e)
+		}
+		os.Exit(1)
+	}
+
+	if semaErrors := sema.Check(prog); len(semaErrors) > 0 {
+		for _, e := range semaErrors {
+			fmt.Fprintln(os.Stderr, formatSpanError(inputFile, e.Span, e.Message))
+		}
 		os.Exit(1)
 	}
 
 	output, codegenErrors := codegen.Generate(prog)
 	if len(codegenErrors) > 0 {
 		for _, e := range codegenErrors {
-			fmt.Fprintf(os.Stderr, "%s: %s\n", inputFile, e)
+			fmt.Fprintln(os.Stderr, formatSpanError(inputFile, e.Span, e.Message))
 		}
 		os.Exit(1)
 	}
@@ -97,25 +116,29 @@ func main() {
 		fmt.Printf("Built %s -> %s\n", inputFile, outFile)
 
 	case "run":
-		tmpFile, err := os.CreateTemp("", "langz-*.sh")
+		// The script is named after the input so that $0, and with it the
+		// usage line of a flags block, reads "deploy" rather than a temp name.
+		tmpDir, err := os.MkdirTemp("", "langz-")
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error creating temp file: %v\n", err)
 			os.Exit(1)
 		}
-		defer os.Remove(tmpFile.Name())
-
-		if _, err := tmpFile.WriteString(output); err != nil {
+		script := filepath.Join(tmpDir, strings.TrimSuffix(filepath.Base(inputFile), filepath.Ext(inputFile)))
+		if err := os.WriteFile(script, []byte(output), 0700); err != nil {
+			os.RemoveAll(tmpDir)
 			fmt.Fprintf(os.Stderr, "Error writing temp file: %v\n", err)
 			os.Exit(1)
 		}
-		tmpFile.Close()
 
-		cmd := exec.Command("bash", tmpFile.Name())
+		// Everything after the file is the script's own arguments.
+		cmd := exec.Command("bash", append([]string{script}, os.Args[3:]...)...)
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
 		cmd.Stdin = os.Stdin
 
-		if err := cmd.Run(); err != nil {
+		err = cmd.Run()
+		os.RemoveAll(tmpDir)
+		if err != nil {
 			if exitErr, ok := err.(*exec.ExitError); ok {
 				os.Exit(exitErr.ExitCode())
 			}
@@ -124,54 +147,63 @@ func main() {
 		}
 
 	default:
-		fmt.Fprintf(os.Stderr, "Unknown command: %s\nUsage: langz <build|run|fmt|lsp> <file.lz>\n", command)
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\nUsage: langz <build|run|fmt|lsp|vendor> <file.lz>\n", command)
 		os.Exit(1)
 	}
 }
 
-// resolveImports walks the AST, finds ImportStmt nodes, reads/parses imported
-// files, and prepends their statements. Circular imports are detected via visited.
-func resolveImports(prog *ast.Program, baseDir string, visited map[string]bool) error {
-	var resolved []ast.Node
-	for _, stmt := range prog.Statements {
-		imp, ok := stmt.(*ast.ImportStmt)
-		if !ok {
-			resolved = append(resolved, stmt)
-			continue
-		}
-
-		importPath := filepath.Join(baseDir, imp.Path)
-		absPath, err := filepath.Abs(importPath)
-		if err != nil {
-			return fmt.Errorf("import %q: %v", imp.Path, err)
-		}
-
-		if visited[absPath] {
-			return fmt.Errorf("circular import detected: %s", imp.Path)
-		}
-		visited[absPath] = true
-
-		data, err := os.ReadFile(importPath)
-		if err != nil {
-			return fmt.Errorf("import %q: %v", imp.Path, err)
-		}
+// vendor copies the modules files import from library roots into the
+// vendor directory of the project in the current directory.
+func vendor(files []string) {
+	wd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	mod, err := modules.FindModFile(wd)
+	if err == nil && mod == nil {
+		err = fmt.Errorf("no %s found in %s or any parent directory", modules.ModFileName, wd)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 
-		tokens := lexer.New(string(data)).Tokenize()
-		importProg, parseErrs := parser.New(tokens).ParseAllErrors()
-		if len(parseErrs) > 0 {
-			return fmt.Errorf("import %q: %s", imp.Path, parseErrs[0].Message)
+	if len(files) == 0 {
+		if files, err = modules.ProjectFiles(mod); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
 		}
+	}
+	written, errs := modules.Vendor(mod, files)
+	for _, e := range errs {
+		fmt.Fprintln(os.Stderr, e)
+	}
+	if len(errs) > 0 {
+		os.Exit(1)
+	}
+	for _, file := range written {
+		fmt.Printf("Vendored %s\n", file)
+	}
+}
 
-		// Recursively resolve imports in the imported file
-		importDir := filepath.Dir(importPath)
-		if err := resolveImports(importProg, importDir, visited); err != nil {
-			return err
-		}
+// formatSpanError renders a message located at span, which points into
+// an imported file when the error is in one.
+func formatSpanError(inputFile string, span ast.Span, msg string) string {
+	file := inputFile
+	if span.File != "" {
+		file = span.File
+	}
+	return formatPosError(file, span.Start, msg)
+}
 
-		resolved = append(resolved, importProg.Statements...)
+// formatPosError renders a message as file:line:col: msg, omitting the
+// position when it is unknown.
+func formatPosError(file string, pos ast.Pos, msg string) string {
+	if pos.Line == 0 {
+		return fmt.Sprintf("%s: %s", file, msg)
 	}
-	prog.Statements = resolved
-	return nil
+	return fmt.Sprintf("%s:%d:%d: %s", file, pos.Line, pos.Col, msg)
 }
 
 // formatAllParseErrors prints all parse errors with source context.
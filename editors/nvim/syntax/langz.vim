@@ -8,13 +8,15 @@ syntax match langzComment "//.*$" contains=@Spell
 
 " Strings with interpolation
 syntax region langzString start=/"/ end=/"/ contains=langzInterpolation
+syntax region langzString start=/"""/ end=/"""/ contains=langzInterpolation
+syntax region langzRawString start=/r"""/ end=/"""/
 syntax match langzInterpolation /{\w\+}/ contained
 
 " Numbers
-syntax match langzNumber /\<[0-9]\+\>/
+syntax match langzNumber /\<[0-9]\+\(\.[0-9]\+\)\=\>/
 
 " Control flow keywords
-syntax keyword langzKeyword if elif else for in fn return match continue break while
+syntax keyword langzKeyword if elif else for in fn return match continue break while try catch finally defer with
 
 " Logical operators
 syntax keyword langzLogical and or
@@ -23,10 +25,10 @@ syntax keyword langzLogical and or
 syntax keyword langzBoolean true false
 
 " Builtin functions
-syntax match langzBuiltin /\<\(print\|exec\|env\|read\|write\|rm\|mkdir\|copy\|move\|chmod\|chown\|glob\|exit\|fetch\|sleep\|append\|hostname\|whoami\|arch\|dirname\|basename\|is_file\|is_dir\|rmdir\|upper\|lower\|os\|args\|range\|exists\|json_get\|trim\|len\|timestamp\|date\)\>\ze\s*(/
+syntax match langzBuiltin /\<\(print\|exec\|env\|read\|write\|rm\|mkdir\|copy\|move\|chmod\|chown\|glob\|exit\|fetch\|sleep\|append\|hostname\|whoami\|arch\|dirname\|basename\|is_file\|is_dir\|rmdir\|upper\|lower\|os\|args\|range\|exists\|json_get\|parse_json\|to_json\|trim\|len\|timestamp\|date\|round\|floor\|ceil\|matches\|regex_find\|replace_regex\|spawn\|wait\|wait_all\|kill\|is_running\|pid\|keys\|values\|has\|delete\)\>\ze\s*(/
 
 " String methods
-syntax match langzMethod /\.\<\(replace\|contains\|starts_with\|ends_with\|split\|join\|length\)\>\ze\s*(/
+syntax match langzMethod /\.\<\(replace\|contains\|starts_with\|ends_with\|split\|join\|length\|matches\)\>\ze\s*(/
 
 " Operators
 syntax match langzOperator /|>\|=>\|->\|==\|!=\|>=\|<=\|+=\|-=\|\*=\|\/=\|[=+\-*/%<>!]/
@@ -34,6 +36,7 @@ syntax match langzOperator /|>\|=>\|->\|==\|!=\|>=\|<=\|+=\|-=\|\*=\|\/=\|[=+\-*
 " Highlighting
 highlight default link langzComment Comment
 highlight default link langzString String
+highlight default link langzRawString String
 highlight default link langzInterpolation Special
 highlight default link langzNumber Number
 highlight default link langzKeyword Keyword
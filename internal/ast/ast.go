@@ -1,12 +1,33 @@
 package ast
 
+import "strings"
+
 // Node is the interface all AST nodes implement.
 type Node interface {
 	nodeType() string
+	NodeSpan() Span
+}
+
+// Pos is a 1-based line and column in the source.
+type Pos struct {
+	Line int
+	Col  int
+}
+
+// Span is the source range a node covers. End points just past the
+// node's last character.
+type Span struct {
+	Start Pos
+	End   Pos
+	File  string // source file, when the parser was given one
 }
 
+// NodeSpan returns the source range of the node.
+func (s Span) NodeSpan() Span { return s }
+
 // Program is the root node — a list of statements.
 type Program struct {
+	Span
 	Statements []Node
 }
 
@@ -14,28 +35,42 @@ func (p *Program) nodeType() string { return "Program" }
 
 // Assignment: name = expr
 type Assignment struct {
+	Span
 	Name  string
 	Value Node
 }
 
 func (a *Assignment) nodeType() string { return "Assignment" }
 
-// StringLiteral: "hello"
+// StringLiteral: "hello", """...""", r"""..."""
 type StringLiteral struct {
-	Value string
+	Span
+	Value     string
+	Multiline bool // triple-quoted
+	Raw       bool // r"""...""": no interpolation
 }
 
 func (s *StringLiteral) nodeType() string { return "StringLiteral" }
 
 // IntLiteral: 42
 type IntLiteral struct {
+	Span
 	Value string
 }
 
 func (i *IntLiteral) nodeType() string { return "IntLiteral" }
 
+// FloatLiteral: 3.14
+type FloatLiteral struct {
+	Span
+	Value string
+}
+
+func (f *FloatLiteral) nodeType() string { return "FloatLiteral" }
+
 // BoolLiteral: true, false
 type BoolLiteral struct {
+	Span
 	Value bool
 }
 
@@ -43,6 +78,7 @@ func (b *BoolLiteral) nodeType() string { return "BoolLiteral" }
 
 // Identifier: name
 type Identifier struct {
+	Span
 	Name string
 }
 
@@ -50,6 +86,7 @@ func (id *Identifier) nodeType() string { return "Identifier" }
 
 // KeywordArg: key: value in function calls
 type KeywordArg struct {
+	Span
 	Key   string
 	Value Node
 }
@@ -58,6 +95,7 @@ func (k *KeywordArg) nodeType() string { return "KeywordArg" }
 
 // FuncCall: print("hello"), fetch("url", method: "POST")
 type FuncCall struct {
+	Span
 	Name   string
 	Args   []Node
 	KwArgs []KeywordArg
@@ -67,6 +105,7 @@ func (f *FuncCall) nodeType() string { return "FuncCall" }
 
 // OrExpr: expr or fallback
 type OrExpr struct {
+	Span
 	Expr     Node
 	Fallback Node
 }
@@ -75,6 +114,7 @@ func (o *OrExpr) nodeType() string { return "OrExpr" }
 
 // FuncDecl: fn name(params) -> returnType { body }
 type FuncDecl struct {
+	Span
 	Name       string
 	Params     []Param
 	ReturnType string
@@ -85,6 +125,7 @@ func (f *FuncDecl) nodeType() string { return "FuncDecl" }
 
 // Param: name: type or name: type = default
 type Param struct {
+	Span
 	Name    string
 	Type    string
 	Default Node // nil if no default
@@ -92,6 +133,7 @@ type Param struct {
 
 // IfStmt: if cond { body } else { elseBody }
 type IfStmt struct {
+	Span
 	Condition Node
 	Body      []Node
 	ElseBody  []Node
@@ -100,8 +142,11 @@ type IfStmt struct {
 func (i *IfStmt) nodeType() string { return "IfStmt" }
 
 // ForStmt: for item in collection { body }
+// or for key, value in collection { body }
 type ForStmt struct {
+	Span
 	Var        string
+	ValueVar   string // second variable of the two-variable form; "" if absent
 	Collection Node
 	Body       []Node
 }
@@ -110,6 +155,7 @@ func (f *ForStmt) nodeType() string { return "ForStmt" }
 
 // BinaryExpr: left op right
 type BinaryExpr struct {
+	Span
 	Left  Node
 	Op    string
 	Right Node
@@ -119,6 +165,7 @@ func (b *BinaryExpr) nodeType() string { return "BinaryExpr" }
 
 // UnaryExpr: !expr
 type UnaryExpr struct {
+	Span
 	Op      string
 	Operand Node
 }
@@ -127,6 +174,7 @@ func (u *UnaryExpr) nodeType() string { return "UnaryExpr" }
 
 // DotExpr: obj.field
 type DotExpr struct {
+	Span
 	Object Node
 	Field  string
 }
@@ -135,27 +183,33 @@ func (d *DotExpr) nodeType() string { return "DotExpr" }
 
 // MethodCall: obj.method(args)
 type MethodCall struct {
+	Span
 	Object Node
 	Method string
 	Args   []Node
+	KwArgs []KeywordArg // only for calls into an imported module
 }
 
 func (m *MethodCall) nodeType() string { return "MethodCall" }
 
 // ReturnStmt: return expr
 type ReturnStmt struct {
+	Span
 	Value Node
 }
 
 func (r *ReturnStmt) nodeType() string { return "ReturnStmt" }
 
 // ContinueStmt: continue
-type ContinueStmt struct{}
+type ContinueStmt struct {
+	Span
+}
 
 func (c *ContinueStmt) nodeType() string { return "ContinueStmt" }
 
 // ExitCall: exit(code)
 type ExitCall struct {
+	Span
 	Code Node
 }
 
@@ -163,6 +217,7 @@ func (e *ExitCall) nodeType() string { return "ExitCall" }
 
 // ListLiteral: ["a", "b", "c"]
 type ListLiteral struct {
+	Span
 	Elements []Node
 }
 
@@ -170,6 +225,7 @@ func (l *ListLiteral) nodeType() string { return "ListLiteral" }
 
 // MapLiteral: {key: value, ...}
 type MapLiteral struct {
+	Span
 	Keys   []string
 	Values []Node
 }
@@ -178,14 +234,25 @@ func (m *MapLiteral) nodeType() string { return "MapLiteral" }
 
 // IndexExpr: arr[0], map["key"]
 type IndexExpr struct {
+	Span
 	Object Node
 	Index  Node
 }
 
 func (i *IndexExpr) nodeType() string { return "IndexExpr" }
 
+// PathAssignment: data.items[0].name = value
+type PathAssignment struct {
+	Span
+	Target Node // a DotExpr or IndexExpr chain
+	Value  Node
+}
+
+func (p *PathAssignment) nodeType() string { return "PathAssignment" }
+
 // IndexAssignment: arr[0] = value
 type IndexAssignment struct {
+	Span
 	Object string
 	Index  Node
 	Value  Node
@@ -195,6 +262,7 @@ func (i *IndexAssignment) nodeType() string { return "IndexAssignment" }
 
 // BlockExpr: { stmts... lastExpr } — used in `or { ... }` blocks
 type BlockExpr struct {
+	Span
 	Statements []Node
 }
 
@@ -202,6 +270,7 @@ func (b *BlockExpr) nodeType() string { return "BlockExpr" }
 
 // MatchStmt: match expr { cases }
 type MatchStmt struct {
+	Span
 	Expr  Node
 	Cases []MatchCase
 }
@@ -210,33 +279,105 @@ func (m *MatchStmt) nodeType() string { return "MatchStmt" }
 
 // MatchCase: pattern => body
 type MatchCase struct {
+	Span
 	Pattern Node // nil means wildcard _
 	Body    []Node
 }
 
 // WhileStmt: while condition { body }
 type WhileStmt struct {
+	Span
 	Condition Node
 	Body      []Node
 }
 
 func (w *WhileStmt) nodeType() string { return "WhileStmt" }
 
+// TryStmt: try { body } catch err { handler } finally { cleanup }
+type TryStmt struct {
+	Span
+	Body     []Node
+	ErrVar   string // name bound in the catch block; "" when omitted
+	Catch    []Node
+	HasCatch bool
+	Finally  []Node
+}
+
+func (t *TryStmt) nodeType() string { return "TryStmt" }
+
+// DeferStmt: defer stmt, or defer { body }
+type DeferStmt struct {
+	Span
+	Body []Node
+}
+
+func (d *DeferStmt) nodeType() string { return "DeferStmt" }
+
+// WithStmt: with name = tempfile() { body }
+type WithStmt struct {
+	Span
+	Var   string
+	Value Node
+	Body  []Node
+}
+
+func (w *WithStmt) nodeType() string { return "WithStmt" }
+
+// FlagsDecl: flags { name: type = default required env "VAR" "help", ... }
+type FlagsDecl struct {
+	Span
+	Flags []Flag
+}
+
+func (f *FlagsDecl) nodeType() string { return "FlagsDecl" }
+
+// Flag is one command-line flag of a flags block.
+type Flag struct {
+	Span
+	Name     string
+	Type     string
+	Default  Node   // nil if no default
+	Required bool   // the flag must be given, on the command line or through Env
+	Env      string // environment variable used when the flag is not given; "" for none
+	Help     string
+}
+
 // BreakStmt: break
-type BreakStmt struct{}
+type BreakStmt struct {
+	Span
+}
 
 func (b *BreakStmt) nodeType() string { return "BreakStmt" }
 
 // BashBlock: bash { raw content }
 type BashBlock struct {
+	Span
 	Content string
 }
 
 func (b *BashBlock) nodeType() string { return "BashBlock" }
 
-// ImportStmt: import "path.lz"
+// ImportStmt: import "path.lz", import "path.lz" as name, or
+// from "path.lz" import a, b
 type ImportStmt struct {
-	Path string
+	Span
+	Path  string
+	Alias string   // namespace for as imports; "" otherwise
+	Names []string // names listed by from imports; nil otherwise
 }
 
 func (i *ImportStmt) nodeType() string { return "ImportStmt" }
+
+// Namespace returns the name the imported module's members are reached
+// through: the alias, or the module name when a standard library module
+// (std/name) is imported whole. It is "" when the names are imported
+// directly.
+func (i *ImportStmt) Namespace() string {
+	if i.Alias != "" || i.Names != nil {
+		return i.Alias
+	}
+	if name, ok := strings.CutPrefix(i.Path, "std/"); ok {
+		return name
+	}
+	return ""
+}
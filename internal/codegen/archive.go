@@ -0,0 +1,174 @@
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/tasnimzotder/langz/internal/ast"
+	"github.com/tasnimzotder/langz/internal/codegen/builtins"
+)
+
+// archiveRuntime creates, extracts and lists tar.gz, tar.zst, tar and zip
+// archives. The format follows from the archive's name unless archive()
+// is given one, and each call checks for the tools its format needs, so a
+// script only needs zstd or zip if it actually meets such an archive.
+//
+// _archive dest format excludes source... writes dest afresh, removing it
+// again if anything fails. Every source must exist; excludes holds one
+// pattern per line, matched against any part of a path. _extract archive dest strip unpacks into
+// dest, creating it, and drops the first strip path components as tar's
+// --strip-components does. _list_archive prints one entry per line.
+const archiveRuntime = `_archive_format() {
+  local fmt=$3 tool
+  if [ -z "$fmt" ]; then
+    case $2 in
+      *.tar.gz | *.tgz) fmt=tar.gz ;;
+      *.tar.zst | *.tzst) fmt=tar.zst ;;
+      *.tar) fmt=tar ;;
+      *.zip) fmt=zip ;;
+      *)
+        printf '%s: cannot tell the format of %s from its name; want .tar.gz, .tgz, .tar.zst, .tar or .zip\n' "$1" "$2" >&2
+        return 1
+        ;;
+    esac
+  fi
+  case $fmt in
+    tar.gz) _archive_tools=(tar gzip) ;;
+    tar.zst) _archive_tools=(tar zstd) ;;
+    tar) _archive_tools=(tar) ;;
+    zip)
+      _archive_tools=(unzip)
+      [ "$1" != archive ] || _archive_tools=(zip)
+      ;;
+    *)
+      printf '%s: unknown format "%s", want tar.gz, tar.zst, tar or zip\n' "$1" "$fmt" >&2
+      return 1
+      ;;
+  esac
+  _archive_fmt=$fmt
+  for tool in "${_archive_tools[@]}"; do
+    if ! command -v "$tool" >/dev/null 2>&1; then
+      printf '%s: %s needs %s, which was not found; install it and try again\n' "$1" "$2" "$tool" >&2
+      return 127
+    fi
+  done
+}
+_archive_readable() {
+  if ! [ -f "$2" ] || ! [ -r "$2" ]; then
+    printf '%s: cannot read %s\n' "$1" "$2" >&2
+    return 1
+  fi
+}
+_archive() {
+  local dest=$1 _archive_fmt _archive_tools excludes=() opts=() e
+  _archive_format archive "$1" "$2" || return
+  [ -z "$3" ] || mapfile -t excludes <<< "$3"
+  shift 3
+  if [ $# -eq 0 ]; then
+    printf 'archive: %s: no sources given\n' "$dest" >&2
+    return 1
+  fi
+  for e in "$@"; do
+    if ! [ -e "$e" ]; then
+      printf 'archive: %s: %s does not exist\n' "$dest" "$e" >&2
+      return 1
+    fi
+  done
+  rm -f "$dest"
+  if [ "$_archive_fmt" = zip ]; then
+    for e in "${excludes[@]}"; do
+      opts+=(-x "$e" "*/$e" "$e/*" "*/$e/*")
+    done
+    zip -q -r "$dest" "$@" "${opts[@]}" || { rm -f "$dest"; return 1; }
+    return 0
+  fi
+  for e in "${excludes[@]}"; do
+    opts+=("--exclude=$e")
+  done
+  case $_archive_fmt in
+    tar.gz) tar -czf "$dest" "${opts[@]}" "$@" ;;
+    tar.zst) tar -cf - "${opts[@]}" "$@" | zstd -q -f -o "$dest" ;;
+    tar) tar -cf "$dest" "${opts[@]}" "$@" ;;
+  esac || { rm -f "$dest"; return 1; }
+}
+_extract() {
+  local src=$1 dest=$2 _archive_fmt _archive_tools opts=() tmp entry ok=true
+  _archive_format extract "$1" "" || return
+  _archive_readable extract "$src" || return
+  mkdir -p "$dest"
+  [ "$3" -eq 0 ] || opts=("--strip-components=$3")
+  case $_archive_fmt in
+    tar.gz) tar -xzf "$src" -C "$dest" "${opts[@]}" ;;
+    tar.zst) zstd -q -dc "$src" | tar -xf - -C "$dest" "${opts[@]}" ;;
+    tar) tar -xf "$src" -C "$dest" "${opts[@]}" ;;
+    zip)
+      if [ "$3" -eq 0 ]; then
+        unzip -q -o "$src" -d "$dest"
+      else
+        tmp=$(mktemp -d "$dest/.extract.XXXXXX") || return 1
+        if unzip -q -o "$src" -d "$tmp"; then
+          while IFS= read -r -d '' entry; do
+            cp -Rp "$entry" "$dest"/ || ok=false
+          done < <(find "$tmp" -mindepth $(($3 + 1)) -maxdepth $(($3 + 1)) -print0)
+        else
+          ok=false
+        fi
+        rm -rf "$tmp"
+        [ "$ok" = true ]
+      fi
+      ;;
+  esac
+}
+_list_archive() {
+  local _archive_fmt _archive_tools
+  _archive_format list_archive "$1" "" || return
+  _archive_readable list_archive "$1" || return
+  case $_archive_fmt in
+    tar.gz) tar -tzf "$1" ;;
+    tar.zst) zstd -q -dc "$1" | tar -tf - ;;
+    tar) tar -tf "$1" ;;
+    zip) unzip -Z1 "$1" ;;
+  esac
+}`
+
+// genArchive generates archive(dest, sources, format:, exclude:).
+func (g *Generator) genArchive(call *ast.FuncCall) string {
+	if len(call.Args) != 2 {
+		return "# error: archive() requires 2 arguments (dest, sources)"
+	}
+	g.useRuntime("archive", archiveRuntime)
+	format, excludes := `""`, `""`
+	if kw, ok := builtins.FindKwarg(call.KwArgs, "format"); ok {
+		format = quoteExpr(g.genExpr(kw))
+	}
+	if kw, ok := builtins.FindKwarg(call.KwArgs, "exclude"); ok {
+		excludes = g.genTemplateValue(kw)
+	}
+	return fmt.Sprintf("_archive %s %s %s %s", g.genExpr(call.Args[0]), format, excludes, g.genListWords(call.Args[1]))
+}
+
+// genExtract generates extract(archive, dest, strip:).
+func (g *Generator) genExtract(call *ast.FuncCall) string {
+	if len(call.Args) != 2 {
+		return "# error: extract() requires 2 arguments (archive, dest)"
+	}
+	g.useRuntime("archive", archiveRuntime)
+	strip := "0"
+	if kw, ok := builtins.FindKwarg(call.KwArgs, "strip"); ok {
+		strip = quoteExpr(g.genExpr(kw))
+	}
+	return fmt.Sprintf("_extract %s %s %s", g.genExpr(call.Args[0]), g.genExpr(call.Args[1]), strip)
+}
+
+// archiveListSource returns the command listing the entries of
+// list_archive(path), or path |> list_archive(), one per line.
+func (g *Generator) archiveListSource(node ast.Node) (string, bool) {
+	call, ok := node.(*ast.FuncCall)
+	if b, isPipe := node.(*ast.BinaryExpr); isPipe && b.Op == "|>" {
+		call, ok = pipeCall(b)
+	}
+	if !ok || call.Name != "list_archive" || len(call.Args) != 1 || g.funcs["list_archive"] != nil {
+		return "", false
+	}
+	g.useRuntime("archive", archiveRuntime)
+	return "_list_archive " + g.genExpr(call.Args[0]), true
+}
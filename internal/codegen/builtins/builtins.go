@@ -53,3 +53,11 @@ func FindKwarg(kwargs []ast.KeywordArg, key string) (ast.Node, bool) {
 }
 
 type builtinHandler func(args []ast.Node, kwargs []ast.KeywordArg, genExpr ExprGen, genRaw RawValueGen) string
+
+// varName returns the Bash variable a collection argument refers to.
+func varName(node ast.Node, genRaw RawValueGen) string {
+	if id, ok := node.(*ast.Identifier); ok {
+		return id.Name
+	}
+	return genRaw(node)
+}
@@ -38,6 +38,21 @@ var exprBuiltins = map[string]builtinHandler{
 		}
 		return fmt.Sprintf("[ -e %s ]", genExpr(args[0]))
 	},
+	"is_running": func(args []ast.Node, _ []ast.KeywordArg, genExpr ExprGen, _ RawValueGen) string {
+		if len(args) != 1 {
+			return "# error: is_running() requires 1 argument (job)"
+		}
+		return fmt.Sprintf("kill -0 %s 2>/dev/null", genExpr(args[0]))
+	},
+	"pid": func(args []ast.Node, _ []ast.KeywordArg, _ ExprGen, _ RawValueGen) string {
+		return "$$"
+	},
+	"wait": func(args []ast.Node, _ []ast.KeywordArg, _ ExprGen, _ RawValueGen) string {
+		return "# error: wait() must be assigned (code = wait(job)) or called as a statement"
+	},
+	"spawn": func(args []ast.Node, _ []ast.KeywordArg, _ ExprGen, _ RawValueGen) string {
+		return "# error: spawn() must be assigned (job = spawn(cmd)) or called as a statement"
+	},
 	"is_file": func(args []ast.Node, _ []ast.KeywordArg, genExpr ExprGen, _ RawValueGen) string {
 		if len(args) == 0 {
 			return "# error: is_file() requires 1 argument"
@@ -90,7 +105,25 @@ var exprBuiltins = map[string]builtinHandler{
 		if len(args) == 0 {
 			return "# error: len() requires 1 argument"
 		}
-		return fmt.Sprintf("${#%s[@]}", genRaw(args[0]))
+		return fmt.Sprintf("${#%s[@]}", varName(args[0], genRaw))
+	},
+	"keys": func(args []ast.Node, _ []ast.KeywordArg, _ ExprGen, genRaw RawValueGen) string {
+		if len(args) != 1 {
+			return "# error: keys() requires 1 argument (map)"
+		}
+		return fmt.Sprintf(`("${!%s[@]}")`, varName(args[0], genRaw))
+	},
+	"values": func(args []ast.Node, _ []ast.KeywordArg, _ ExprGen, genRaw RawValueGen) string {
+		if len(args) != 1 {
+			return "# error: values() requires 1 argument (map)"
+		}
+		return fmt.Sprintf(`("${%s[@]}")`, varName(args[0], genRaw))
+	},
+	"has": func(args []ast.Node, _ []ast.KeywordArg, genExpr ExprGen, genRaw RawValueGen) string {
+		if len(args) != 2 {
+			return "# error: has() requires 2 arguments (map, key)"
+		}
+		return fmt.Sprintf("[[ -v %s[%s] ]]", varName(args[0], genRaw), genExpr(args[1]))
 	},
 	"trim": func(args []ast.Node, _ []ast.KeywordArg, genExpr ExprGen, _ RawValueGen) string {
 		if len(args) == 0 {
@@ -116,6 +149,55 @@ var exprBuiltins = map[string]builtinHandler{
 		}
 		return fmt.Sprintf("$(echo %s | jq -r %s)", genExpr(args[0]), genExpr(args[1]))
 	},
+	"parse_json": func(args []ast.Node, _ []ast.KeywordArg, genExpr ExprGen, _ RawValueGen) string {
+		if len(args) != 1 {
+			return "# error: parse_json() requires 1 argument (text)"
+		}
+		return fmt.Sprintf("$(jq -c . <<< %s)", genExpr(args[0]))
+	},
+	"to_json": func(_ []ast.Node, _ []ast.KeywordArg, _ ExprGen, _ RawValueGen) string {
+		return "# error: to_json() requires 1 argument (value)"
+	},
+	"matches": func(args []ast.Node, _ []ast.KeywordArg, genExpr ExprGen, _ RawValueGen) string {
+		if len(args) != 2 {
+			return "# error: matches() requires 2 arguments (string, pattern)"
+		}
+		return regexTest(args[0], args[1], genExpr)
+	},
+	"regex_find": func(args []ast.Node, _ []ast.KeywordArg, genExpr ExprGen, _ RawValueGen) string {
+		if len(args) != 2 {
+			return "# error: regex_find() requires 2 arguments (string, pattern)"
+		}
+		return fmt.Sprintf(`$(if %s; then printf '%%s\n' "${BASH_REMATCH[@]:1}"; fi)`, regexTest(args[0], args[1], genExpr))
+	},
+	"replace_regex": func(args []ast.Node, _ []ast.KeywordArg, genExpr ExprGen, _ RawValueGen) string {
+		if len(args) != 3 {
+			return "# error: replace_regex() requires 3 arguments (string, pattern, replacement)"
+		}
+		return regexReplace(args[0], args[1], args[2], genExpr)
+	},
+	"round": func(args []ast.Node, _ []ast.KeywordArg, _ ExprGen, genRaw RawValueGen) string {
+		if len(args) == 0 {
+			return "# error: round() requires 1 or 2 arguments (value, digits)"
+		}
+		// Round half away from zero; awk's printf "%.0f" rounds half to even
+		if len(args) == 1 {
+			return fmt.Sprintf(`$(awk -v x="%s" 'BEGIN { print (x < 0 ? -int(-x + 0.5) : int(x + 0.5)) }')`, genRaw(args[0]))
+		}
+		return fmt.Sprintf(`$(awk -v x="%s" -v d="%s" 'BEGIN { OFMT = "%%.10g"; p = 10 ^ d; print (x < 0 ? -int(-x * p + 0.5) : int(x * p + 0.5)) / p }')`, genRaw(args[0]), genRaw(args[1]))
+	},
+	"floor": func(args []ast.Node, _ []ast.KeywordArg, _ ExprGen, genRaw RawValueGen) string {
+		if len(args) == 0 {
+			return "# error: floor() requires 1 argument"
+		}
+		return fmt.Sprintf(`$(awk -v x="%s" 'BEGIN { i = int(x); print (x < i ? i - 1 : i) }')`, genRaw(args[0]))
+	},
+	"ceil": func(args []ast.Node, _ []ast.KeywordArg, _ ExprGen, genRaw RawValueGen) string {
+		if len(args) == 0 {
+			return "# error: ceil() requires 1 argument"
+		}
+		return fmt.Sprintf(`$(awk -v x="%s" 'BEGIN { i = int(x); print (x > i ? i + 1 : i) }')`, genRaw(args[0]))
+	},
 	"timestamp": func(_ []ast.Node, _ []ast.KeywordArg, _ ExprGen, _ RawValueGen) string {
 		return "$(date +%s)"
 	},
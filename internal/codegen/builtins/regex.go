@@ -0,0 +1,25 @@
+package builtins
+
+import (
+	"fmt"
+
+	"github.com/tasnimzotder/langz/internal/ast"
+)
+
+// regexTest renders a [[ =~ ]] test against a POSIX extended regex.
+// Bash only treats the right-hand side as a regex when it is unquoted, so
+// anything but a plain variable is assigned to _re first.
+func regexTest(subject, pattern ast.Node, genExpr ExprGen) string {
+	if id, ok := pattern.(*ast.Identifier); ok {
+		return fmt.Sprintf("[[ %s =~ $%s ]]", genExpr(subject), id.Name)
+	}
+	return fmt.Sprintf("{ _re=%s; [[ %s =~ $_re ]]; }", genExpr(pattern), genExpr(subject))
+}
+
+// regexReplace renders a sed -E substitution of every match. Slashes in
+// the pattern and replacement are escaped at runtime so they can't end
+// the s/// expression early.
+func regexReplace(subject, pattern, repl ast.Node, genExpr ExprGen) string {
+	return fmt.Sprintf(`$(_re=%s; _to=%s; printf '%%s\n' %s | sed -E "s/${_re//\//\\/}/${_to//\//\\/}/g")`,
+		genExpr(pattern), genExpr(repl), genExpr(subject))
+}
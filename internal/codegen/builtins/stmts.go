@@ -8,13 +8,29 @@ import (
 )
 
 var stmtBuiltins = map[string]builtinHandler{
+	"exec": func(args []ast.Node, _ []ast.KeywordArg, _ ExprGen, genRaw RawValueGen) string {
+		if len(args) == 0 {
+			return "# error: exec() requires 1 argument"
+		}
+		// As a statement the command runs directly, with its output shown
+		return genRaw(args[0])
+	},
 	"print": func(args []ast.Node, _ []ast.KeywordArg, genExpr ExprGen, _ RawValueGen) string {
 		if len(args) == 0 {
 			return "echo"
 		}
+		if len(args) == 1 && isText(args[0]) {
+			return genOutput(args[0], "", genExpr)
+		}
 		parts := make([]string, len(args))
+		hasText := false
 		for i, arg := range args {
 			parts[i] = genExpr(arg)
+			hasText = hasText || isText(arg)
+		}
+		if hasText {
+			format := strings.TrimSuffix(strings.Repeat("%s ", len(args)), " ")
+			return fmt.Sprintf(`printf '%s\n' %s`, format, strings.Join(parts, " "))
 		}
 		return fmt.Sprintf("echo %s", strings.Join(parts, " "))
 	},
@@ -22,13 +38,13 @@ var stmtBuiltins = map[string]builtinHandler{
 		if len(args) != 2 {
 			return "# error: write() requires 2 arguments (path, content)"
 		}
-		return fmt.Sprintf("echo %s > %s", genExpr(args[1]), genExpr(args[0]))
+		return genOutput(args[1], " > "+genExpr(args[0]), genExpr)
 	},
 	"append": func(args []ast.Node, _ []ast.KeywordArg, genExpr ExprGen, _ RawValueGen) string {
 		if len(args) != 2 {
 			return "# error: append() requires 2 arguments (path, content)"
 		}
-		return fmt.Sprintf("echo %s >> %s", genExpr(args[1]), genExpr(args[0]))
+		return genOutput(args[1], " >> "+genExpr(args[0]), genExpr)
 	},
 	"rm": func(args []ast.Node, _ []ast.KeywordArg, genExpr ExprGen, _ RawValueGen) string {
 		if len(args) == 0 {
@@ -66,6 +82,34 @@ var stmtBuiltins = map[string]builtinHandler{
 		}
 		return fmt.Sprintf("chmod %s %s", genRaw(args[1]), genExpr(args[0]))
 	},
+	"wait": func(args []ast.Node, _ []ast.KeywordArg, genExpr ExprGen, _ RawValueGen) string {
+		if len(args) != 1 {
+			return "# error: wait() requires 1 argument (job)"
+		}
+		return fmt.Sprintf("wait %s", genExpr(args[0]))
+	},
+	"wait_all": func(args []ast.Node, _ []ast.KeywordArg, _ ExprGen, _ RawValueGen) string {
+		return "wait"
+	},
+	"kill": func(args []ast.Node, kwargs []ast.KeywordArg, genExpr ExprGen, genRaw RawValueGen) string {
+		if len(args) != 1 {
+			return "# error: kill() requires 1 argument (job)"
+		}
+		// A job leads its own process group, signalled as a whole. A job
+		// that already exited is not an error
+		if sig, ok := FindKwarg(kwargs, "signal"); ok {
+			return fmt.Sprintf("kill -s %s -- -%s 2>/dev/null || true", genRaw(sig), genExpr(args[0]))
+		}
+		return fmt.Sprintf("kill -- -%s 2>/dev/null || true", genExpr(args[0]))
+	},
+	"delete": func(args []ast.Node, _ []ast.KeywordArg, genExpr ExprGen, genRaw RawValueGen) string {
+		if len(args) != 2 {
+			return "# error: delete() requires 2 arguments (map, key)"
+		}
+		// The key goes through a variable, single-quoted so unset expands
+		// it exactly once whatever it holds
+		return fmt.Sprintf("_key=%s; unset '%s[$_key]'", genExpr(args[1]), varName(args[0], genRaw))
+	},
 	"exit": func(args []ast.Node, _ []ast.KeywordArg, _ ExprGen, genRaw RawValueGen) string {
 		if len(args) == 0 {
 			return "exit 0"
@@ -85,3 +129,39 @@ var stmtBuiltins = map[string]builtinHandler{
 		return fmt.Sprintf("chown %s %s", genRaw(args[1]), genExpr(args[0]))
 	},
 }
+
+// isText reports whether node is a triple-quoted string literal.
+func isText(node ast.Node) bool {
+	lit, ok := node.(*ast.StringLiteral)
+	return ok && lit.Multiline
+}
+
+// genOutput writes content followed by a newline, optionally redirected.
+// A raw triple-quoted literal becomes a quoted heredoc; anything else goes
+// through printf, which unlike echo leaves backslashes and leading dashes
+// alone.
+func genOutput(content ast.Node, redirect string, genExpr ExprGen) string {
+	if lit, ok := content.(*ast.StringLiteral); ok && lit.Raw {
+		delim := heredocDelimiter(lit.Value)
+		return fmt.Sprintf("cat%s <<'%s'\n%s\n%s", redirect, delim, lit.Value, delim)
+	}
+	return fmt.Sprintf(`printf '%%s\n' %s%s`, genExpr(content), redirect)
+}
+
+// heredocDelimiter picks a delimiter that does not occur as a line of text.
+func heredocDelimiter(text string) string {
+	delim := "EOF"
+	for {
+		clash := false
+		for _, line := range strings.Split(text, "\n") {
+			if line == delim {
+				clash = true
+				break
+			}
+		}
+		if !clash {
+			return delim
+		}
+		delim += "_"
+	}
+}
@@ -11,29 +11,169 @@ import (
 type Generator struct {
 	buf    strings.Builder
 	indent int
+
+	// funcs maps user-defined function names to their declarations so
+	// call sites know whether a function returns a value.
+	funcs map[string]*ast.FuncDecl
+	// returnType is the declared return type of the function currently
+	// being generated ("" at top level or in a plain fn).
+	returnType string
+	// floats holds the variables and parameters known to hold floats, so
+	// arithmetic and comparisons on them are generated with awk.
+	floats map[string]bool
+	// maps holds the variables and parameters known to hold maps, which
+	// are associative arrays passed to functions by name.
+	maps map[string]bool
+	// jsons holds the variables holding JSON text, whose fields and
+	// elements are read and updated with jq.
+	jsons map[string]bool
+	// lists holds the variables known to hold Bash arrays.
+	lists map[string]bool
+	// typed holds, for map and list variables, the keys or indices last
+	// assigned a number or bool, which to_json() gives back that type.
+	typed map[string]map[string]bool
+	// mapParams maps the map and list parameters of the function being
+	// generated, which are passed by name, to their positions; nil at top
+	// level.
+	mapParams map[string]int
+	// tries numbers try blocks so nested ones keep separate state, and
+	// tryFrames holds the try blocks whose bodies are being generated in
+	// the current function, innermost last.
+	tries     int
+	tryFrames []*tryFrame
+	// pipes numbers loops over lines() so nested ones keep separate file
+	// descriptors.
+	pipes int
+	// renders numbers the variables render() values are computed into
+	// ahead of the statement using them, and inlineDepth is non-zero
+	// while generating a while or elif condition, which has no place
+	// ahead of it to compute them in.
+	renders     int
+	inlineDepth int
+	// shellValues holds the Bash for placeholder identifiers standing for
+	// the output of a pipeline that builtin pipe stages read.
+	shellValues map[*ast.Identifier]string
+	// runtime holds helper snippets the script needs, emitted once after
+	// the preamble in the order they were first requested.
+	runtime []runtimeSnippet
+
+	// span is the statement currently being generated; # error: markers
+	// emitted while it is active are reported at its position.
+	span    ast.Span
+	scanned int
+	errs    []Error
+}
+
+// Error is a codegen error located at the statement that produced it.
+type Error struct {
+	Span    ast.Span
+	Message string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("line %d, col %d: %s", e.Span.Start.Line, e.Span.Start.Col, e.Message)
 }
 
 // Generate converts an AST program into a Bash script string.
 // Returns the generated Bash and any codegen errors found.
-func Generate(prog *ast.Program) (output string, errs []string) {
+func Generate(prog *ast.Program) (output string, errs []Error) {
 	defer func() {
 		if r := recover(); r != nil {
 			output = ""
-			errs = []string{fmt.Sprintf("internal error: %v", r)}
+			errs = []Error{{Message: fmt.Sprintf("internal error: %v", r)}}
 		}
 	}()
-	g := &Generator{}
+	g := &Generator{funcs: collectFuncs(prog.Statements), floats: make(map[string]bool), maps: make(map[string]bool),
+		jsons: make(map[string]bool), lists: make(map[string]bool), typed: make(map[string]map[string]bool), shellValues: make(map[*ast.Identifier]string)}
 	g.writeln("#!/bin/bash")
 	g.writeln("set -euo pipefail")
 	g.writeln("")
 
+	header := g.buf.Len()
+
 	for _, stmt := range prog.Statements {
 		g.genStatement(stmt)
 	}
 
-	output = strings.TrimRight(g.buf.String(), "\n") + "\n"
-	errs = findCodegenErrors(output)
-	return output, errs
+	g.collectErrors()
+	output = g.buf.String()
+	output = output[:header] + g.runtimeCode() + output[header:]
+	output = strings.TrimRight(output, "\n") + "\n"
+	return output, g.errs
+}
+
+type runtimeSnippet struct {
+	name string
+	code string
+}
+
+// useRuntime requests a helper snippet; repeated requests are ignored.
+func (g *Generator) useRuntime(name, code string) {
+	for _, r := range g.runtime {
+		if r.name == name {
+			return
+		}
+	}
+	g.runtime = append(g.runtime, runtimeSnippet{name: name, code: code})
+}
+
+func (g *Generator) runtimeCode() string {
+	var b strings.Builder
+	for _, r := range g.runtime {
+		b.WriteString(r.code)
+		b.WriteString("\n\n")
+	}
+	return b.String()
+}
+
+// enterStatement attributes pending output to the enclosing statement,
+// then makes node the current statement. It returns the enclosing span
+// for leaveStatement to restore.
+func (g *Generator) enterStatement(node ast.Node) ast.Span {
+	g.collectErrors()
+	outer := g.span
+	g.span = node.NodeSpan()
+	return outer
+}
+
+func (g *Generator) leaveStatement(outer ast.Span) {
+	g.collectErrors()
+	g.span = outer
+}
+
+// collectErrors records # error: markers written since the last scan
+// at the current statement's position.
+func (g *Generator) collectErrors() {
+	out := g.buf.String()
+	for _, msg := range findCodegenErrors(out[g.scanned:]) {
+		g.errs = append(g.errs, Error{Span: g.span, Message: msg})
+	}
+	g.scanned = len(out)
+}
+
+// collectFuncs indexes every function declaration in the program by name.
+func collectFuncs(stmts []ast.Node) map[string]*ast.FuncDecl {
+	funcs := make(map[string]*ast.FuncDecl)
+	for _, stmt := range stmts {
+		if fn, ok := stmt.(*ast.FuncDecl); ok {
+			funcs[fn.Name] = fn
+		}
+	}
+	return funcs
+}
+
+// returnsValue reports whether a user function passes its result back on
+// stdout (declared with a non-bool return type) rather than as an exit status.
+func (g *Generator) returnsValue(name string) bool {
+	fn, ok := g.funcs[name]
+	return ok && isValueType(fn.ReturnType)
+}
+
+// isValueType reports whether a declared return type is returned as a value.
+// Functions without a return type, or returning bool, use the exit status so
+// they keep working as conditions.
+func isValueType(t string) bool {
+	return t != "" && t != "bool"
 }
 
 // findCodegenErrors scans generated Bash for # error: markers
@@ -43,12 +183,27 @@ func findCodegenErrors(output string) []string {
 	for _, line := range strings.Split(output, "\n") {
 		trimmed := strings.TrimSpace(line)
 		if idx := strings.Index(trimmed, "# error:"); idx >= 0 {
-			errs = append(errs, strings.TrimSpace(trimmed[idx+len("# error:"):]))
+			errs = append(errs, trimEnclosing(strings.TrimSpace(trimmed[idx+len("# error:"):])))
 		}
 	}
 	return errs
 }
 
+// trimEnclosing drops the closing parens and quotes of the $( ) or "..."
+// a marker was generated inside, which are left unbalanced at its end.
+func trimEnclosing(msg string) string {
+	for {
+		switch {
+		case strings.HasSuffix(msg, ")") && strings.Count(msg, ")") > strings.Count(msg, "("):
+			msg = strings.TrimSuffix(msg, ")")
+		case strings.HasSuffix(msg, `"`) && strings.Count(msg, `"`)%2 == 1:
+			msg = strings.TrimSuffix(msg, `"`)
+		default:
+			return strings.TrimSpace(msg)
+		}
+	}
+}
+
 func (g *Generator) write(s string) {
 	g.buf.WriteString(s)
 }
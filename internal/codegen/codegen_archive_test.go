@@ -0,0 +1,34 @@
+package codegen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchive(t *testing.T) {
+	output := body(compile("archive(\"app.tar.gz\", [\"src\", dir])\narchive(dest, files, format: \"zip\", exclude: [\"*.log\", \".git\"])"))
+
+	assert.Contains(t, output, `_archive "app.tar.gz" "" "" "src" "$dir"`)
+	assert.Contains(t, output, `_archive "$dest" "zip" "$(printf '%s\n' "*.log" ".git")" "${files[@]}"`)
+}
+
+func TestExtract(t *testing.T) {
+	output := body(compile("extract(\"app.zip\", \"out\")\nextract(src, dest, strip: 1)"))
+
+	assert.Contains(t, output, `_extract "app.zip" "out" 0`)
+	assert.Contains(t, output, `_extract "$src" "$dest" "1"`)
+}
+
+func TestListArchive(t *testing.T) {
+	output := body(compile("entries = list_archive(\"app.tar.zst\")\nprint(len(entries))\nfor e in list_archive(path) {\n\tprint(e)\n}"))
+
+	assert.Contains(t, output, "mapfile -t entries < <(_list_archive \"app.tar.zst\")\nwait $!\necho ${#entries[@]}")
+	assert.Contains(t, output, `exec {_lines_fd1}< <(_list_archive "$path")`)
+}
+
+func TestListArchiveOnlyAssigned(t *testing.T) {
+	_, errs := compileWithErrors(`print(list_archive("app.zip"))`)
+
+	assert.NotEmpty(t, errs)
+}
@@ -81,8 +81,7 @@ func TestEmptyListCodegen(t *testing.T) {
 func TestMapLiteralCodegen(t *testing.T) {
 	output := body(compile(`config = {port: 8080, host: "localhost"}`))
 
-	assert.Contains(t, output, `config_port=8080`)
-	assert.Contains(t, output, `config_host="localhost"`)
+	assert.Equal(t, `declare -A config=(["port"]=8080 ["host"]="localhost")`, output)
 }
 
 func TestStringDollarEscaping(t *testing.T) {
@@ -104,18 +103,35 @@ func TestRawValueEscaping(t *testing.T) {
 	assert.NotContains(t, output, `;`)
 }
 
-func TestMapKeySanitization(t *testing.T) {
+func TestMapKeyWithHyphen(t *testing.T) {
 	output := body(compile(`x = config["my-key"]`))
 
-	// Hyphens in map keys should be sanitized to underscores
-	assert.Contains(t, output, `config_my_key`)
+	// Associative array keys need no sanitizing
+	assert.Contains(t, output, `x="${config["my-key"]}"`)
 }
 
 func TestCodegenErrorDetection(t *testing.T) {
 	_, errs := compileWithErrors(`write("file.txt")`)
 
 	require.Len(t, errs, 1)
-	assert.Contains(t, errs[0], "write()")
+	assert.Contains(t, errs[0].Message, "write()")
+}
+
+func TestCodegenErrorPosition(t *testing.T) {
+	_, errs := compileWithErrors("x = 1\nif x > 0 {\n    copy(\"a\")\n}")
+
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Message, "copy()")
+	assert.Equal(t, ast.Pos{Line: 3, Col: 5}, errs[0].Span.Start)
+	assert.Equal(t, ast.Pos{Line: 3, Col: 14}, errs[0].Span.End)
+}
+
+func TestCodegenErrorInConditionUsesEnclosingStatement(t *testing.T) {
+	_, errs := compileWithErrors("print(1)\nwhile x.frobnicate() {\n    print(x)\n}")
+
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Message, "unknown method frobnicate")
+	assert.Equal(t, ast.Pos{Line: 2, Col: 1}, errs[0].Span.Start)
 }
 
 func TestCodegenNoErrorOnValidCode(t *testing.T) {
@@ -134,7 +150,7 @@ func TestUnhandledStatementTypeProducesError(t *testing.T) {
 	_, errs := Generate(prog)
 
 	require.Len(t, errs, 1)
-	assert.Contains(t, errs[0], "unhandled statement type")
+	assert.Contains(t, errs[0].Message, "unhandled statement type")
 }
 
 func TestUnhandledExpressionTypeProducesError(t *testing.T) {
@@ -151,5 +167,5 @@ func TestUnhandledExpressionTypeProducesError(t *testing.T) {
 	_, errs := Generate(prog)
 
 	require.Len(t, errs, 1)
-	assert.Contains(t, errs[0], "unhandled expression type")
+	assert.Contains(t, errs[0].Message, "unhandled expression type")
 }
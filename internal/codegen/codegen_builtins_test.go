@@ -33,7 +33,7 @@ func TestReadBuiltin(t *testing.T) {
 func TestWriteBuiltin(t *testing.T) {
 	output := body(compile(`write("out.txt", "hello")`))
 
-	assert.Contains(t, output, `echo "hello" > "out.txt"`)
+	assert.Contains(t, output, `printf '%s\n' "hello" > "out.txt"`)
 }
 
 func TestRmBuiltin(t *testing.T) {
@@ -95,7 +95,7 @@ func TestSleepBuiltin(t *testing.T) {
 func TestAppendBuiltin(t *testing.T) {
 	output := body(compile(`append("log.txt", "entry")`))
 
-	assert.Contains(t, output, `echo "entry" >> "log.txt"`)
+	assert.Contains(t, output, `printf '%s\n' "entry" >> "log.txt"`)
 }
 
 func TestHostnameBuiltin(t *testing.T) {
@@ -169,3 +169,9 @@ func TestArgsBuiltin(t *testing.T) {
 
 	assert.Contains(t, output, `params=("$@")`)
 }
+
+func TestExecStatementRunsCommand(t *testing.T) {
+	output := body(compile(`exec("make build")`))
+
+	assert.Equal(t, "make build", output)
+}
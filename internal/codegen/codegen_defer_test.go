@@ -0,0 +1,60 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeferCapturesVariables(t *testing.T) {
+	output := body(compile("tmp = exec(\"mktemp -d\")\ndefer rmdir(tmp)"))
+
+	assert.Contains(t, output, `_defer 'rm -rf "$tmp"' tmp`)
+}
+
+func TestDeferBlock(t *testing.T) {
+	output := body(compile("defer {\n\trm(\"a\")\n\tprint(\"it's done\")\n}"))
+
+	assert.Contains(t, output, "_defer '\n  rm -f \"a\"\n  echo \"it'\\''s done\"\n'")
+}
+
+func TestDeferRuntimeOnce(t *testing.T) {
+	output := compile("defer rm(\"a\")\ndefer rm(\"b\")")
+
+	assert.Equal(t, 1, strings.Count(output, "_defers=()"))
+	assert.Equal(t, 1, strings.Count(output, "trap '_run_defers 0' EXIT"))
+	assert.Contains(t, output, "trap 'exit 130' INT")
+	assert.Contains(t, output, "trap 'exit 143' TERM")
+}
+
+func TestNoDeferRuntimeWithoutDefer(t *testing.T) {
+	output := compile("fn f() {\n\tprint(\"hi\")\n}")
+
+	assert.NotContains(t, output, "_defer")
+}
+
+func TestDeferInFunctionStartsFrame(t *testing.T) {
+	output := body(compile("fn f(dir: str) {\n\tif exists(dir) {\n\t\tdefer rmdir(dir)\n\t}\n}"))
+
+	assert.Contains(t, output, "f() {\n  local dir=\"$1\"\n  local _defer_frame=${#FUNCNAME[@]} _defer_base=${#_defers[@]}\n  trap '_return_defers \"${#FUNCNAME[@]}\"' RETURN\n")
+}
+
+func TestDeferInValueFunctionWritesToStderr(t *testing.T) {
+	output := body(compile("fn f() -> str {\n\tdefer print(\"bye\")\n\treturn \"x\"\n}"))
+
+	assert.Contains(t, output, `trap '_return_defers "${#FUNCNAME[@]}" >&2' RETURN`)
+}
+
+func TestDeferInTryKeepsStack(t *testing.T) {
+	output := body(compile("try {\n\tdefer print(\"bye\")\n} catch {\n\tprint(\"failed\")\n}"))
+
+	assert.Contains(t, output, "_try1_body() {\n  _try_line=2\n  _defer 'echo \"bye\"'\n")
+}
+
+func TestSpawnAndFetchCleanUpThroughDefers(t *testing.T) {
+	output := compile("job = spawn(\"sleep 5\")\nresp = fetch(\"http://localhost\")")
+
+	assert.Contains(t, output, `_defers+=('rm -rf "$_temp_root"')`)
+	assert.Less(t, strings.Index(output, "trap '_run_defers 0' EXIT"), strings.Index(output, "_defers+=('_cleanup_jobs"))
+}
@@ -0,0 +1,49 @@
+package codegen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDigestCalls(t *testing.T) {
+	output := compile("a = sha256(\"app.tar.gz\")\nb = sha1(name)\nc = md5(\"x\")")
+
+	assert.Contains(t, output, `a="$(_digest sha256 "app.tar.gz")"`)
+	assert.Contains(t, output, `b="$(_digest sha1 "$name")"`)
+	assert.Contains(t, output, `c="$(_digest md5 "x")"`)
+	assert.Contains(t, output, "_digest_tool sha256 || exit 127\n")
+	assert.Contains(t, output, "_digest_tool md5 || exit 127\n")
+	assert.NotContains(t, output, "_base64_tool")
+}
+
+func TestEncodeCalls(t *testing.T) {
+	output := compile("a = base64_encode(s)\nb = base64_decode(a)\nc = hex_encode(s)\nd = url_encode(s)\ne = url_decode(d)")
+
+	assert.Contains(t, output, `a="$(_base64_encode "$s")"`)
+	assert.Contains(t, output, `b="$(_base64_decode "$a")"`)
+	assert.Contains(t, output, `c="$(_hex_encode "$s")"`)
+	assert.Contains(t, output, `d="$(_url_encode "$s")"`)
+	assert.Contains(t, output, `e="$(_url_decode "$d")"`)
+	assert.Contains(t, output, "_base64_tool || exit 127\n")
+	assert.NotContains(t, output, "_digest_tool")
+}
+
+func TestURLEncodeNeedsNoTools(t *testing.T) {
+	output := compile(`print(url_encode("a b"))`)
+
+	assert.NotContains(t, output, "_base64_tool || exit 127")
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	output := body(compile("if verify_checksum(\"app.tar.gz\", sum) {\n\tprint(\"ok\")\n}\nok = verify_checksum(f, sum)"))
+
+	assert.Contains(t, output, `if _verify_checksum "app.tar.gz" "$sum"; then`)
+	assert.Contains(t, output, `ok="$(_verify_checksum "$f" "$sum" && echo true || echo false)"`)
+}
+
+func TestDigestArgCount(t *testing.T) {
+	_, errs := compileWithErrors(`x = sha256("a", "b")`)
+
+	assert.NotEmpty(t, errs)
+}
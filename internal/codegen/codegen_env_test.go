@@ -0,0 +1,34 @@
+package codegen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadEnv(t *testing.T) {
+	output := body(compile("load_env(\".env\")\nload_env(\"prod.env\", override: true)"))
+
+	assert.Contains(t, output, `_load_env ".env" false`)
+	assert.Contains(t, output, `_load_env "prod.env" "true"`)
+}
+
+func TestReadIniDeclaresMap(t *testing.T) {
+	output := body(compile("cfg = read_ini(\"app.ini\")\nprint(cfg[\"db.host\"])\nfn load() {\n\tlocal_cfg = read_ini(\"x.ini\")\n}"))
+
+	assert.Contains(t, output, "declare -A cfg=()\n_read_ini \"app.ini\" cfg")
+	assert.Contains(t, output, "  declare -gA local_cfg=()\n  _read_ini \"x.ini\" local_cfg")
+}
+
+func TestReadIniOnlyAssigned(t *testing.T) {
+	_, errs := compileWithErrors(`print(read_ini("app.ini"))`)
+
+	assert.NotEmpty(t, errs)
+}
+
+func TestWriteEnv(t *testing.T) {
+	output := body(compile("settings = {A: \"1\"}\nwrite_env(\".env\", settings)\nwrite_env(\"b.env\", {PORT: 8080, NAME: name})"))
+
+	assert.Contains(t, output, `_write_env ".env" settings`)
+	assert.Contains(t, output, `_write_env "b.env" "" "PORT" "8080" "NAME" "$name"`)
+}
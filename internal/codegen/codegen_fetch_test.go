@@ -9,8 +9,8 @@ import (
 func TestFetchSimpleGET(t *testing.T) {
 	output := body(compile(`data = fetch("https://api.example.com/health")`))
 
-	assert.Contains(t, output, `_tmp_headers=$(mktemp)`)
-	assert.Contains(t, output, `_tmp_body=$(mktemp)`)
+	assert.Contains(t, output, `_tmp_headers=$(_temp_new fetch "")`)
+	assert.Contains(t, output, `_tmp_body=$(_temp_new fetch "")`)
 	assert.Contains(t, output, `curl -s -w "%{http_code}"`)
 	assert.Contains(t, output, `-D "$_tmp_headers"`)
 	assert.Contains(t, output, `-o "$_tmp_body"`)
@@ -0,0 +1,58 @@
+package codegen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlagsParseLoop(t *testing.T) {
+	output := compile(`flags { env: str = "staging", replicas: int = 2, dry_run: bool, targets: list }`)
+
+	assert.Contains(t, output, "_flags_error() {")
+	assert.Contains(t, output, "unset -v env replicas dry_run\ntargets=()\n_args=()\n")
+	assert.Contains(t, output, `--env) [ $# -ge 2 ] || _flags_error "--env needs a value"; env=$2; shift ;;`)
+	assert.Contains(t, output, "--env=*) env=${1#*=} ;;")
+	assert.Contains(t, output, "--dry-run) dry_run=true ;;\n")
+	assert.Contains(t, output, "--no-dry-run) dry_run=false ;;\n")
+	assert.Contains(t, output, `--targets=*) targets+=("${1#*=}") ;;`)
+	assert.Contains(t, output, `-?*) _flags_error "unknown flag: $1" ;;`)
+	assert.Contains(t, output, `set -- "${_args[@]}"`)
+}
+
+func TestFlagsFallbacksAndValidation(t *testing.T) {
+	output := body(compile(`flags {
+    env: str = "staging" env "DEPLOY_ENV"
+    replicas: int
+    token: str required
+    hosts: list = ["a", "b"] env "HOSTS"
+}`))
+
+	assert.Contains(t, output, `[ -n "${env+x}" ] || [ -z "${DEPLOY_ENV:-}" ] || env=$DEPLOY_ENV`+"\n"+
+		`[ -n "${env+x}" ] || env="staging"`)
+	assert.Contains(t, output, `[ -n "${replicas+x}" ] || replicas=0`)
+	assert.Contains(t, output, `[[ $replicas =~ ^-?[0-9]+$ ]] || _flags_error "--replicas must be an int, got '$replicas'"`)
+	assert.Contains(t, output, `[ -n "${token+x}" ] || _flags_error "missing required flag --token"`)
+	assert.Contains(t, output, `IFS=, read -ra hosts <<< "$HOSTS"`)
+	assert.Contains(t, output, `[ ${#hosts[@]} -gt 0 ] || hosts=("a" "b")`)
+}
+
+func TestFlagsUsage(t *testing.T) {
+	output := body(compile(`flags {
+    env: str = "staging" env "DEPLOY_ENV" "Where to deploy"
+    dry_run: bool "Print the plan only"
+    hosts: list required
+}`))
+
+	assert.Contains(t, output, `printf 'Usage: %s [options] [args...]\n\n' "${0##*/}"`)
+	assert.Contains(t, output, `'  --env <str>         Where to deploy (default: staging, env: DEPLOY_ENV)' \`)
+	assert.Contains(t, output, `'  --[no-]dry-run      Print the plan only' \`)
+	assert.Contains(t, output, `'  --hosts <value>...  (required)' \`)
+	assert.Contains(t, output, `'  -h, --help          Show this help'`)
+}
+
+func TestFlagsListIsAList(t *testing.T) {
+	output := body(compile("flags { targets: list }\nfor t in targets {\n\tprint(t)\n}"))
+
+	assert.Contains(t, output, `for t in "${targets[@]}"; do`)
+}
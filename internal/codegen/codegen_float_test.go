@@ -0,0 +1,90 @@
+package codegen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFloatLiteralCodegen(t *testing.T) {
+	output := body(compile(`ratio = 0.75`))
+
+	assert.Equal(t, `ratio=0.75`, output)
+}
+
+func TestIntArithmeticStaysInBash(t *testing.T) {
+	output := body(compile(`x = 7 / 2`))
+
+	assert.Equal(t, `x=$((7 / 2))`, output)
+}
+
+func TestFloatArithmeticUsesAwk(t *testing.T) {
+	output := body(compile(`x = 7 / 2.0`))
+
+	assert.Equal(t, `x=$(awk 'BEGIN { OFMT = "%.10g"; print 7 / 2.0 }')`, output)
+}
+
+func TestFloatPromotesWholeExpression(t *testing.T) {
+	// used / total must not be truncated before the float multiply
+	output := body(compile("used = 3\ntotal = 4\nratio = used / total * 100.0"))
+
+	assert.Contains(t, output, `ratio=$(awk -v v1="$used" -v v2="$total" 'BEGIN { OFMT = "%.10g"; print v1 / v2 * 100.0 }')`)
+}
+
+func TestFloatArithmeticPrecedence(t *testing.T) {
+	output := body(compile(`x = (1.5 + 2) * 3`))
+
+	assert.Contains(t, output, `print (1.5 + 2) * 3`)
+}
+
+func TestFloatVariableIsTracked(t *testing.T) {
+	output := body(compile("x = 1.5\nx += 1\ny = x * 2"))
+
+	assert.Contains(t, output, `x=$(awk -v v1="$x" 'BEGIN { OFMT = "%.10g"; print v1 + 1 }')`)
+	assert.Contains(t, output, `y=$(awk -v v1="$x" 'BEGIN { OFMT = "%.10g"; print v1 * 2 }')`)
+}
+
+func TestFloatOperandBoundOnce(t *testing.T) {
+	output := body(compile("x = 1.5\ny = x * x"))
+
+	assert.Contains(t, output, `awk -v v1="$x" 'BEGIN { OFMT = "%.10g"; print v1 * v1 }'`)
+}
+
+func TestFloatParam(t *testing.T) {
+	output := body(compile(`fn half(n: float) -> float { return n / 2 }`))
+
+	assert.Contains(t, output, `printf '%s\n' "$(awk -v v1="$n" 'BEGIN { OFMT = "%.10g"; print v1 / 2 }')"`)
+}
+
+func TestFloatReturningFunctionInArithmetic(t *testing.T) {
+	output := body(compile("fn pi() -> float { return 3.14 }\nx = pi() * 2"))
+
+	assert.Contains(t, output, `x=$(awk -v v1="$(pi)" 'BEGIN { OFMT = "%.10g"; print v1 * 2 }')`)
+}
+
+func TestFloatComparison(t *testing.T) {
+	output := body(compile("load = 0.8\nif load > 0.75 { print(\"busy\") }"))
+
+	assert.Contains(t, output, `if awk -v v1="$load" 'BEGIN { exit !(v1 > 0.75) }'; then`)
+}
+
+func TestIntComparisonStaysInBash(t *testing.T) {
+	output := body(compile("n = 3\nif n > 2 { print(\"many\") }"))
+
+	assert.Contains(t, output, `if [ "$n" -gt 2 ]; then`)
+}
+
+func TestRoundFloorCeil(t *testing.T) {
+	output := body(compile("r = round(2.5)\nf = floor(2.5)\nc = ceil(2.5)"))
+
+	assert.Contains(t, output, `r=$(awk -v x="2.5" 'BEGIN { print (x < 0 ? -int(-x + 0.5) : int(x + 0.5)) }')`)
+	assert.Contains(t, output, `f=$(awk -v x="2.5" 'BEGIN { i = int(x); print (x < i ? i - 1 : i) }')`)
+	assert.Contains(t, output, `c=$(awk -v x="2.5" 'BEGIN { i = int(x); print (x > i ? i + 1 : i) }')`)
+}
+
+func TestRoundToDigitsIsFloat(t *testing.T) {
+	output := body(compile("r = round(2.345, 2)\nif r > 2.3 { print(r) }"))
+
+	assert.Contains(t, output, `-v d="2"`)
+	assert.Contains(t, output, `if awk -v v1="$r" 'BEGIN { exit !(v1 > 2.3) }'; then`)
+}
@@ -0,0 +1,84 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpawnAssignment(t *testing.T) {
+	output := body(compile(`job = spawn("python3 -m http.server", log: "server.log")`))
+
+	assert.Contains(t, output, "_spawn_opts=$-; set -m\n{ python3 -m http.server; } < /dev/null > \"server.log\" 2>&1 &\njob=$!\necho \"$job\" >> \"$_jobs\"\n[[ $_spawn_opts == *m* ]] || set +m")
+}
+
+func TestSpawnStatement(t *testing.T) {
+	output := body(compile(`spawn("sleep 5")`))
+
+	assert.Contains(t, output, "{ sleep 5; } < /dev/null &\necho \"$!\" >> \"$_jobs\"")
+}
+
+func TestSpawnAddsCleanupTrapOnce(t *testing.T) {
+	output := compile("a = spawn(\"sleep 1\")\nb = spawn(\"sleep 2\")")
+
+	assert.Equal(t, 1, strings.Count(output, "_defers+=('_cleanup_jobs 2>/dev/null')"))
+	assert.Equal(t, 1, strings.Count(output, "trap '_run_defers 0' EXIT"))
+	assert.Less(t, strings.Index(output, `_jobs=$(_temp_new jobs "")`), strings.Index(output, "a=$!"))
+}
+
+func TestNoCleanupTrapWithoutSpawn(t *testing.T) {
+	output := compile(`print("hi")`)
+
+	assert.NotContains(t, output, "trap")
+}
+
+func TestWaitAssignment(t *testing.T) {
+	output := body(compile("job = spawn(\"make\")\ncode = wait(job)"))
+
+	assert.Contains(t, output, "code=0\nwait \"$job\" || code=$?")
+}
+
+func TestWaitAndWaitAll(t *testing.T) {
+	output := body(compile("job = spawn(\"make\")\nwait(job)\nwait_all()"))
+
+	assert.Contains(t, output, "wait \"$job\"\nwait")
+}
+
+func TestKill(t *testing.T) {
+	output := body(compile("job = spawn(\"make\")\nkill(job)\nkill(job, signal: \"KILL\")"))
+
+	assert.Contains(t, output, `kill -- -"$job" 2>/dev/null || true`)
+	assert.Contains(t, output, `kill -s KILL -- -"$job" 2>/dev/null || true`)
+}
+
+func TestIsRunningAndPid(t *testing.T) {
+	output := body(compile("job = spawn(\"make\")\nif is_running(job) {\n\tprint(pid())\n}"))
+
+	assert.Contains(t, output, `if kill -0 "$job" 2>/dev/null; then`)
+	assert.Contains(t, output, "echo $$")
+}
+
+func TestIsRunningAsValue(t *testing.T) {
+	output := body(compile("job = spawn(\"make\")\nprint(is_running(job))"))
+
+	assert.Contains(t, output, `echo "$(kill -0 "$job" 2>/dev/null && echo true || echo false)"`)
+}
+
+func TestSpawnRunsCommandAsUnit(t *testing.T) {
+	output := body(compile(`job = spawn("cd /srv; make | tee build.log")`))
+
+	assert.Contains(t, output, "{ cd /srv; make | tee build.log; } < /dev/null &")
+}
+
+func TestWaitInExpressionIsError(t *testing.T) {
+	_, errs := compileWithErrors("job = spawn(\"make\")\nprint(wait(job))")
+
+	assert.NotEmpty(t, errs)
+}
+
+func TestSpawnInValueFunctionKeepsStdoutFree(t *testing.T) {
+	output := body(compile("fn start() -> int {\n\tjob = spawn(\"sleep 5\")\n\treturn job\n}"))
+
+	assert.Contains(t, output, "{ sleep 5; } < /dev/null >&2 &")
+}
@@ -1,6 +1,7 @@
 package codegen
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -23,3 +24,115 @@ func TestJsonGetErrorNoArgs(t *testing.T) {
 
 	assert.Contains(t, output, `# error: json_get() requires 2 arguments`)
 }
+
+func TestParseJSON(t *testing.T) {
+	output := body(compile(`data = parse_json(body)`))
+
+	assert.True(t, strings.HasSuffix(output, "\n"+`data=$(jq -c . <<< "$body")`), output)
+}
+
+func TestJSONPathRead(t *testing.T) {
+	output := body(compile("data = parse_json(body)\nname = data.items[0].name"))
+
+	assert.Contains(t, output, `name="$(jq -cr '.items[0].name' <<< "$data")"`)
+}
+
+func TestJSONRuntimeIndex(t *testing.T) {
+	output := body(compile("data = parse_json(body)\ni = 1\nx = data.items[i]"))
+
+	assert.Contains(t, output, `x="$(jq -cr --arg v1 "$i" '.items[$v1 | tonumber? // $v1]' <<< "$data")"`)
+}
+
+func TestJSONLen(t *testing.T) {
+	output := body(compile("data = parse_json(body)\nn = len(data.items)"))
+
+	assert.Contains(t, output, `n=$(jq -c '.items | length' <<< "$data")`)
+}
+
+func TestForOverJSONArray(t *testing.T) {
+	output := body(compile("data = parse_json(body)\nfor item in data.items {\n\tprint(item.name)\n}"))
+
+	assert.Contains(t, output, `exec {_lines_fd1}< <(jq -j '.items[] | (if type == "string" then . else tojson end), "\u0000"' <<< "$data")`)
+	assert.Contains(t, output, "while _lines_next item \"$_lines_fd1\" _lines_eof1 ''; do\n  echo \"$(jq -cr '.name' <<< \"$item\")\"\ndone\nexec {_lines_fd1}<&-\n_lines_wait \"$_lines_pid1\" \"$_lines_eof1\"")
+}
+
+func TestForKeyValueOverJSON(t *testing.T) {
+	output := body(compile("data = parse_json(body)\nfor k, v in data {\n\tprint(k)\n}"))
+
+	assert.Contains(t, output, `while _lines_next k "$_lines_fd1" _lines_eof1 '' && _lines_next v "$_lines_fd1" _lines_eof1 ''; do`)
+	assert.Contains(t, output, `< <(jq -j '. | to_entries[] | (.key, .value) | `)
+}
+
+func TestJSONPathAssignment(t *testing.T) {
+	output := body(compile("data = parse_json(body)\ndata.items[0].name = \"x\"\ndata.count = 3\ndata[\"tags\"] = [\"a\", {k: true}]"))
+
+	assert.Contains(t, output, `data=$(jq -c --arg v1 "x" '.items[0].name = $v1' <<< "$data")`)
+	assert.Contains(t, output, `data=$(jq -c '.count = 3' <<< "$data")`)
+	assert.Contains(t, output, `data=$(jq -c --arg v1 "a" '.["tags"] = [$v1, {"k": true}]' <<< "$data")`)
+}
+
+func TestJSONPathAssignmentNeedsJSON(t *testing.T) {
+	_, errs := compileWithErrors("name = \"x\"\nname.first = \"y\"")
+
+	assert.NotEmpty(t, errs)
+}
+
+func TestFieldAccessNeedsJSON(t *testing.T) {
+	_, errs := compileWithErrors("name = \"x\"\nprint(name.first)")
+
+	assert.NotEmpty(t, errs)
+}
+
+func TestToJSON(t *testing.T) {
+	output := body(compile("m = {a: \"1\"}\nxs = [\"a\"]\nx = to_json(m)\ny = to_json(xs)\nz = to_json(2.5)"))
+
+	assert.Contains(t, output, `x="$(_a=(); for k in "${!m[@]}"; do _a+=(--arg "$k" "${m[$k]}"); done; jq -cn '$ARGS.named' "${_a[@]}")"`)
+	assert.Contains(t, output, `y="$(jq -cn '$ARGS.positional' --args "${xs[@]}")"`)
+	assert.Contains(t, output, `z="$(jq -cn '2.5')"`)
+}
+
+func TestToJSONKeepsTypesOfCollections(t *testing.T) {
+	output := body(compile("m = {n: 3, on: true, s: \"x\"}\nm[\"on\"] = \"no\"\nxs = [1, \"a\", 2.5]\nx = to_json(m)\ny = to_json(xs)"))
+
+	assert.Contains(t, output, `jq -cn '$ARGS.named | with_entries(if .key == "n" then .value = (.value as $s | try ($s | fromjson) catch $s) else . end)' "${_a[@]}"`)
+	assert.Contains(t, output, `y="$(jq -cn '[$ARGS.positional | to_entries[] | if .key == 0 or .key == 2 then .value as $s | try ($s | fromjson) catch $s else .value end]' --args "${xs[@]}")"`)
+}
+
+func TestJSONParam(t *testing.T) {
+	output := body(compile("fn name(data: json) -> str {\n\treturn data.name\n}"))
+
+	assert.Contains(t, output, `printf '%s\n' "$(jq -cr '.name' <<< "$data")"`)
+}
+
+func TestJSONSetAndDelete(t *testing.T) {
+	output := body(compile("key = \"env\"\ndoc = json_set(_body, \".spec.replicas\", 3)\ndoc = json_set(doc, \".spec.{key}\", {a: [1, \"x\"]})\ndoc = doc |> json_delete(\".debug\")"))
+
+	assert.Contains(t, output, `doc=$(jq -c '(.spec.replicas) = 3' <<< "$_body")`)
+	assert.Contains(t, output, `doc=$(jq -c --arg v1 "x" '('".spec.${key}"') = {"a": [1, $v1]}' <<< "$doc")`)
+	assert.Contains(t, output, `doc=$(jq -c 'del(.debug)' <<< "$doc")`)
+}
+
+func TestJSONKeysLenMerge(t *testing.T) {
+	output := body(compile("ks = json_keys(doc)\nn = json_len(doc)\nm = json_merge(doc, other)\nfor k in doc |> json_keys() {\n\tprint(k)\n}"))
+
+	assert.Contains(t, output, "mapfile -t ks < <(jq -r 'keys_unsorted[]' <<< \"$doc\")\nwait $!")
+	assert.Contains(t, output, `n=$(jq -c 'length' <<< "$doc")`)
+	assert.Contains(t, output, `m=$(jq -c --argjson v1 "$other" '. * $v1' <<< "$doc")`)
+	assert.Contains(t, output, `exec {_lines_fd1}< <(jq -r 'keys_unsorted[]' <<< "$doc")`)
+}
+
+func TestJSONValid(t *testing.T) {
+	output := body(compile("if !json_valid(_body) {\n\texit(1)\n}\nif _body |> json_valid() {\n\tprint(\"ok\")\n}\nok = json_valid(_body)"))
+
+	check := `jq -en '[inputs] | length == 1' <<< "$_body" >/dev/null 2>&1`
+	assert.Contains(t, output, "if ! "+check+"; then")
+	assert.Contains(t, output, "if "+check+"; then")
+	assert.Contains(t, output, `ok="$(`+check+` && echo true || echo false)"`)
+}
+
+func TestJSONChecksForJq(t *testing.T) {
+	output := compile("print(json_len(\"[]\"))\nprint(to_json([\"a\"]))")
+
+	assert.Equal(t, 1, strings.Count(output, "command -v jq"))
+	assert.NotContains(t, compile(`print("no json")`), "command -v jq")
+}
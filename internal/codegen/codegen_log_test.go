@@ -0,0 +1,34 @@
+package codegen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogWritesFields(t *testing.T) {
+	output := compile("user = \"bob\"\nlog.info(\"deploying {user}\", user: user, replicas: 3)")
+
+	assert.Contains(t, output, "_log() {")
+	assert.Contains(t, output, `_log info "deploying ${user}" user "$user" replicas "3"`)
+}
+
+func TestLogLevels(t *testing.T) {
+	output := body(compile("log.debug(\"a\")\nlog.warn(\"b\")\nlog.error(\"c\")"))
+
+	assert.Contains(t, output, "_log debug \"a\"\n_log warn \"b\"\n_log error \"c\"")
+}
+
+func TestLogRuntime(t *testing.T) {
+	output := compile(`log.info("x")`)
+
+	assert.Contains(t, output, `case "${LOG_LEVEL:-info}" in`)
+	assert.Contains(t, output, `if [ "${LOG_FORMAT:-}" = json ]; then`)
+	assert.Contains(t, output, `if [ -t 2 ] && [ -z "${NO_COLOR:-}" ]; then`)
+}
+
+func TestLogIsNotAValue(t *testing.T) {
+	output := body(compile(`x = log.info("x")`))
+
+	assert.Contains(t, output, "# error: log.info() does not return a value")
+}
@@ -0,0 +1,99 @@
+package codegen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmptyMap(t *testing.T) {
+	output := body(compile(`m = {}`))
+
+	assert.Equal(t, `declare -A m=()`, output)
+}
+
+func TestMapInFunctionIsGlobal(t *testing.T) {
+	output := body(compile("fn load() {\n\tcfg = {a: 1}\n}"))
+
+	assert.Contains(t, output, `declare -gA cfg=(["a"]=1)`)
+}
+
+func TestMapRuntimeKey(t *testing.T) {
+	output := body(compile("config = {host: \"h\"}\nkey = \"host\"\nx = config[key]\nconfig[key] = \"new\""))
+
+	assert.Contains(t, output, `x="${config[$key]}"`)
+	assert.Contains(t, output, `config[$key]="new"`)
+}
+
+func TestMapStringKeyAssignment(t *testing.T) {
+	output := body(compile("config = {}\nconfig[\"log-level\"] = \"debug\""))
+
+	assert.Contains(t, output, `config["log-level"]="debug"`)
+}
+
+func TestForKeyValueOverMap(t *testing.T) {
+	output := body(compile("config = {a: 1}\nfor k, v in config {\n\tprint(k, v)\n}"))
+
+	assert.Contains(t, output, "for k in \"${!config[@]}\"; do\n  v=\"${config[$k]}\"\n  echo \"$k\" \"$v\"\ndone")
+}
+
+func TestForOverMapVisitsKeys(t *testing.T) {
+	output := body(compile("config = {a: 1}\nfor k in config {\n\tprint(k)\n}"))
+
+	assert.Contains(t, output, `for k in "${!config[@]}"; do`)
+}
+
+func TestForIndexValueOverList(t *testing.T) {
+	output := body(compile("items = [\"a\"]\nfor i, item in items {\n\tprint(i)\n}"))
+
+	assert.Contains(t, output, "for i in \"${!items[@]}\"; do\n  item=\"${items[$i]}\"")
+}
+
+func TestForKeyValueNeedsVariable(t *testing.T) {
+	_, errs := compileWithErrors("for k, v in keys(m) {\n\tprint(k)\n}")
+
+	assert.NotEmpty(t, errs)
+}
+
+func TestMapBuiltins(t *testing.T) {
+	output := body(compile("m = {a: 1}\nks = keys(m)\nvs = values(m)\nn = len(m)\nif has(m, \"a\") {\n\tdelete(m, \"a\")\n}"))
+
+	assert.Contains(t, output, `ks=("${!m[@]}")`)
+	assert.Contains(t, output, `vs=("${m[@]}")`)
+	assert.Contains(t, output, `n=${#m[@]}`)
+	assert.Contains(t, output, `if [[ -v m["a"] ]]; then`)
+	assert.Contains(t, output, `_key="a"; unset 'm[$_key]'`)
+}
+
+func TestHasAsValue(t *testing.T) {
+	output := body(compile("m = {a: 1}\nfound = has(m, \"a\")\nprint(has(m, \"b\"))"))
+
+	assert.Contains(t, output, `found="$([[ -v m["a"] ]] && echo true || echo false)"`)
+	assert.Contains(t, output, `echo "$([[ -v m["b"] ]] && echo true || echo false)"`)
+}
+
+func TestForOverKeys(t *testing.T) {
+	output := body(compile("m = {a: 1}\nfor k in keys(m) {\n\tprint(k)\n}"))
+
+	assert.Contains(t, output, `for k in "${!m[@]}"; do`)
+}
+
+func TestMapParamIsNameref(t *testing.T) {
+	output := body(compile("fn show(cfg: map, label: str) {\n\tprint(label)\n}\nconfig = {a: 1}\nshow(config, \"x\")"))
+
+	assert.Contains(t, output, `[[ "$1" == cfg ]] || local -n cfg="$1"`)
+	assert.Contains(t, output, `local label="$2"`)
+	assert.Contains(t, output, `show config "x"`)
+}
+
+func TestMapParamForwardsName(t *testing.T) {
+	output := body(compile("fn inner(m: map) {\n\tprint(len(m))\n}\nfn outer(cfg: map) {\n\tinner(cfg)\n}"))
+
+	assert.Contains(t, output, `inner "$1"`)
+}
+
+func TestMapLiteralArgumentIsError(t *testing.T) {
+	_, errs := compileWithErrors("fn show(cfg: map) {\n\tprint(len(cfg))\n}\nshow({a: 1})")
+
+	assert.NotEmpty(t, errs)
+}
@@ -58,6 +58,12 @@ parts = name.split(",")
 	assert.Contains(t, output, `IFS=',' read -ra parts <<< "$name"`)
 }
 
+func TestMethodSplitOfCallResult(t *testing.T) {
+	output := body(compile(`parts = exec("date +%F").split("-")`))
+
+	assert.Contains(t, output, `IFS='-' read -ra parts <<< "$(date +%F)"`)
+}
+
 func TestMethodJoin(t *testing.T) {
 	output := body(compile(`
 items = ["a", "b", "c"]
@@ -0,0 +1,62 @@
+package codegen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultilineStringAssignment(t *testing.T) {
+	output := body(compile("conf = \"\"\"\n    listen {port};\n    root $HOME;\n    \"\"\""))
+
+	assert.Equal(t, "conf=\"listen ${port};\nroot \\$HOME;\"", output)
+}
+
+func TestRawStringAssignment(t *testing.T) {
+	output := body(compile(`pattern = r"""it's {not} $interpolated"""`))
+
+	assert.Equal(t, `pattern='it'\''s {not} $interpolated'`, output)
+}
+
+func TestWriteUsesPrintf(t *testing.T) {
+	output := body(compile(`write("out.txt", msg)`))
+
+	assert.Equal(t, `printf '%s\n' "$msg" > "out.txt"`, output)
+}
+
+func TestWriteMultilineString(t *testing.T) {
+	output := body(compile("write(\"app.conf\", \"\"\"\n    -n {name}\n    \"\"\")"))
+
+	assert.Equal(t, `printf '%s\n' "-n ${name}" > "app.conf"`, output)
+}
+
+func TestWriteRawStringUsesQuotedHeredoc(t *testing.T) {
+	output := body(compile("fn conf() {\n    write(\"app.conf\", r\"\"\"\n        a \\d {x}\n          b\n        \"\"\")\n}"))
+
+	// The heredoc body and delimiter are not indented with the function body
+	assert.Contains(t, output, "  cat > \"app.conf\" <<'EOF'\na \\d {x}\n  b\nEOF\n}")
+}
+
+func TestAppendRawString(t *testing.T) {
+	output := body(compile(`append("log.txt", r"""line""")`))
+
+	assert.Equal(t, "cat >> \"log.txt\" <<'EOF'\nline\nEOF", output)
+}
+
+func TestHeredocDelimiterAvoidsContent(t *testing.T) {
+	output := body(compile("print(r\"\"\"\n    EOF\n    EOF_\n    \"\"\")"))
+
+	assert.Equal(t, "cat <<'EOF__'\nEOF\nEOF_\nEOF__", output)
+}
+
+func TestPrintMultilineString(t *testing.T) {
+	output := body(compile("print(\"\"\"\n    a\n    b\n    \"\"\")"))
+
+	assert.Equal(t, "printf '%s\\n' \"a\nb\"", output)
+}
+
+func TestPrintMultilineStringWithOtherArgs(t *testing.T) {
+	output := body(compile(`print("got:", """a""")`))
+
+	assert.Equal(t, `printf '%s %s\n' "got:" "a"`, output)
+}
@@ -46,3 +46,49 @@ result = name |> lower
 `))
 	assert.Contains(t, output, "tr '[:upper:]' '[:lower:]'")
 }
+
+func TestPipeFileIntoCommands(t *testing.T) {
+	output := body(compile("n = read(\"app.log\") |> cmd(\"grep\", \"ERROR\") |> cmd(\"wc\", \"-l\")"))
+
+	assert.Contains(t, output, `n=$(grep ERROR < "app.log" | wc -l)`)
+}
+
+func TestPipeValueIntoCommand(t *testing.T) {
+	output := body(compile("msg = \"a b\"\nfiles = [\"x\", \"y\"]\nmsg |> cmd(\"tr\", \"a-z\", \"A-Z\")\nfiles |> cmd(\"sort\")"))
+
+	assert.Contains(t, output, `printf '%s\n' "$msg" | tr a-z A-Z`)
+	assert.Contains(t, output, `printf '%s\n' "${files[@]}" | sort`)
+}
+
+func TestPipeCommandIntoBuiltin(t *testing.T) {
+	output := body(compile("n = $ wc -l app.log |> trim()"))
+
+	assert.Contains(t, output, `n=$(echo "$(wc -l app.log)" | xargs)`)
+}
+
+func TestPipeCommandAsCondition(t *testing.T) {
+	output := body(compile("if read(f) |> cmd(\"grep\", \"-q\", \"x\") {\n\tprint(\"y\")\n}"))
+
+	assert.Contains(t, output, `if grep -q x < "$f"; then`)
+}
+
+func TestPipeIntoLines(t *testing.T) {
+	output := body(compile("errs = read(\"app.log\") |> cmd(\"grep\", \"ERROR\") |> lines()\nprint(len(errs))"))
+
+	assert.Contains(t, output, "mapfile -t errs < <(grep ERROR < \"app.log\")\nwait $!")
+	assert.Contains(t, output, `${#errs[@]}`)
+}
+
+func TestForOverPipedLines(t *testing.T) {
+	output := body(compile("for l in $ git log --oneline |> lines() {\n\tprint(l)\n}"))
+
+	assert.Contains(t, output, "exec {_lines_fd1}< <(git log --oneline)\n_lines_pid1=$!\n_lines_eof1=false\n")
+	assert.Contains(t, output, `while _lines_next l "$_lines_fd1" _lines_eof1 $'\n'; do`)
+	assert.Contains(t, output, "done\nexec {_lines_fd1}<&-\n_lines_wait \"$_lines_pid1\" \"$_lines_eof1\"")
+}
+
+func TestPipeStatementIntoBuiltin(t *testing.T) {
+	output := body(compile("data = \"x\"\ndata |> upper |> print"))
+
+	assert.Contains(t, output, `echo $(echo "$data" | tr '[:lower:]' '[:upper:]')`)
+}
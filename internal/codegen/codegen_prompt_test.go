@@ -0,0 +1,36 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPromptAndSecret(t *testing.T) {
+	output := body(compile("name = prompt(\"Name\", default: \"bob\")\npw = secret(\"Password:\")"))
+
+	assert.Contains(t, output, `name="$(_prompt "Name" 1 "bob")"`)
+	assert.Contains(t, output, `pw="$(_secret "Password:" 0 "")"`)
+}
+
+func TestChooseExpandsOptions(t *testing.T) {
+	output := body(compile("envs = [\"a\", \"b\"]\ne = choose(\"Env\", envs)\nr = choose(\"Region\", [\"eu\", \"us\"], default: \"eu\")"))
+
+	assert.Contains(t, output, `e="$(_choose "Env" 0 "" "${envs[@]}")"`)
+	assert.Contains(t, output, `r="$(_choose "Region" 1 "eu" "eu" "us")"`)
+}
+
+func TestConfirm(t *testing.T) {
+	output := body(compile("if !confirm(\"Drop {db}?\") {\n\texit(1)\n}\nok = confirm(\"Go?\", default: true)"))
+
+	assert.Contains(t, output, `if ! _confirm "Drop ${db}?" false; then`)
+	assert.Contains(t, output, `ok="$(_confirm "Go?" "true" && echo true || echo false)"`)
+}
+
+func TestPromptRuntimeTakesYesBeforeFlags(t *testing.T) {
+	output := compile("flags { env: str }\nif confirm(\"Deploy?\") {\n\tprint(env)\n}")
+
+	assert.Contains(t, output, "--yes) _prompt_yes=true ;;")
+	assert.Less(t, strings.Index(output, `set -- "${_prompt_args[@]}"`), strings.Index(output, "_flags_usage() {"))
+}
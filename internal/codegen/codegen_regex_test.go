@@ -0,0 +1,71 @@
+package codegen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchesLiteralPattern(t *testing.T) {
+	output := body(compile("tag = \"v1\"\nif matches(tag, \"^v[0-9]+\") {\n\tprint(\"ok\")\n}"))
+
+	assert.Contains(t, output, `if { _re="^v[0-9]+"; [[ "$tag" =~ $_re ]]; }; then`)
+}
+
+func TestMatchesVariablePattern(t *testing.T) {
+	output := body(compile("pat = \"^v\"\nok = matches(\"v1\", pat)"))
+
+	assert.Contains(t, output, `[[ "v1" =~ $pat ]]`)
+}
+
+func TestMatchesMethod(t *testing.T) {
+	output := body(compile("tag = \"v1\"\nif tag.matches(\"^v\") {\n\tprint(\"ok\")\n}"))
+
+	assert.Contains(t, output, `if { _re="^v"; [[ "$tag" =~ $_re ]]; }; then`)
+}
+
+func TestRegexQuantifierNotInterpolated(t *testing.T) {
+	output := body(compile(`ok = matches("abc", "^[a-z]{3}$")`))
+
+	assert.Contains(t, output, `_re="^[a-z]{3}\$"`)
+}
+
+func TestRegexFindAssignment(t *testing.T) {
+	output := body(compile("tag = \"v1.2\"\nparts = regex_find(tag, \"v([0-9]+)\\.([0-9]+)\")"))
+
+	assert.Contains(t, output, `parts=("${BASH_REMATCH[@]:1}")`)
+	assert.Contains(t, output, "else\n  parts=()\nfi")
+}
+
+func TestReplaceRegex(t *testing.T) {
+	output := body(compile(`s = replace_regex("a/b", "/", "-")`))
+
+	assert.Contains(t, output, `s=$(_re="/"; _to="-"; printf '%s\n' "a/b" | sed -E`)
+}
+
+func TestRegexArity(t *testing.T) {
+	_, errs := compileWithErrors(`ok = matches("x")`)
+
+	assert.NotEmpty(t, errs)
+}
+
+func TestRegexMatchArms(t *testing.T) {
+	input := `match line {
+	matches("^ERR") => print("error")
+	"INFO*" => print("info")
+	_ => print("other")
+}`
+	output := body(compile("line = \"ERR x\"\n" + input))
+
+	assert.Contains(t, output, `if { _re="^ERR"; [[ "$line" =~ $_re ]]; }; then`)
+	assert.Contains(t, output, `elif [[ "$line" == INFO* ]]; then`)
+	assert.Contains(t, output, "else\n  echo \"other\"\nfi")
+	assert.NotContains(t, output, "case")
+}
+
+func TestRegexMatchOnExpression(t *testing.T) {
+	output := body(compile("match upper(\"a\") {\n\tmatches(\"^A\") => print(\"A\")\n}"))
+
+	assert.Contains(t, output, `_match="$(echo "a" | tr '[:lower:]' '[:upper:]')"`)
+	assert.Contains(t, output, `[[ "$_match" =~ $_re ]]`)
+}
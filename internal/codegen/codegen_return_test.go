@@ -0,0 +1,128 @@
+package codegen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReturnValueFromTypedFunc(t *testing.T) {
+	output := body(compile(`fn add(a: int, b: int) -> int { return a + b }`))
+
+	assert.Contains(t, output, `printf '%s\n' "$((a + b))"`)
+	assert.Contains(t, output, "return 0")
+}
+
+func TestReturnStringFromTypedFunc(t *testing.T) {
+	output := body(compile(`fn greet(name: str) -> str { return "hi {name}" }`))
+
+	assert.Contains(t, output, `printf '%s\n' "hi ${name}"`)
+}
+
+func TestReturnExitStatusFromPlainFunc(t *testing.T) {
+	output := body(compile(`fn check(x: int) { return 1 }`))
+
+	assert.Contains(t, output, "return 1")
+	assert.NotContains(t, output, "printf")
+}
+
+func TestReturnBoolAsExitStatus(t *testing.T) {
+	output := body(compile(`fn ok() -> bool { return false }`))
+
+	assert.Contains(t, output, "return 1")
+	assert.NotContains(t, output, "printf")
+}
+
+func TestTypedFuncCallCaptured(t *testing.T) {
+	output := body(compile(`fn add(a: int, b: int) -> int { return a + b }
+total = add(1, 2)`))
+
+	assert.Contains(t, output, `total="$(add 1 2)"`)
+}
+
+func TestPlainFuncCallNotCaptured(t *testing.T) {
+	output := body(compile(`fn check() { return 0 }
+if check() { print("ok") }`))
+
+	assert.Contains(t, output, "if check; then")
+}
+
+func TestTypedFuncCallStatementDiscardsValue(t *testing.T) {
+	output := body(compile(`fn add(a: int, b: int) -> int { return a + b }
+add(1, 2)`))
+
+	assert.Contains(t, output, "add 1 2 >/dev/null")
+}
+
+func TestListReturnAssignment(t *testing.T) {
+	output := body(compile(`fn names() -> list { return ["a", "b"] }
+n = names()`))
+
+	assert.Contains(t, output, `printf '%s\n' "a" "b"`)
+	assert.Contains(t, output, "mapfile -t n < <(names)\nwait $!")
+}
+
+func TestListReturnIdentifier(t *testing.T) {
+	output := body(compile(`fn copy_list(items: list) -> list { return items }
+xs = ["a"]
+ys = copy_list(xs)`))
+
+	assert.Contains(t, output, `[[ "$1" == items ]] || local -n items="$1"`)
+	assert.Contains(t, output, "[ \"${#items[@]}\" -gt 0 ] || return 0\n  printf '%s\\n' \"${items[@]}\"")
+	assert.Contains(t, output, "mapfile -t ys < <(copy_list xs)")
+}
+
+func TestEmptyListReturnPrintsNothing(t *testing.T) {
+	output := body(compile(`fn none() -> list { return [] }`))
+
+	assert.Contains(t, output, "none() {\n  return 0\n}")
+}
+
+func TestListArgNeedsVariable(t *testing.T) {
+	_, errs := compileWithErrors(`fn first(items: list) -> str { return items[0] }
+x = first(["a"])`)
+
+	require.Len(t, errs, 1)
+	assert.Equal(t, "lists are passed by name; assign the list to a variable first", errs[0].Message)
+}
+
+func TestValueCallStaysOneWord(t *testing.T) {
+	output := body(compile(`fn two() -> str { return "a b" }
+fn show(s: str) { print(s) }
+show(two())
+if two() == "a b" { print("eq") }
+xs = [two(), "c"]
+write("f", two())`))
+
+	assert.Contains(t, output, `show "$(two)"`)
+	assert.Contains(t, output, `if [ "$(two)" = "a b" ]; then`)
+	assert.Contains(t, output, `xs=("$(two)" "c")`)
+	assert.Contains(t, output, `printf '%s\n' "$(two)" > "f"`)
+}
+
+func TestPrintInValueFuncGoesToStderr(t *testing.T) {
+	output := body(compile(`fn add(a: int, b: int) -> int {
+	print("adding")
+	return a + b
+}
+fn greet() { print("hi") }`))
+
+	assert.Contains(t, output, `echo "adding" >&2`)
+	assert.Contains(t, output, "greet() {\n  echo \"hi\"\n}")
+}
+
+func TestUserFuncKeywordArgs(t *testing.T) {
+	output := body(compile(`fn deploy(app: str, env: str = "staging", replicas: int = 2) { print(app) }
+deploy("web", replicas: 5)
+deploy(app: "api", env: "prod")`))
+
+	assert.Contains(t, output, `deploy "web" "" 5`)
+	assert.Contains(t, output, `deploy "api" "prod"`)
+}
+
+func TestReturnBoolExpression(t *testing.T) {
+	output := body(compile(`fn newer(a: int, b: int) -> bool { return a > b }`))
+
+	assert.Contains(t, output, `if [ "$a" -gt "$b" ]; then return 0; else return 1; fi`)
+}
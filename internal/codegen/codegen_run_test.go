@@ -0,0 +1,45 @@
+package codegen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunQuotesEachArgument(t *testing.T) {
+	output := body(compile("tag = \"v1; rm -rf /\"\nrun(\"git\", \"tag\", \"-a\", tag, \"-m\", \"Release {tag}\")"))
+
+	assert.Contains(t, output, `git tag -a "$tag" -m "Release ${tag}"`)
+}
+
+func TestRunExpandsListArguments(t *testing.T) {
+	output := body(compile("files = [\"a b\", \"c\"]\n$ gzip -9 {files} '*.log'"))
+
+	assert.Contains(t, output, `gzip -9 "${files[@]}" '*.log'`)
+}
+
+func TestRunAssignmentCapturesResult(t *testing.T) {
+	output := compile("r = $ make build\nif !r.ok {\n\tprint(r.stderr)\n}")
+
+	assert.Contains(t, output, "_run_capture() {")
+	assert.Contains(t, output, "declare -A r=()\n_run_capture r make build")
+	assert.Contains(t, output, `if ! [ "${r["ok"]}" = true ]; then`)
+}
+
+func TestRunAssignmentInFunctionIsGlobal(t *testing.T) {
+	output := body(compile("fn f() {\n\tr = run(\"true\")\n}"))
+
+	assert.Contains(t, output, "declare -gA r=()")
+}
+
+func TestRunAsCondition(t *testing.T) {
+	output := body(compile("if $ git diff --quiet {f} {\n\tprint(\"clean\")\n}"))
+
+	assert.Contains(t, output, `if git diff --quiet "$f"; then`)
+}
+
+func TestRunOrFallback(t *testing.T) {
+	output := body(compile("r = run(\"false\") or {\n\texit(1)\n}"))
+
+	assert.Contains(t, output, "_run_capture r false\nif [ \"${r[\"ok\"]}\" != true ]; then\n  exit 1\nfi")
+}
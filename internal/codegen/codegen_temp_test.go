@@ -0,0 +1,37 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTempfileAndTempdir(t *testing.T) {
+	output := compile("f = tempfile()\nconf = tempfile(suffix: \".json\")\nd = tempdir()\nwrite(tempfile(suffix: ext), \"x\")")
+
+	assert.Contains(t, output, `f="$(_temp_new tempfile "")"`)
+	assert.Contains(t, output, `conf="$(_temp_new tempfile ".json")"`)
+	assert.Contains(t, output, `d="$(_temp_new tempdir "")"`)
+	assert.Contains(t, output, `"$(_temp_new tempfile "$ext")"`)
+	assert.Equal(t, 1, strings.Count(output, "_temp_root=$(mktemp -d)"))
+	assert.Less(t, strings.Index(output, "trap '_run_defers 0' EXIT"), strings.Index(output, `_defers+=('rm -rf "$_temp_root"')`))
+}
+
+func TestWithRemovesPathAtBlockEnd(t *testing.T) {
+	output := body(compile("with dir = tempdir() {\n\twrite(\"{dir}/a\", \"x\")\n}\nprint(\"done\")"))
+
+	assert.Contains(t, output, "dir=\"$(_temp_new tempdir \"\")\"\nprintf '%s\\n' \"x\" > \"${dir}/a\"\n_temp_remove \"$dir\"\necho \"done\"")
+}
+
+func TestWithInFunctionKeepsDeferFrame(t *testing.T) {
+	output := body(compile("fn build() {\n\twith f = tempfile() {\n\t\tdefer print(\"bye\")\n\t}\n}"))
+
+	assert.Contains(t, output, "local _defer_frame=${#FUNCNAME[@]} _defer_base=${#_defers[@]}")
+}
+
+func TestWithInTryKeepsVariableGlobal(t *testing.T) {
+	output := body(compile("try {\n\twith f = tempfile() {\n\t\twrite(f, \"x\")\n\t}\n} catch {\n\tprint(\"failed\")\n}"))
+
+	assert.Contains(t, output, "_try1_body() {\n  _try_line=2\n  f=")
+}
@@ -0,0 +1,35 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderPassesVars(t *testing.T) {
+	output := body(compile("hosts = [\"a\", \"b\"]\nconf = render(\"app.tmpl\", vars: {name: \"web\", port: 80, hosts: hosts, tags: [\"x\", \"y\"]})"))
+
+	assert.Contains(t, output, `conf="$(_render "app.tmpl" "" "name" "web" "port" "80" "hosts" "$(printf '%s\n' "${hosts[@]}")" "tags" "$(printf '%s\n' "x" "y")")"`)
+}
+
+func TestRenderMapVariableByName(t *testing.T) {
+	output := body(compile("cfg = {name: \"web\"}\nrender_to(\"app.tmpl\", \"/etc/app.conf\", vars: cfg)\nplain = render(\"motd.tmpl\")"))
+
+	assert.Contains(t, output, `_render_to "app.tmpl" "/etc/app.conf" cfg`)
+	assert.Contains(t, output, `plain="$(_render "motd.tmpl" "")"`)
+}
+
+func TestRenderValueComputedAhead(t *testing.T) {
+	output := body(compile("write(\"out.conf\", render(\"a.tmpl\"))\nprint(\"motd:\", render(\"b.tmpl\"))\nwhile render(\"c.tmpl\") == \"\" {\n\tsleep(1)\n}"))
+
+	assert.Contains(t, output, "_rendered1=\"$(_render \"a.tmpl\" \"\")\"\nprintf '%s\\n' \"$_rendered1\" > \"out.conf\"")
+	assert.Contains(t, output, "_rendered2=\"$(_render \"b.tmpl\" \"\")\"\necho \"motd:\" \"$_rendered2\"")
+	assert.Contains(t, output, `while [ "$(_render "c.tmpl" "")" = "" ]; do`)
+}
+
+func TestRenderRuntimeEmittedOnce(t *testing.T) {
+	output := compile("a = render(\"a.tmpl\")\nrender_to(\"b.tmpl\", \"b\")")
+
+	assert.Equal(t, 1, strings.Count(output, "_render() {"))
+}
@@ -17,7 +17,7 @@ func compile(input string) string {
 	return output
 }
 
-func compileWithErrors(input string) (string, []string) {
+func compileWithErrors(input string) (string, []Error) {
 	tokens := lexer.New(input).Tokenize()
 	prog, err := parser.New(tokens).ParseWithErrors()
 	if err != nil {
@@ -0,0 +1,71 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTryRunsBodyInCurrentShell(t *testing.T) {
+	output := body(compile("try {\n\tx = read(\"f\")\n} catch err {\n\tprint(err.code)\n}"))
+
+	assert.Contains(t, output, "unset _try1_err _try1_exit\n_try1_opts=$- _try1_trap=$(trap -p ERR) _try1_depth=${#BASH_SOURCE[@]} _try1_sub=$BASH_SUBSHELL\n_try1_body() {\n  _try_line=2\n  x=")
+	assert.Contains(t, output, "  return 0\n}\nset +e -E\n")
+	assert.Contains(t, output, `trap '_try_catch _try1_err "$?" "$_try1_depth" || { (( BASH_SUBSHELL == _try1_sub )) || exit "${_try1_err[0]}"; return "${_try1_err[0]}"; }' ERR`)
+	assert.Contains(t, output, "_try1_body \"$@\"\neval \"${_try1_trap:-trap - ERR}\"\n[[ $_try1_opts != *e* ]] || set -e\n")
+	assert.NotContains(t, output, "mktemp")
+}
+
+func TestTryCatchBindsErrMap(t *testing.T) {
+	output := body(compile("try {\n\texec(\"false\")\n} catch err {\n\tprint(err.code, err.command)\n}"))
+
+	assert.Contains(t, output, "if [[ -v _try1_err ]]; then\n  declare -A err=([\"code\"]=\"${_try1_err[0]}\" [\"line\"]=\"${_try1_err[1]}\" [\"command\"]=\"${_try1_err[2]}\")")
+	assert.Contains(t, output, `echo "${err["code"]}" "${err["command"]}"`)
+}
+
+func TestTryFinallyWithoutCatchRethrows(t *testing.T) {
+	output := body(compile("try {\n\texec(\"false\")\n} finally {\n\tprint(\"cleanup\")\n}"))
+
+	assert.Contains(t, output, "echo \"cleanup\"\nif [[ -v _try1_err ]]; then\n  _try_raise _try1_err\nfi")
+}
+
+func TestNestedTryUsesSeparateState(t *testing.T) {
+	output := body(compile("try {\n\ttry {\n\t\texec(\"false\")\n\t} finally {\n\t\tprint(\"a\")\n\t}\n} catch {\n\tprint(\"b\")\n}"))
+
+	assert.Contains(t, output, "_try1_body() {")
+	assert.Contains(t, output, "  _try2_body() {")
+}
+
+func TestTryExitsAfterFinally(t *testing.T) {
+	output := body(compile("fn f() -> int {\n\tfor x in [1] {\n\t\ttry {\n\t\t\tcontinue\n\t\t} finally {\n\t\t\tprint(\"a\")\n\t\t}\n\t\ttry {\n\t\t\tfor y in [2] {\n\t\t\t\tbreak\n\t\t\t}\n\t\t\treturn 1\n\t\t} finally {\n\t\t\tprint(\"b\")\n\t\t}\n\t}\n\treturn 0\n}"))
+
+	assert.Contains(t, output, "{ _try1_exit=(continue); return 0; }")
+	assert.Contains(t, output, "      break\n")
+	assert.Contains(t, output, "{ _try2_exit=(return 0); return 0; }")
+	assert.Contains(t, output, "if [[ -v _try2_exit ]]; then\n      \"${_try2_exit[@]}\"\n")
+}
+
+func TestTryInFunctionKeepsStateLocal(t *testing.T) {
+	output := body(compile("fn f() {\n\ttry {\n\t\texec(\"false\")\n\t} catch e {\n\t\tprint(e.code)\n\t}\n}"))
+
+	assert.Contains(t, output, "local _try1_err _try1_exit _try1_opts _try1_trap _try1_depth _try1_sub\n")
+}
+
+func TestTryInFunctionKeepsErrGlobal(t *testing.T) {
+	output := body(compile("fn f() {\n\ttry {\n\t\texec(\"false\")\n\t} catch e {\n\t\tprint(e.code)\n\t}\n}"))
+
+	assert.Contains(t, output, `declare -gA e=(`)
+}
+
+func TestTryRuntimeEmittedOnce(t *testing.T) {
+	output := compile("try {\n\texec(\"a\")\n} catch {\n\tprint(\"x\")\n}\ntry {\n\texec(\"b\")\n} catch {\n\tprint(\"y\")\n}")
+
+	assert.Equal(t, 1, strings.Count(output, "_try_catch() {"))
+}
+
+func TestMapFieldAccess(t *testing.T) {
+	output := body(compile("config = {host: \"h\"}\nprint(config.host)"))
+
+	assert.Contains(t, output, `echo "${config["host"]}"`)
+}
@@ -0,0 +1,159 @@
+package codegen
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/tasnimzotder/langz/internal/ast"
+)
+
+// deferRuntime keeps deferred code on a single stack shared by the whole
+// script. _defer pushes code together with the current values of the
+// variables it reads; _run_defers pops and runs entries down to a base,
+// newest first, each in its own function so captured values stay local and
+// a failing entry does not stop the others. The script unwinds everything
+// on exit, and the signal traps turn a signal into an exit so that happens
+// for them too. Functions with defers note the stack size and their depth
+// on entry and unwind back to it from a RETURN trap; Bash also runs that
+// trap when callers return, hence the depth check in _return_defers.
+const deferRuntime = `_defers=()
+_defer() {
+  local _entry="" _decl _name
+  for _name in "${@:2}"; do
+    _decl=$(declare -p "$_name" 2>/dev/null) || continue
+    _entry+="$_decl"$'\n'
+  done
+  _defers+=("$_entry$1")
+}
+
+_run_defer() {
+  eval "$1"
+}
+
+_run_defers() {
+  local _entry
+  while (( ${#_defers[@]} > $1 )); do
+    _entry=${_defers[-1]}
+    unset '_defers[-1]'
+    _run_defer "$_entry" || true
+  done
+}
+
+_return_defers() {
+  [[ ${_defer_frame:-} == "$1" ]] || return 0
+  _run_defers "$_defer_base"
+}
+trap '_run_defers 0' EXIT
+trap 'exit 129' HUP
+trap 'exit 130' INT
+trap 'exit 143' TERM`
+
+// varRefRegex finds the variables a piece of generated Bash expands.
+var varRefRegex = regexp.MustCompile(`\$\{?[#!]?([A-Za-z_][A-Za-z0-9_]*)`)
+
+// genDefer pushes the statement's code onto the defer stack. As in Go, the
+// values it uses are the ones they have when the defer statement runs, so
+// a defer in a loop cleans up after each iteration rather than the last.
+func (g *Generator) genDefer(d *ast.DeferStmt) {
+	g.useRuntime("defer", deferRuntime)
+
+	code := g.genDeferred(d.Body)
+	var names []string
+	seen := make(map[string]bool)
+	for _, m := range varRefRegex.FindAllStringSubmatch(code, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			names = append(names, m[1])
+		}
+	}
+
+	call := "_defer " + singleQuote(code)
+	if strings.Contains(code, "\n") {
+		call = "_defer " + singleQuote("\n"+code+"\n"+strings.Repeat("  ", g.indent))
+	}
+	if len(names) > 0 {
+		call += " " + strings.Join(names, " ")
+	}
+	g.writeln(call)
+}
+
+// genDeferred generates stmts on their own, for the defer stack. A single
+// statement stays on one line; a block is indented one level past the
+// defer statement.
+func (g *Generator) genDeferred(stmts []ast.Node) string {
+	sub := *g
+	sub.buf = strings.Builder{}
+	sub.indent = g.indent + 1
+	sub.tryFrames = nil
+	sub.scanned = 0
+	sub.errs = nil
+	for _, stmt := range stmts {
+		sub.genStatement(stmt)
+	}
+	g.runtime, g.tries = sub.runtime, sub.tries
+	g.errs = append(g.errs, sub.errs...)
+
+	code := strings.TrimRight(sub.buf.String(), "\n")
+	if !strings.Contains(code, "\n") {
+		code = strings.TrimSpace(code)
+	}
+	return code
+}
+
+// genDeferFrame starts a function's own defer stack, which unwinds when the
+// function returns. A value-returning function's stdout is its result, so
+// its deferred output goes to stderr.
+func (g *Generator) genDeferFrame() {
+	g.useRuntime("defer", deferRuntime)
+	g.writeln("local _defer_frame=${#FUNCNAME[@]} _defer_base=${#_defers[@]}")
+	redirect := ""
+	if isValueType(g.returnType) {
+		redirect = " >&2"
+	}
+	g.writeln(fmt.Sprintf(`trap '_return_defers "${#FUNCNAME[@]}"%s' RETURN`, redirect))
+}
+
+// hasDefer reports whether a block defers anything, including in nested
+// blocks but not in function declarations.
+func hasDefer(stmts []ast.Node) bool {
+	for _, stmt := range stmts {
+		switch n := stmt.(type) {
+		case *ast.DeferStmt:
+			return true
+		case *ast.Assignment:
+			if or, ok := n.Value.(*ast.OrExpr); ok {
+				if block, ok := or.Fallback.(*ast.BlockExpr); ok && hasDefer(block.Statements) {
+					return true
+				}
+			}
+		case *ast.ForStmt:
+			if hasDefer(n.Body) {
+				return true
+			}
+		case *ast.WhileStmt:
+			if hasDefer(n.Body) {
+				return true
+			}
+		case *ast.WithStmt:
+			if hasDefer(n.Body) {
+				return true
+			}
+		case *ast.IfStmt:
+			if hasDefer(n.Body) || hasDefer(n.ElseBody) {
+				return true
+			}
+		case *ast.MatchStmt:
+			for _, c := range n.Cases {
+				if hasDefer(c.Body) {
+					return true
+				}
+			}
+		case *ast.TryStmt:
+			if hasDefer(n.Body) || hasDefer(n.Catch) || hasDefer(n.Finally) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,218 @@
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/tasnimzotder/langz/internal/ast"
+)
+
+// digestRuntime hashes with whichever tool the system has, picked when the
+// call runs, so scripts work on GNU, BSD and BusyBox userlands alike.
+//
+// _digest algo value prints the lowercase hex digest of value: of the file
+// it names when there is one, otherwise of the string itself. sha256 and
+// sha1 try sha256sum/sha1sum, then shasum, then openssl; md5 tries md5sum,
+// then md5, then openssl.
+//
+// _verify_checksum path expected succeeds when the file's digest is
+// expected, which is a hex digest, optionally prefixed with its algorithm
+// as in sha256:..., or a line of sha256sum output. Without a prefix the
+// algorithm follows from the length. It fails, with a message, when the
+// file can't be read or expected isn't a checksum.
+const digestRuntime = `_digest_tool() {
+  case $1 in
+    md5)
+      if command -v md5sum >/dev/null 2>&1; then
+        _digest_cmd=(md5sum)
+      elif command -v md5 >/dev/null 2>&1; then
+        _digest_cmd=(md5 -q)
+      elif command -v openssl >/dev/null 2>&1; then
+        _digest_cmd=(openssl dgst -md5 -r)
+      else
+        printf '%s: md5() needs md5sum, md5 or openssl, but none was found\n' "${0##*/}" >&2
+        return 127
+      fi
+      ;;
+    *)
+      if command -v "$1sum" >/dev/null 2>&1; then
+        _digest_cmd=("$1sum")
+      elif command -v shasum >/dev/null 2>&1; then
+        _digest_cmd=(shasum -a "${1#sha}")
+      elif command -v openssl >/dev/null 2>&1; then
+        _digest_cmd=(openssl dgst "-$1" -r)
+      else
+        printf '%s: %s() needs %ssum, shasum or openssl, but none was found\n' "${0##*/}" "$1" "$1" >&2
+        return 127
+      fi
+      ;;
+  esac
+}
+_digest() {
+  local _digest_cmd=() out
+  _digest_tool "$1" || return
+  if [ -f "$2" ]; then
+    out=$("${_digest_cmd[@]}" < "$2") || return 1
+  else
+    out=$(printf '%s' "$2" | "${_digest_cmd[@]}") || return 1
+  fi
+  out=${out%%[[:space:]]*}
+  printf '%s\n' "${out,,}"
+}
+_verify_checksum() {
+  local sum algo="" want actual
+  sum=${2#"${2%%[![:space:]]*}"}
+  sum=${sum%%[[:space:]]*}
+  case $sum in
+    sha256:* | sha1:* | md5:*) algo=${sum%%:*} sum=${sum#*:} ;;
+    *)
+      case ${#sum} in
+        64) algo=sha256 ;;
+        40) algo=sha1 ;;
+        32) algo=md5 ;;
+      esac
+      ;;
+  esac
+  case $algo in
+    sha256) want=64 ;;
+    sha1) want=40 ;;
+    md5) want=32 ;;
+  esac
+  if [ -z "$algo" ] || [ "${#sum}" -ne "$want" ] || [[ $sum == *[!0-9A-Fa-f]* ]]; then
+    printf 'verify_checksum: "%s" is not a sha256, sha1 or md5 checksum\n' "$2" >&2
+    return 1
+  fi
+  if ! [ -f "$1" ] || ! [ -r "$1" ]; then
+    printf 'verify_checksum: cannot read %s\n' "$1" >&2
+    return 1
+  fi
+  actual=$(_digest "$algo" "$1") || return 1
+  [ "$actual" = "${sum,,}" ]
+}`
+
+// digestPreflight stops a script that hashes with algo before it does
+// anything when no tool for it is installed.
+func digestPreflight(algo string) string {
+	return fmt.Sprintf("_digest_tool %s || exit 127", algo)
+}
+
+// encodeRuntime encodes and decodes strings. base64 uses base64, then
+// openssl. Hex and URL encoding work byte by byte in Bash itself, so they
+// need no tools at all. url_encode keeps only unreserved characters
+// (A-Z a-z 0-9 - . _ ~); url_decode turns %XX back into bytes and leaves
+// + alone.
+const encodeRuntime = `_encode_url_escape='%([^0-9A-Fa-f]|[0-9A-Fa-f][^0-9A-Fa-f]|[0-9A-Fa-f]?$)'
+_base64_tool() {
+  if command -v base64 >/dev/null 2>&1; then
+    _base64_cmd=(base64)
+  elif command -v openssl >/dev/null 2>&1; then
+    _base64_cmd=(openssl base64 -A)
+  else
+    printf '%s: base64 encoding needs base64 or openssl, but neither was found\n' "${0##*/}" >&2
+    return 127
+  fi
+}
+_base64_encode() {
+  local _base64_cmd=() out
+  _base64_tool || return
+  out=$(printf '%s' "$1" | "${_base64_cmd[@]}") || return 1
+  printf '%s\n' "${out//$'\n'/}"
+}
+_base64_decode() {
+  local _base64_cmd=() text=${1//[[:space:]]/}
+  _base64_tool || return
+  if ! [[ $text =~ ^[A-Za-z0-9+/]*=?=?$ ]] || [ $((${#text} % 4)) -ne 0 ]; then
+    printf 'base64_decode: invalid base64 "%s"\n' "$1" >&2
+    return 1
+  fi
+  printf '%s\n' "$text" | "${_base64_cmd[@]}" -d
+}
+_encode_byte() {
+  printf -v _encode_n '%d' "'$1"
+  [ "$_encode_n" -ge 0 ] || _encode_n=$((_encode_n + 256))
+}
+_hex_encode() {
+  local LC_ALL=C text=$1 out="" i _encode_n
+  for ((i = 0; i < ${#text}; i++)); do
+    _encode_byte "${text:i:1}"
+    printf -v out '%s%02x' "$out" "$_encode_n"
+  done
+  printf '%s\n' "$out"
+}
+_url_encode() {
+  local LC_ALL=C text=$1 out="" c i _encode_n
+  for ((i = 0; i < ${#text}; i++)); do
+    c=${text:i:1}
+    case $c in
+      [A-Za-z0-9._~-]) out+=$c ;;
+      *)
+        _encode_byte "$c"
+        printf -v out '%s%%%02X' "$out" "$_encode_n"
+        ;;
+    esac
+  done
+  printf '%s\n' "$out"
+}
+_url_decode() {
+  local text=$1
+  if [[ $text =~ $_encode_url_escape ]]; then
+    printf 'url_decode: invalid escape "%s" in "%s"\n' "${BASH_REMATCH[0]}" "$1" >&2
+    return 1
+  fi
+  text=${text//\\/\\\\}
+  printf '%b\n' "${text//%/\\x}"
+}`
+
+// isDigestBuiltin reports whether name is one of the hashing and encoding
+// builtins.
+func isDigestBuiltin(name string) bool {
+	_, ok := digestParams[name]
+	return ok
+}
+
+// digestParams names the arguments of each hashing and encoding builtin,
+// for errors.
+var digestParams = map[string][]string{
+	"sha256":          {"path_or_string"},
+	"sha1":            {"path_or_string"},
+	"md5":             {"path_or_string"},
+	"base64_encode":   {"text"},
+	"base64_decode":   {"text"},
+	"hex_encode":      {"text"},
+	"url_encode":      {"text"},
+	"url_decode":      {"text"},
+	"verify_checksum": {"path", "expected"},
+}
+
+// genDigestCall generates the hashing and encoding builtins as values.
+func (g *Generator) genDigestCall(call *ast.FuncCall) string {
+	params := digestParams[call.Name]
+	if len(call.Args) != len(params) {
+		return fmt.Sprintf("# error: %s() requires %s", call.Name, describeParams(params))
+	}
+	value := g.genExpr(call.Args[0])
+	switch call.Name {
+	case "sha256", "sha1", "md5":
+		g.useRuntime("digest", digestRuntime)
+		g.useRuntime("digest "+call.Name, digestPreflight(call.Name))
+		return fmt.Sprintf(`"$(_digest %s %s)"`, call.Name, value)
+	case "verify_checksum":
+		return fmt.Sprintf(`"$(%s && echo true || echo false)"`, g.genVerifyChecksum(call))
+	default:
+		g.useRuntime("encode", encodeRuntime)
+		if call.Name == "base64_encode" || call.Name == "base64_decode" {
+			g.useRuntime("encode base64", "_base64_tool || exit 127")
+		}
+		return fmt.Sprintf(`"$(_%s %s)"`, call.Name, value)
+	}
+}
+
+// genVerifyChecksum tests that a file matches its checksum. The algorithm
+// is only known once expected is, so no tool is required up front; a
+// missing one makes the check fail.
+func (g *Generator) genVerifyChecksum(call *ast.FuncCall) string {
+	if len(call.Args) != 2 {
+		return "# error: verify_checksum() requires " + describeParams(digestParams["verify_checksum"])
+	}
+	g.useRuntime("digest", digestRuntime)
+	return fmt.Sprintf("_verify_checksum %s %s", g.genExpr(call.Args[0]), g.genExpr(call.Args[1]))
+}
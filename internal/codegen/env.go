@@ -0,0 +1,199 @@
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/tasnimzotder/langz/internal/ast"
+	"github.com/tasnimzotder/langz/internal/codegen/builtins"
+)
+
+// envRuntime reads and writes settings files without running them.
+//
+// _load_env exports the KEY=value lines of a dotenv file, skipping
+// variables already set unless override is true. Lines may start with
+// export, and # begins a comment at the start of a line or after
+// whitespace. 'single' quotes are literal; "double" quotes take \n, \t,
+// \", \\, \$ and \` escapes; either may span lines. Nothing is expanded.
+//
+// _read_ini fills a map with the key = value lines of an INI file, keyed
+// section.key under a [section]. Values are read like dotenv values, and
+// ; starts a comment too.
+//
+// _write_env writes a map as a dotenv file, sorted by key, quoting values
+// so that both _load_env and Bash's source read them back unchanged.
+const envRuntime = `_env_assign='^[[:space:]]*(export[[:space:]]+)?([A-Za-z_][A-Za-z0-9_]*)[[:space:]]*=(.*)$'
+_ini_blank='^[[:space:]]*([#;].*)?$'
+_ini_header='^[[:space:]]*\[([^]]*)\][[:space:]]*$'
+_ini_assign='^[[:space:]]*([^=[:space:]][^=]*)=(.*)$'
+_env_fail() {
+  printf '%s: %s, line %d: %s\n' "$1" "$2" "$3" "$4" >&2
+}
+_env_value() {
+  local q c
+  _env_rest=${_env_rest#"${_env_rest%%[![:space:]]*}"}
+  q=${_env_rest:0:1}
+  if [ "$q" != "'" ] && [ "$q" != '"' ]; then
+    _env_val=" $_env_rest"
+    _env_val=${_env_val%%[[:space:]][$3]*}
+    _env_val=${_env_val#"${_env_val%%[![:space:]]*}"}
+    _env_val=${_env_val%"${_env_val##*[![:space:]]}"}
+    return 0
+  fi
+  _env_rest=${_env_rest:1} _env_val=""
+  while :; do
+    while [ -n "$_env_rest" ]; do
+      c=${_env_rest:0:1} _env_rest=${_env_rest:1}
+      if [ "$c" = "$q" ]; then
+        if ! [[ $_env_rest =~ ^[[:space:]]*([$3].*)?$ ]]; then
+          _env_fail "$1" "$2" "$_env_start" "unexpected text after closing $q"
+          return 1
+        fi
+        return 0
+      fi
+      if [ "$q" = '"' ] && [ "$c" = '\' ] && [ -n "$_env_rest" ]; then
+        c=${_env_rest:0:1} _env_rest=${_env_rest:1}
+        case $c in
+          n) c=$'\n' ;;
+          t) c=$'\t' ;;
+          '"' | '\' | '$' | '` + "`" + `') ;;
+          *) c="\\$c" ;;
+        esac
+      fi
+      _env_val+=$c
+    done
+    if [ "$_env_i" -ge "${#_env_lines[@]}" ]; then
+      _env_fail "$1" "$2" "$_env_start" "missing closing $q"
+      return 1
+    fi
+    _env_val+=$'\n'
+    _env_rest=${_env_lines[_env_i]%$'\r'}
+    _env_i=$((_env_i + 1))
+  done
+}
+_env_read() {
+  if ! [ -r "$2" ]; then
+    printf '%s: cannot read %s\n' "$1" "$2" >&2
+    return 1
+  fi
+  mapfile -t _env_lines < "$2"
+}
+_load_env() {
+  local _env_lines=() _env_i=0 _env_start _env_line _env_key _env_rest _env_val
+  _env_read load_env "$1" || return 1
+  while [ "$_env_i" -lt "${#_env_lines[@]}" ]; do
+    _env_line=${_env_lines[_env_i]%$'\r'}
+    _env_i=$((_env_i + 1))
+    _env_start=$_env_i
+    if [[ $_env_line =~ ^[[:space:]]*(#.*)?$ ]]; then
+      continue
+    fi
+    if ! [[ $_env_line =~ $_env_assign ]]; then
+      _env_fail load_env "$1" "$_env_i" "expected KEY=value"
+      return 1
+    fi
+    _env_key=${BASH_REMATCH[2]} _env_rest=${BASH_REMATCH[3]}
+    _env_value load_env "$1" '#' || return 1
+    if [ "$2" = true ] || [ -z "${!_env_key+x}" ]; then
+      declare -gx "$_env_key=$_env_val"
+    fi
+  done
+}
+_read_ini() {
+  local -n _ini_map=$2
+  local _env_lines=() _env_i=0 _env_start _env_line _env_rest _env_val _ini_section="" _ini_key
+  _env_read read_ini "$1" || return 1
+  while [ "$_env_i" -lt "${#_env_lines[@]}" ]; do
+    _env_line=${_env_lines[_env_i]%$'\r'}
+    _env_i=$((_env_i + 1))
+    _env_start=$_env_i
+    if [[ $_env_line =~ $_ini_blank ]]; then
+      continue
+    fi
+    if [[ $_env_line =~ $_ini_header ]]; then
+      _ini_section=${BASH_REMATCH[1]}
+      _ini_section=${_ini_section#"${_ini_section%%[![:space:]]*}"}
+      _ini_section=${_ini_section%"${_ini_section##*[![:space:]]}"}
+      continue
+    fi
+    if ! [[ $_env_line =~ $_ini_assign ]]; then
+      _env_fail read_ini "$1" "$_env_i" "expected key = value or [section]"
+      return 1
+    fi
+    _ini_key=${BASH_REMATCH[1]} _env_rest=${BASH_REMATCH[2]}
+    _ini_key=${_ini_key%"${_ini_key##*[![:space:]]}"}
+    _env_value read_ini "$1" '#;' || return 1
+    _ini_map[${_ini_section:+$_ini_section.}$_ini_key]=$_env_val
+  done
+}
+_write_env() {
+  local _env_file=$1 _env_out="" _env_key _env_val _env_keys=()
+  local -A _env_map=()
+  if [ -n "$2" ]; then
+    local -n _env_src=$2
+    for _env_key in "${!_env_src[@]}"; do
+      _env_map[$_env_key]=${_env_src[$_env_key]}
+    done
+  fi
+  shift 2
+  while [ $# -gt 1 ]; do
+    _env_map[$1]=$2
+    shift 2
+  done
+  [ "${#_env_map[@]}" -eq 0 ] || mapfile -t _env_keys < <(printf '%s\n' "${!_env_map[@]}" | LC_ALL=C sort)
+  for _env_key in "${_env_keys[@]}"; do
+    if ! [[ $_env_key =~ ^[A-Za-z_][A-Za-z0-9_]*$ ]]; then
+      printf 'write_env: %s: invalid variable name "%s"\n' "$_env_file" "$_env_key" >&2
+      return 1
+    fi
+    _env_val=${_env_map[$_env_key]}
+    if [[ $_env_val =~ ^[A-Za-z0-9_./:@%+,=-]*$ ]]; then
+      _env_out+="$_env_key=$_env_val"$'\n'
+    elif [[ $_env_val != *[\'$'\n']* ]]; then
+      _env_out+="$_env_key='$_env_val'"$'\n'
+    else
+      _env_val=${_env_val//\\/\\\\}
+      _env_val=${_env_val//\"/\\\"}
+      _env_val=${_env_val//\$/\\\$}
+      _env_val=${_env_val//\` + "`" + `/\\\` + "`" + `}
+      _env_out+="$_env_key=\"$_env_val\""$'\n'
+    fi
+  done
+  printf '%s' "$_env_out" > "$_env_file"
+}`
+
+// genLoadEnv generates load_env(path, override:).
+func (g *Generator) genLoadEnv(call *ast.FuncCall) string {
+	if len(call.Args) != 1 {
+		return "# error: load_env() requires 1 argument (path)"
+	}
+	g.useRuntime("env", envRuntime)
+	override := "false"
+	if kw, ok := builtins.FindKwarg(call.KwArgs, "override"); ok {
+		override = quoteExpr(g.genExpr(kw))
+	}
+	return fmt.Sprintf("_load_env %s %s", g.genExpr(call.Args[0]), override)
+}
+
+// genReadIniAssignment declares name as a map and fills it from the file.
+func (g *Generator) genReadIniAssignment(name string, call *ast.FuncCall) {
+	if len(call.Args) != 1 {
+		g.writeln("# error: read_ini() requires 1 argument (path)")
+		return
+	}
+	g.useRuntime("env", envRuntime)
+	g.genMapAssignment(name, &ast.MapLiteral{})
+	g.writeln(fmt.Sprintf("_read_ini %s %s", g.genExpr(call.Args[0]), name))
+}
+
+// genWriteEnv generates write_env(path, map).
+func (g *Generator) genWriteEnv(call *ast.FuncCall) string {
+	if len(call.Args) != 2 {
+		return "# error: write_env() requires 2 arguments (path, map)"
+	}
+	g.useRuntime("env", envRuntime)
+	return fmt.Sprintf("_write_env %s %s", g.genExpr(call.Args[0]), g.genMapOrPairs(call.Args[1], g.genEnvValue))
+}
+
+func (g *Generator) genEnvValue(node ast.Node) string {
+	return quoteExpr(g.genExpr(node))
+}
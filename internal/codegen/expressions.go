@@ -9,7 +9,9 @@ import (
 	"github.com/tasnimzotder/langz/internal/codegen/builtins"
 )
 
-var interpRegex = regexp.MustCompile(`\{(\w+)\}`)
+// interpRegex matches {name}; names can't start with a digit, so regex
+// quantifiers like {3} are left alone.
+var interpRegex = regexp.MustCompile(`\{([A-Za-z_]\w*)\}`)
 
 // interpolate converts Langz string interpolation {var} to Bash ${var}.
 func interpolate(s string) string {
@@ -25,28 +27,56 @@ func bashEscape(s string) string {
 	return s
 }
 
+// singleQuote quotes s for Bash so that nothing in it is expanded.
+func singleQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// isTestBuiltin reports whether the builtin name renders as a test
+// command, which gives true or false when used as a value.
+func isTestBuiltin(name string) bool {
+	return name == "has" || name == "is_running"
+}
+
 func (g *Generator) genExpr(node ast.Node) string {
 	switch n := node.(type) {
 	case *ast.StringLiteral:
+		if n.Raw {
+			return singleQuote(n.Value)
+		}
 		return fmt.Sprintf(`"%s"`, interpolate(bashEscape(n.Value)))
 	case *ast.IntLiteral:
 		return n.Value
+	case *ast.FloatLiteral:
+		return n.Value
 	case *ast.BoolLiteral:
 		if n.Value {
 			return "true"
 		}
 		return "false"
 	case *ast.Identifier:
+		if value, ok := g.shellValues[n]; ok {
+			return value
+		}
 		return fmt.Sprintf(`"$%s"`, n.Name)
 	case *ast.FuncCall:
 		return g.genFuncCallExpr(n)
 	case *ast.DotExpr:
-		obj := g.genExpr(n.Object)
-		return fmt.Sprintf("%s.%s", obj, n.Field)
+		if g.isJSON(n) {
+			return g.genJSONRead(n)
+		}
+		if g.isMap(n.Object) {
+			// config.host reads the "host" key
+			return fmt.Sprintf(`"${%s["%s"]}"`, g.genVarName(n.Object), n.Field)
+		}
+		return fmt.Sprintf("# error: field access .%s requires a map or JSON value", n.Field)
 	case *ast.BinaryExpr:
 		if n.Op == "|>" {
 			return g.genPipeExpr(n)
 		}
+		if isArithmeticOp(n.Op) && g.isFloatExpr(n) {
+			return g.genFloatArith(n)
+		}
 		if isArithmeticOp(n.Op) {
 			prec := arithPrecedence(n.Op)
 			return fmt.Sprintf("$((%s %s %s))", g.genArithOperandPrec(n.Left, prec), n.Op, g.genArithOperandPrec(n.Right, prec))
@@ -72,58 +102,94 @@ func (g *Generator) genExpr(node ast.Node) string {
 }
 
 func (g *Generator) genFuncCallExpr(f *ast.FuncCall) string {
+	if f.Name == "to_json" && len(f.Args) == 1 {
+		return quoteExpr(g.genJSONText(f.Args[0]))
+	}
+	if f.Name == "len" && len(f.Args) == 1 && g.isJSON(f.Args[0]) {
+		return g.genJSONLen(f.Args[0])
+	}
+	if f.Name == "run" || f.Name == "cmd" {
+		// Used as a value, a command gives its output
+		return fmt.Sprintf(`"$(%s)"`, g.genRunWords(f))
+	}
+	if f.Name == "lines" {
+		return "# error: lines() can only be assigned to a variable or looped over"
+	}
+	if f.Name == "read_ini" {
+		return "# error: read_ini() can only be assigned to a variable"
+	}
+	if f.Name == "list_archive" {
+		return "# error: list_archive() can only be assigned to a variable or looped over"
+	}
+	if isPrompt(f.Name) {
+		return g.genPrompt(f)
+	}
+	if f.Name == "render" {
+		return g.genRender(f)
+	}
+	if f.Name == "tempfile" || f.Name == "tempdir" {
+		return g.genTempCall(f)
+	}
+	if isJSONBuiltin(f.Name) {
+		return g.genJSONCall(f)
+	}
+	if isDigestBuiltin(f.Name) {
+		return g.genDigestCall(f)
+	}
+	if f.Name == "json_get" || f.Name == "parse_json" {
+		g.useRuntime("jq", jqRuntime)
+	}
 	result := builtins.GenExpr(f.Name, f.Args, f.KwArgs, g.genExpr, g.genRawValue)
+	if result.OK && isTestBuiltin(f.Name) && !strings.HasPrefix(result.Code, "# error:") {
+		// Used as a value, a test gives true or false
+		return fmt.Sprintf(`"$(%s && echo true || echo false)"`, result.Code)
+	}
 	if result.OK {
 		return result.Code
 	}
 
-	// User-defined function call
-	args := make([]string, len(f.Args))
-	for i, arg := range f.Args {
-		args[i] = g.genExpr(arg)
+	// User-defined function call; value-returning functions are captured,
+	// quoted so the value stays one word. A list is split into its lines.
+	if g.returnsValue(f.Name) {
+		if g.funcs[f.Name].ReturnType == "list" {
+			return fmt.Sprintf("$(%s)", g.genUserCall(f))
+		}
+		return fmt.Sprintf(`"$(%s)"`, g.genUserCall(f))
 	}
-	return fmt.Sprintf("%s %s", f.Name, strings.Join(args, " "))
+	return g.genUserCall(f)
 }
 
-// genPipeExpr transforms a |> f into f(a) by synthesizing a FuncCall.
-func (g *Generator) genPipeExpr(expr *ast.BinaryExpr) string {
-	switch right := expr.Right.(type) {
-	case *ast.Identifier:
-		// data |> upper → upper(data)
-		call := &ast.FuncCall{Name: right.Name, Args: []ast.Node{expr.Left}}
-		return g.genFuncCallExpr(call)
-	case *ast.FuncCall:
-		// data |> json_get(".name") → json_get(data, ".name")
-		args := make([]ast.Node, 0, 1+len(right.Args))
-		args = append(args, expr.Left)
-		args = append(args, right.Args...)
-		call := &ast.FuncCall{Name: right.Name, Args: args, KwArgs: right.KwArgs}
-		return g.genFuncCallExpr(call)
-	default:
-		return "# error: pipe target must be a function"
+// quoteExpr wraps an expression in double quotes unless it already is quoted.
+func quoteExpr(s string) string {
+	if strings.HasPrefix(s, `"`) {
+		return s
 	}
+	return fmt.Sprintf(`"%s"`, s)
 }
 
-// sanitizeMapKey replaces non-alphanumeric characters with underscores
-// so map keys produce valid Bash variable names.
-func sanitizeMapKey(key string) string {
-	var b strings.Builder
-	for _, ch := range key {
-		if (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9') || ch == '_' {
-			b.WriteRune(ch)
-		} else {
-			b.WriteByte('_')
-		}
+// genPipeExpr transforms a |> f into f(a) by synthesizing a FuncCall. A
+// chain with commands in it becomes a shell pipeline.
+func (g *Generator) genPipeExpr(expr *ast.BinaryExpr) string {
+	stages := pipeStages(expr)
+	if last := g.lastCommand(stages); last >= 0 {
+		return g.genPipeValue(stages, last)
+	}
+	call, ok := pipeCall(expr)
+	if !ok {
+		return "# error: pipe target must be a function"
 	}
-	return b.String()
+	return g.genFuncCallExpr(call)
 }
 
 func (g *Generator) genIndexExpr(n *ast.IndexExpr) string {
+	if g.isJSON(n.Object) {
+		return g.genJSONRead(n)
+	}
 	obj := g.genVarName(n.Object)
-	switch idx := n.Index.(type) {
+	switch n.Index.(type) {
 	case *ast.StringLiteral:
-		// Map access with string key: config["host"] → "$config_host"
-		return fmt.Sprintf(`"$%s_%s"`, obj, sanitizeMapKey(idx.Value))
+		// Map access with string key: config["host"] → "${config["host"]}"
+		return fmt.Sprintf(`"${%s[%s]}"`, obj, g.genExpr(n.Index))
 	default:
 		// Array access: items[0] or items[i] → "${items[idx]}"
 		return fmt.Sprintf(`"${%s[%s]}"`, obj, g.genRawValue(n.Index))
@@ -139,6 +205,9 @@ func (g *Generator) genVarName(node ast.Node) string {
 }
 
 func (g *Generator) genMethodCall(m *ast.MethodCall) string {
+	if isLogCall(m) {
+		return fmt.Sprintf("# error: log.%s() does not return a value", m.Method)
+	}
 	obj := g.genVarName(m.Object)
 	switch m.Method {
 	case "replace":
@@ -174,24 +243,33 @@ func (g *Generator) genMethodCall(m *ast.MethodCall) string {
 		return fmt.Sprintf(`$(IFS='%s'; echo "${%s[*]}")`, sep, obj)
 	case "length":
 		return fmt.Sprintf("${#%s}", obj)
+	case "matches":
+		if len(m.Args) != 1 {
+			return "# error: matches() requires 1 argument (pattern)"
+		}
+		return g.genFuncCallExpr(&ast.FuncCall{Name: "matches", Args: []ast.Node{m.Object, m.Args[0]}})
 	default:
 		return fmt.Sprintf("# error: unknown method %s", m.Method)
 	}
 }
 
 func (g *Generator) genSplitAssignment(name string, mc *ast.MethodCall) {
-	obj := g.genVarName(mc.Object)
 	sep := g.genRawValue(mc.Args[0])
-	g.writeln(fmt.Sprintf(`IFS='%s' read -ra %s <<< "$%s"`, sep, name, obj))
+	g.writeln(fmt.Sprintf(`IFS='%s' read -ra %s <<< %s`, sep, name, quoteExpr(g.genExpr(mc.Object))))
 }
 
 // genRawValue extracts the raw value from a node without quoting.
 func (g *Generator) genRawValue(node ast.Node) string {
 	switch n := node.(type) {
 	case *ast.StringLiteral:
+		if n.Raw {
+			return n.Value
+		}
 		return interpolate(n.Value)
 	case *ast.IntLiteral:
 		return n.Value
+	case *ast.FloatLiteral:
+		return n.Value
 	case *ast.Identifier:
 		return fmt.Sprintf("$%s", n.Name)
 	default:
@@ -208,6 +286,12 @@ func (g *Generator) genCondition(node ast.Node) string {
 		if n.Op == "or" {
 			return fmt.Sprintf("%s || %s", g.genCondition(n.Left), g.genCondition(n.Right))
 		}
+		if n.Op == "|>" {
+			return g.genPipeCondition(n)
+		}
+		if g.isFloatComparison(n) {
+			return g.genFloatComparison(n)
+		}
 		left := g.genConditionOperand(n.Left)
 		right := g.genConditionOperand(n.Right)
 		op := bashCompareOp(n.Op)
@@ -218,9 +302,35 @@ func (g *Generator) genCondition(node ast.Node) string {
 		}
 		return g.genExpr(node)
 	case *ast.FuncCall:
+		if g.isCommand(n) {
+			return g.genRunWords(n)
+		}
+		if n.Name == "confirm" {
+			return g.genPromptCondition(n)
+		}
+		if n.Name == "json_valid" {
+			return g.genJSONValid(n)
+		}
+		if n.Name == "verify_checksum" {
+			return g.genVerifyChecksum(n)
+		}
+		if isTestBuiltin(n.Name) {
+			return builtins.GenExpr(n.Name, n.Args, n.KwArgs, g.genExpr, g.genRawValue).Code
+		}
 		return g.genFuncCallExpr(n)
 	case *ast.Identifier:
 		return fmt.Sprintf(`[ "$%s" = true ]`, n.Name)
+	case *ast.DotExpr:
+		if g.isJSON(n) || g.isMap(n.Object) {
+			// result.ok holds true or false
+			return fmt.Sprintf(`[ %s = true ]`, g.genExpr(n))
+		}
+		return g.genExpr(node)
+	case *ast.IndexExpr:
+		if g.isJSON(n) {
+			return fmt.Sprintf(`[ %s = true ]`, g.genExpr(n))
+		}
+		return g.genExpr(node)
 	default:
 		return g.genExpr(node)
 	}
@@ -232,8 +342,10 @@ func (g *Generator) genConditionOperand(node ast.Node) string {
 		return fmt.Sprintf(`"$%s"`, n.Name)
 	case *ast.IntLiteral:
 		return n.Value
+	case *ast.FloatLiteral:
+		return n.Value
 	case *ast.StringLiteral:
-		return fmt.Sprintf(`"%s"`, interpolate(bashEscape(n.Value)))
+		return g.genExpr(n)
 	default:
 		return g.genExpr(node)
 	}
@@ -242,12 +354,20 @@ func (g *Generator) genConditionOperand(node ast.Node) string {
 func (g *Generator) genForCollection(node ast.Node) string {
 	switch n := node.(type) {
 	case *ast.Identifier:
+		if g.maps[n.Name] {
+			// Iterating a map visits its keys
+			return fmt.Sprintf(`"${!%s[@]}"`, n.Name)
+		}
 		return fmt.Sprintf(`"${%s[@]}"`, n.Name)
 	case *ast.FuncCall:
 		expr := g.genFuncCallExpr(n)
 		if strings.HasPrefix(expr, "$(") {
 			return expr
 		}
+		if strings.HasPrefix(expr, "(") && strings.HasSuffix(expr, ")") {
+			// List-valued builtins like glob() and keys() render as (...)
+			return expr[1 : len(expr)-1]
+		}
 		return fmt.Sprintf("$(%s)", expr)
 	default:
 		return g.genExpr(node)
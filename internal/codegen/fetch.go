@@ -92,10 +92,13 @@ func buildCurlCmd(opts fetchOptions) string {
 	return strings.Join(parts, " ")
 }
 
-// emitCurlCore writes the tmpfile setup, curl call, and cleanup.
+// emitCurlCore writes the tmpfile setup, curl call, and cleanup. The temp
+// files come from the same place as tempfile()'s, so a fetch cut short by
+// an error or signal leaves nothing behind either.
 func (g *Generator) emitCurlCore(opts fetchOptions) {
-	g.writeln(`_tmp_headers=$(mktemp)`)
-	g.writeln(`_tmp_body=$(mktemp)`)
+	g.useTemp()
+	g.writeln(`_tmp_headers=$(_temp_new fetch "")`)
+	g.writeln(`_tmp_body=$(_temp_new fetch "")`)
 	g.writeln(fmt.Sprintf(`_status=$(%s) || true`, buildCurlCmd(opts)))
 	g.writeln(`_body=$(cat "$_tmp_body")`)
 	g.writeln(`_headers=$(cat "$_tmp_headers")`)
@@ -0,0 +1,274 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tasnimzotder/langz/internal/ast"
+)
+
+// flagsRuntime reports a command-line error the way getopt-style tools
+// do, exiting with the conventional usage status 2.
+const flagsRuntime = `_flags_error() {
+  printf '%s: %s\n' "${0##*/}" "$1" >&2
+  printf "Run '%s --help' for usage.\n" "${0##*/}" >&2
+  exit 2
+}`
+
+// flagOption returns the command-line spelling of a flag: dry_run is
+// --dry-run.
+func flagOption(f ast.Flag) string {
+	return "--" + strings.ReplaceAll(f.Name, "_", "-")
+}
+
+// genFlags parses the script's arguments into the flags' variables.
+// Flags may be given as --name value or --name=value, bools also as
+// --name and --no-name, and lists once per element. What is not a flag,
+// and everything after --, is left in "$@" for args(). A flag that is not
+// given takes its environment variable, then its default, then the zero
+// value of its type; the result is checked against the type either way.
+func (g *Generator) genFlags(d *ast.FlagsDecl) {
+	g.useRuntime("flags", flagsRuntime)
+	g.genFlagsUsage(d)
+
+	var scalars []string
+	for _, f := range d.Flags {
+		switch f.Type {
+		case "list":
+			g.lists[f.Name] = true
+		case "float":
+			g.floats[f.Name] = true
+		}
+		if f.Type != "list" {
+			scalars = append(scalars, f.Name)
+		}
+	}
+	if len(scalars) > 0 {
+		g.writeln("unset -v " + strings.Join(scalars, " "))
+	}
+	for _, f := range d.Flags {
+		if f.Type == "list" {
+			g.writeln(f.Name + "=()")
+		}
+	}
+	g.writeln("_args=()")
+	g.writeln(`while [ $# -gt 0 ]; do`)
+	g.indent++
+	g.writeln(`case "$1" in`)
+	g.indent++
+	g.writeln("-h | --help) _flags_usage; exit 0 ;;")
+	for _, f := range d.Flags {
+		g.genFlagCases(f)
+	}
+	g.writeln(`--) shift; _args+=("$@"); break ;;`)
+	g.writeln(`-?*) _flags_error "unknown flag: $1" ;;`)
+	g.writeln(`*) _args+=("$1") ;;`)
+	g.indent--
+	g.writeln("esac")
+	g.writeln("shift")
+	g.indent--
+	g.writeln("done")
+	g.writeln(`set -- "${_args[@]}"`)
+
+	for _, f := range d.Flags {
+		if f.Type == "list" {
+			g.genListFlagValue(f)
+		} else {
+			g.genFlagValue(f)
+		}
+	}
+}
+
+func (g *Generator) genFlagCases(f ast.Flag) {
+	opt := flagOption(f)
+	set := fmt.Sprintf("%s=$2", f.Name)
+	setInline := fmt.Sprintf("%s=${1#*=}", f.Name)
+	if f.Type == "list" {
+		set = fmt.Sprintf(`%s+=("$2")`, f.Name)
+		setInline = fmt.Sprintf(`%s+=("${1#*=}")`, f.Name)
+	}
+	if f.Type == "bool" {
+		g.writeln(fmt.Sprintf("%s) %s=true ;;", opt, f.Name))
+		g.writeln(fmt.Sprintf("--no-%s) %s=false ;;", opt[2:], f.Name))
+	} else {
+		g.writeln(fmt.Sprintf(`%s) [ $# -ge 2 ] || _flags_error "%s needs a value"; %s; shift ;;`, opt, opt, set))
+	}
+	g.writeln(fmt.Sprintf("%s=*) %s ;;", opt, setInline))
+}
+
+// genFlagValue fills in a scalar flag that was not given and checks its
+// value.
+func (g *Generator) genFlagValue(f ast.Flag) {
+	given := fmt.Sprintf(`[ -n "${%s+x}" ]`, f.Name)
+	if f.Env != "" {
+		g.writeln(fmt.Sprintf(`%s || [ -z "${%s:-}" ] || %s=$%s`, given, f.Env, f.Name, f.Env))
+	}
+	switch {
+	case f.Required:
+		g.writeln(fmt.Sprintf(`%s || _flags_error "%s"`, given, missingFlag(f)))
+	case f.Default != nil:
+		g.writeln(fmt.Sprintf("%s || %s=%s", given, f.Name, g.genExpr(f.Default)))
+	default:
+		g.writeln(fmt.Sprintf("%s || %s=%s", given, f.Name, zeroValue(f.Type)))
+	}
+
+	opt := flagOption(f)
+	switch f.Type {
+	case "int", "float":
+		re := `^-?[0-9]+$`
+		if f.Type == "float" {
+			re = `^-?[0-9]+(\.[0-9]+)?$`
+		}
+		g.writeln(fmt.Sprintf(`[[ $%s =~ %s ]] || _flags_error "%s must be %s, got '$%s'"`,
+			f.Name, re, opt, article(f.Type), f.Name))
+	case "bool":
+		g.writeln(fmt.Sprintf(`case "$%s" in`, f.Name))
+		g.indent++
+		g.writeln(fmt.Sprintf("true | 1 | yes) %s=true ;;", f.Name))
+		g.writeln(fmt.Sprintf("false | 0 | no) %s=false ;;", f.Name))
+		g.writeln(fmt.Sprintf(`*) _flags_error "%s must be true or false, got '$%s'" ;;`, opt, f.Name))
+		g.indent--
+		g.writeln("esac")
+	}
+}
+
+// genListFlagValue fills in a list flag that was not given. Its
+// environment variable holds the elements separated by commas.
+func (g *Generator) genListFlagValue(f ast.Flag) {
+	given := fmt.Sprintf(`[ ${#%s[@]} -gt 0 ]`, f.Name)
+	if f.Env != "" {
+		g.writeln(fmt.Sprintf(`if ! %s && [ -n "${%s:-}" ]; then`, given, f.Env))
+		g.indent++
+		g.writeln(fmt.Sprintf(`IFS=, read -ra %s <<< "$%s"`, f.Name, f.Env))
+		g.indent--
+		g.writeln("fi")
+	}
+	switch {
+	case f.Required:
+		g.writeln(fmt.Sprintf(`%s || _flags_error "%s"`, given, missingFlag(f)))
+	case f.Default != nil:
+		g.writeln(fmt.Sprintf("%s || %s=%s", given, f.Name, g.genExpr(f.Default)))
+	}
+}
+
+func missingFlag(f ast.Flag) string {
+	msg := "missing required flag " + flagOption(f)
+	if f.Env != "" {
+		msg += " (or set " + f.Env + ")"
+	}
+	return msg
+}
+
+func zeroValue(typ string) string {
+	switch typ {
+	case "int", "float":
+		return "0"
+	case "bool":
+		return "false"
+	}
+	return `""`
+}
+
+func article(typ string) string {
+	if typ == "int" {
+		return "an int"
+	}
+	return "a " + typ
+}
+
+// genFlagsUsage defines _flags_usage, which prints the --help text: one
+// line per flag with its help, default, environment variable and whether
+// it is required.
+func (g *Generator) genFlagsUsage(d *ast.FlagsDecl) {
+	names := make([]string, 0, len(d.Flags)+1)
+	for _, f := range d.Flags {
+		name := flagOption(f)
+		switch f.Type {
+		case "bool":
+			name = "--[no-]" + name[2:]
+		case "list":
+			name += " <value>..."
+		default:
+			name += " <" + f.Type + ">"
+		}
+		names = append(names, name)
+	}
+	names = append(names, "-h, --help")
+	width := 0
+	for _, name := range names {
+		width = max(width, len(name))
+	}
+
+	lines := []string{"Options:"}
+	for i, f := range d.Flags {
+		lines = append(lines, strings.TrimRight(fmt.Sprintf("  %-*s  %s", width, names[i], flagHelp(f)), " "))
+	}
+	lines = append(lines, fmt.Sprintf("  %-*s  %s", width, "-h, --help", "Show this help"))
+	quoted := make([]string, len(lines))
+	for i, line := range lines {
+		quoted[i] = singleQuote(line)
+	}
+
+	g.writeln("_flags_usage() {")
+	g.indent++
+	g.writeln(`printf 'Usage: %s [options] [args...]\n\n' "${0##*/}"`)
+	g.writeln("printf '%s\\n' \\")
+	g.indent++
+	for i, line := range quoted {
+		if i < len(quoted)-1 {
+			line += " \\"
+		}
+		g.writeln(line)
+	}
+	g.indent--
+	g.indent--
+	g.writeln("}")
+}
+
+// flagHelp is the description of f in the --help text.
+func flagHelp(f ast.Flag) string {
+	var notes []string
+	if f.Required {
+		notes = append(notes, "required")
+	}
+	if def, ok := literalText(f.Default); ok && !(f.Type == "bool" && def == "false") {
+		notes = append(notes, "default: "+def)
+	}
+	if f.Env != "" {
+		notes = append(notes, "env: "+f.Env)
+	}
+	help := f.Help
+	if len(notes) > 0 {
+		if help != "" {
+			help += " "
+		}
+		help += "(" + strings.Join(notes, ", ") + ")"
+	}
+	return help
+}
+
+// literalText renders a literal default as it would be typed on the
+// command line.
+func literalText(node ast.Node) (string, bool) {
+	switch n := node.(type) {
+	case *ast.StringLiteral:
+		return n.Value, true
+	case *ast.IntLiteral:
+		return n.Value, true
+	case *ast.FloatLiteral:
+		return n.Value, true
+	case *ast.BoolLiteral:
+		return fmt.Sprint(n.Value), true
+	case *ast.ListLiteral:
+		elems := make([]string, len(n.Elements))
+		for i, e := range n.Elements {
+			text, ok := literalText(e)
+			if !ok {
+				return "", false
+			}
+			elems[i] = text
+		}
+		return strings.Join(elems, ","), true
+	}
+	return "", false
+}
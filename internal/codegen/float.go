@@ -0,0 +1,112 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tasnimzotder/langz/internal/ast"
+)
+
+// isFloatExpr reports whether node produces a float. An arithmetic
+// expression is a float as soon as any operand is, and is then evaluated
+// in floating point as a whole.
+func (g *Generator) isFloatExpr(node ast.Node) bool {
+	switch n := node.(type) {
+	case *ast.FloatLiteral:
+		return true
+	case *ast.Identifier:
+		return g.floats[n.Name]
+	case *ast.BinaryExpr:
+		return isArithmeticOp(n.Op) && (g.isFloatExpr(n.Left) || g.isFloatExpr(n.Right))
+	case *ast.FuncCall:
+		if n.Name == "round" {
+			return len(n.Args) > 1
+		}
+		fn, ok := g.funcs[n.Name]
+		return ok && fn.ReturnType == "float"
+	default:
+		return false
+	}
+}
+
+// isFloatComparison reports whether a comparison needs awk because one
+// side is a float; Bash's [ -lt ] only compares integers.
+func (g *Generator) isFloatComparison(b *ast.BinaryExpr) bool {
+	switch b.Op {
+	case "<", ">", "<=", ">=", "==", "!=":
+		return g.isFloatExpr(b.Left) || g.isFloatExpr(b.Right)
+	}
+	return false
+}
+
+// awkProgram builds a one-line awk BEGIN program. Operands other than
+// number literals are passed with -v so their values are never parsed
+// as awk code.
+type awkProgram struct {
+	g     *Generator
+	vars  []string
+	bound map[string]string
+}
+
+func (g *Generator) newAwkProgram() *awkProgram {
+	return &awkProgram{g: g, bound: make(map[string]string)}
+}
+
+// operand renders node as an awk expression, adding parentheses when a
+// lower-precedence operation is nested inside a higher one.
+func (a *awkProgram) operand(node ast.Node, parentPrec int) string {
+	switch n := node.(type) {
+	case *ast.IntLiteral:
+		return n.Value
+	case *ast.FloatLiteral:
+		return n.Value
+	case *ast.BinaryExpr:
+		if !isArithmeticOp(n.Op) {
+			break
+		}
+		prec := arithPrecedence(n.Op)
+		inner := fmt.Sprintf("%s %s %s", a.operand(n.Left, prec), n.Op, a.operand(n.Right, prec))
+		if parentPrec > prec {
+			return fmt.Sprintf("(%s)", inner)
+		}
+		return inner
+	}
+	return a.bind(quoteExpr(a.g.genExpr(node)))
+}
+
+// bind passes a Bash value into awk, reusing the variable when the same
+// value is bound twice.
+func (a *awkProgram) bind(value string) string {
+	if name, ok := a.bound[value]; ok {
+		return name
+	}
+	name := fmt.Sprintf("v%d", len(a.bound)+1)
+	a.bound[value] = name
+	a.vars = append(a.vars, fmt.Sprintf("-v %s=%s", name, value))
+	return name
+}
+
+// command renders the awk invocation running body inside BEGIN.
+func (a *awkProgram) command(body string) string {
+	parts := append([]string{"awk"}, a.vars...)
+	parts = append(parts, fmt.Sprintf("'BEGIN { %s }'", body))
+	return strings.Join(parts, " ")
+}
+
+// genFloatArith evaluates an arithmetic expression in awk. OFMT keeps ten
+// significant digits so results don't switch to exponent notation early;
+// whole numbers print without a decimal point.
+func (g *Generator) genFloatArith(b *ast.BinaryExpr) string {
+	a := g.newAwkProgram()
+	expr := a.operand(b, 0)
+	return fmt.Sprintf("$(%s)", a.command("OFMT = \"%.10g\"; print "+expr))
+}
+
+// genFloatComparison tests a comparison in awk, whose exit status is the
+// result.
+func (g *Generator) genFloatComparison(b *ast.BinaryExpr) string {
+	a := g.newAwkProgram()
+	left := a.operand(b.Left, 0)
+	right := a.operand(b.Right, 0)
+	return a.command(fmt.Sprintf("exit !(%s %s %s)", left, b.Op, right))
+}
@@ -0,0 +1,69 @@
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/tasnimzotder/langz/internal/ast"
+	"github.com/tasnimzotder/langz/internal/codegen/builtins"
+)
+
+// jobsRuntime tracks spawned jobs so any still running when the script
+// exits are terminated, process group and all; the cleanup sits just above
+// the temp directory's removal at the bottom of the defer stack, so it
+// runs after everything deferred. The registry is a temp file rather than
+// an array so jobs spawned inside $( ) subshells, such as value-returning
+// functions, are registered too. Bash may report already-reaped jobs while
+// the cleanup runs, hence the redirect.
+const jobsRuntime = `_jobs=$(_temp_new jobs "")
+_cleanup_jobs() {
+  local job
+  while read -r job; do
+    kill -- "-$job" 2>/dev/null || true
+  done < "$_jobs"
+}
+_defers+=('_cleanup_jobs 2>/dev/null')`
+
+// genSpawn starts a command in the background. The handle is the job's
+// pid, stored in name (when given) and registered for cleanup. The command
+// runs as a unit, so a list or pipeline is backgrounded whole; job control
+// is on while it starts so the group gets a process group of its own, led
+// by $!, which kill() and the cleanup signal as a whole. stdin is
+// /dev/null, as it is for background jobs without job control.
+func (g *Generator) genSpawn(name string, call *ast.FuncCall) {
+	if len(call.Args) != 1 {
+		g.writeln("# error: spawn() requires 1 argument (command)")
+		return
+	}
+	g.useTemp()
+	g.useRuntime("jobs", jobsRuntime)
+
+	cmd := fmt.Sprintf("{ %s; } < /dev/null", g.genRawValue(call.Args[0]))
+	if log, ok := builtins.FindKwarg(call.KwArgs, "log"); ok {
+		cmd += fmt.Sprintf(" > %s 2>&1", g.genExpr(log))
+	} else if isValueType(g.returnType) {
+		// stdout carries the function's result; a job holding it open
+		// would block the caller's $( ) until the job exits
+		cmd += " >&2"
+	}
+	g.writeln("_spawn_opts=$-; set -m")
+	g.writeln(cmd + " &")
+	handle := `"$!"`
+	if name != "" {
+		g.writeln(name + "=$!")
+		handle = fmt.Sprintf(`"$%s"`, name)
+	}
+	g.writeln(fmt.Sprintf(`echo %s >> "$_jobs"`, handle))
+	g.writeln("[[ $_spawn_opts == *m* ]] || set +m")
+}
+
+// genWaitAssignment stores a job's exit status without tripping set -e.
+// wait has to run in the current shell: a $( ) subshell cannot wait for
+// its parent's children.
+func (g *Generator) genWaitAssignment(name string, call *ast.FuncCall) {
+	if len(call.Args) != 1 {
+		g.writeln("# error: wait() requires 1 argument (job)")
+		return
+	}
+	g.writeln(name + "=0")
+	g.writeln(fmt.Sprintf("wait %s || %s=$?", g.genExpr(call.Args[0]), name))
+}
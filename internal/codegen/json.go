@@ -0,0 +1,467 @@
+package codegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tasnimzotder/langz/internal/ast"
+)
+
+// isJSON reports whether node evaluates to JSON text: a variable holding
+// it, parse_json()/to_json(), or a path into one of those.
+func (g *Generator) isJSON(node ast.Node) bool {
+	switch n := node.(type) {
+	case *ast.Identifier:
+		return g.jsons[n.Name]
+	case *ast.FuncCall:
+		return jsonResults[n.Name]
+	case *ast.BinaryExpr:
+		call, ok := pipeCall(n)
+		return n.Op == "|>" && ok && g.isJSON(call)
+	case *ast.OrExpr:
+		return g.isJSON(n.Expr)
+	case *ast.DotExpr:
+		return g.isJSON(n.Object)
+	case *ast.IndexExpr:
+		return g.isJSON(n.Object)
+	}
+	return false
+}
+
+// jsonResults are the builtins returning JSON text.
+var jsonResults = map[string]bool{
+	"parse_json": true, "to_json": true, "json_set": true, "json_delete": true, "json_merge": true,
+}
+
+// isListExpr reports whether node produces a list.
+func (g *Generator) isListExpr(node ast.Node) bool {
+	switch n := node.(type) {
+	case *ast.ListLiteral:
+		return true
+	case *ast.Identifier:
+		return g.lists[n.Name]
+	case *ast.FuncCall:
+		switch n.Name {
+		case "glob", "keys", "values", "regex_find", "args", "lines", "json_keys", "list_archive":
+			return true
+		}
+		fn, ok := g.funcs[n.Name]
+		return ok && fn.ReturnType == "list"
+	case *ast.MethodCall:
+		return n.Method == "split"
+	case *ast.BinaryExpr:
+		if n.Op == "|>" {
+			call, ok := pipeCall(n)
+			return ok && g.isListExpr(call)
+		}
+	}
+	return false
+}
+
+// jqProgram builds a jq filter. Shell values are passed with --arg or
+// --argjson so they are never parsed as jq code.
+type jqProgram struct {
+	g    *Generator
+	args []string
+}
+
+func (g *Generator) newJqProgram() *jqProgram {
+	g.useRuntime("jq", jqRuntime)
+	return &jqProgram{g: g}
+}
+
+// jqRuntime stops a script that works with JSON before it does anything
+// when jq isn't installed, rather than part way through.
+const jqRuntime = `if ! command -v jq >/dev/null 2>&1; then
+  printf '%s: jq is required for JSON but was not found; install jq and try again\n' "${0##*/}" >&2
+  exit 127
+fi`
+
+// bind passes a Bash value into jq and returns the jq variable holding it.
+func (q *jqProgram) bind(flag, value string) string {
+	name := fmt.Sprintf("v%d", len(q.args)+1)
+	q.args = append(q.args, fmt.Sprintf("%s %s %s", flag, name, value))
+	return "$" + name
+}
+
+// command renders a jq invocation applying filter to input, or to no
+// input (-n) when input is "".
+func (q *jqProgram) command(flags, filter, input string) string {
+	return q.commandWord(flags, singleQuote(filter), input)
+}
+
+// commandWord is command with the filter already quoted for Bash.
+func (q *jqProgram) commandWord(flags, filter, input string) string {
+	if input == "" {
+		flags += "n"
+	}
+	parts := append([]string{"jq", flags}, q.args...)
+	parts = append(parts, filter)
+	if input != "" {
+		parts = append(parts, "<<<", input)
+	}
+	return strings.Join(parts, " ")
+}
+
+// path splits a chain like data.items[0].name into its root value and the
+// jq path applied to it.
+func (q *jqProgram) path(node ast.Node) (ast.Node, string) {
+	switch n := node.(type) {
+	case *ast.DotExpr:
+		root, p := q.path(n.Object)
+		return root, p + "." + n.Field
+	case *ast.IndexExpr:
+		root, p := q.path(n.Object)
+		if p == "" {
+			p = "."
+		}
+		return root, p + "[" + q.key(n.Index) + "]"
+	}
+	return node, ""
+}
+
+// pathFilter renders a filter around a jq path given as a string, such as
+// ".spec.replicas", as one Bash word. A literal path is written into the
+// filter; any other is spliced in when the script runs.
+func (q *jqProgram) pathFilter(path ast.Node, before, after string) string {
+	if s, ok := path.(*ast.StringLiteral); ok && (s.Raw || !interpRegex.MatchString(s.Value)) {
+		return singleQuote(before + s.Value + after)
+	}
+	return singleQuote(before) + quoteExpr(q.g.genExpr(path)) + singleQuote(after)
+}
+
+// filter is path with the identity filter standing in for the root.
+func filter(path string) string {
+	if path == "" {
+		return "."
+	}
+	return path
+}
+
+// key renders an index or key inside [ ]. Runtime values index arrays when
+// they are numeric and objects otherwise.
+func (q *jqProgram) key(idx ast.Node) string {
+	switch n := idx.(type) {
+	case *ast.IntLiteral:
+		return n.Value
+	case *ast.StringLiteral:
+		if isPlainKey(n) {
+			return strconv.Quote(n.Value)
+		}
+	}
+	v := q.bind("--arg", quoteExpr(q.g.genExpr(idx)))
+	return fmt.Sprintf("%s | tonumber? // %s", v, v)
+}
+
+// isPlainKey reports whether a string key can be written into the filter
+// as a jq string literal.
+func isPlainKey(s *ast.StringLiteral) bool {
+	return !strings.ContainsAny(s.Value, "'\"\\{}$") && strconv.Quote(s.Value) == `"`+s.Value+`"`
+}
+
+// value renders node as a jq expression producing its JSON value.
+// Literals and collections become JSON of the same shape; other values
+// are passed in as strings.
+func (q *jqProgram) value(node ast.Node) string {
+	switch n := node.(type) {
+	case *ast.IntLiteral:
+		return n.Value
+	case *ast.FloatLiteral:
+		return n.Value
+	case *ast.BoolLiteral:
+		return strconv.FormatBool(n.Value)
+	case *ast.ListLiteral:
+		elems := make([]string, len(n.Elements))
+		for i, e := range n.Elements {
+			elems[i] = q.value(e)
+		}
+		return "[" + strings.Join(elems, ", ") + "]"
+	case *ast.MapLiteral:
+		fields := make([]string, len(n.Keys))
+		for i, key := range n.Keys {
+			k := &ast.StringLiteral{Value: key}
+			name := strconv.Quote(key)
+			if !isPlainKey(k) {
+				name = "(" + q.bind("--arg", fmt.Sprintf(`"%s"`, bashEscape(key))) + ")"
+			}
+			fields[i] = fmt.Sprintf("%s: %s", name, q.value(n.Values[i]))
+		}
+		return "{" + strings.Join(fields, ", ") + "}"
+	case *ast.Identifier:
+		switch {
+		case q.g.jsons[n.Name]:
+			// Scalars read out of JSON are stored raw, so fall back to a string
+			v := q.bind("--arg", q.g.genExpr(n))
+			return fmt.Sprintf("(try (%s | fromjson) catch %s)", v, v)
+		case q.g.maps[n.Name], q.g.lists[n.Name], q.g.floats[n.Name]:
+			return q.bind("--argjson", quoteExpr(q.g.genJSONText(n)))
+		}
+	case *ast.BinaryExpr:
+		if isArithmeticOp(n.Op) {
+			return q.bind("--argjson", quoteExpr(q.g.genExpr(n)))
+		}
+	case *ast.FuncCall:
+		switch {
+		case q.g.isJSON(n):
+			return q.bind("--argjson", quoteExpr(q.g.genJSONText(n)))
+		case numericBuiltins[n.Name]:
+			return q.bind("--argjson", quoteExpr(q.g.genExpr(n)))
+		}
+	case *ast.DotExpr, *ast.IndexExpr:
+		if q.g.isJSON(n) {
+			return q.bind("--argjson", quoteExpr(q.g.genJSONText(n)))
+		}
+	}
+	return q.bind("--arg", quoteExpr(q.g.genExpr(node)))
+}
+
+// numericBuiltins return numbers, which are inserted into JSON as such.
+var numericBuiltins = map[string]bool{
+	"len": true, "round": true, "floor": true, "ceil": true, "timestamp": true,
+}
+
+// genJSONRead reads a path out of a JSON value. Strings come back raw and
+// objects and arrays as compact JSON.
+func (g *Generator) genJSONRead(node ast.Node) string {
+	q := g.newJqProgram()
+	root, path := q.path(node)
+	return fmt.Sprintf(`"$(%s)"`, q.command("-cr", filter(path), quoteExpr(g.genExpr(root))))
+}
+
+// genJSONLen counts the elements or keys of a JSON value.
+func (g *Generator) genJSONLen(node ast.Node) string {
+	q := g.newJqProgram()
+	root, path := q.path(node)
+	f := "length"
+	if path != "" {
+		f = path + " | length"
+	}
+	return fmt.Sprintf("$(%s)", q.command("-c", f, quoteExpr(g.genExpr(root))))
+}
+
+// genJSONText renders any value as JSON text, as to_json() does.
+func (g *Generator) genJSONText(node ast.Node) string {
+	switch n := node.(type) {
+	case *ast.FuncCall:
+		if jsonResults[n.Name] {
+			return g.genExpr(n)
+		}
+	case *ast.DotExpr, *ast.IndexExpr:
+		if g.isJSON(n) {
+			q := g.newJqProgram()
+			root, path := q.path(n)
+			return fmt.Sprintf("$(%s)", q.command("-c", filter(path), quoteExpr(g.genExpr(root))))
+		}
+	case *ast.Identifier:
+		switch {
+		case g.maps[n.Name]:
+			g.useRuntime("jq", jqRuntime)
+			f := "$ARGS.named"
+			if cond := g.typedCondition(n.Name); cond != "" {
+				f += fmt.Sprintf(" | with_entries(if %s then .value = (%s) else . end)", cond, typedValue)
+			}
+			return fmt.Sprintf(`$(_a=(); for k in "${!%s[@]}"; do _a+=(--arg "$k" "${%s[$k]}"); done; jq -cn %s "${_a[@]}")`, n.Name, n.Name, singleQuote(f))
+		case g.lists[n.Name]:
+			g.useRuntime("jq", jqRuntime)
+			f := "$ARGS.positional"
+			if cond := g.typedCondition(n.Name); cond != "" {
+				f = fmt.Sprintf("[%s | to_entries[] | if %s then %s else .value end]", f, cond, typedValue)
+			}
+			return fmt.Sprintf(`$(jq -cn %s --args "${%s[@]}")`, singleQuote(f), n.Name)
+		case g.floats[n.Name]:
+			return fmt.Sprintf(`"$%s"`, n.Name)
+		}
+	}
+	q := g.newJqProgram()
+	v := q.value(node)
+	return fmt.Sprintf("$(%s)", q.command("-c", v, ""))
+}
+
+// typedValue turns the string of an entry (.key, .value) back into the
+// number or bool it was assigned as. One changed since to a value that
+// isn't JSON stays a string.
+const typedValue = `.value as $s | try ($s | fromjson) catch $s`
+
+// isTypedScalar reports whether jqProgram.value passes node into JSON as a
+// number or bool rather than a string.
+func (g *Generator) isTypedScalar(node ast.Node) bool {
+	switch n := node.(type) {
+	case *ast.IntLiteral, *ast.FloatLiteral, *ast.BoolLiteral:
+		return true
+	case *ast.Identifier:
+		return g.floats[n.Name] && !g.jsons[n.Name]
+	case *ast.BinaryExpr:
+		return isArithmeticOp(n.Op)
+	case *ast.FuncCall:
+		return numericBuiltins[n.Name]
+	}
+	return false
+}
+
+// trackTyped records which entries of a map or list literal assigned to
+// name are numbers or bools, forgetting those of any earlier value.
+func (g *Generator) trackTyped(name string, value ast.Node) {
+	delete(g.typed, name)
+	switch v := value.(type) {
+	case *ast.MapLiteral:
+		for i, key := range v.Keys {
+			g.trackTypedEntry(name, key, v.Values[i])
+		}
+	case *ast.ListLiteral:
+		for i, e := range v.Elements {
+			g.trackTypedEntry(name, strconv.Itoa(i), e)
+		}
+	}
+}
+
+// trackTypedEntry records whether one entry of name now holds a number or
+// bool.
+func (g *Generator) trackTypedEntry(name, key string, value ast.Node) {
+	if !g.isTypedScalar(value) {
+		delete(g.typed[name], key)
+		return
+	}
+	if g.typed[name] == nil {
+		g.typed[name] = make(map[string]bool)
+	}
+	g.typed[name][key] = true
+}
+
+// typedCondition renders a jq condition on .key matching the typed
+// entries of a map or list variable, or "" when it has none.
+func (g *Generator) typedCondition(name string) string {
+	var keys []string
+	for key := range g.typed[name] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	conds := make([]string, len(keys))
+	for i, key := range keys {
+		if g.lists[name] {
+			conds[i] = ".key == " + key
+			continue
+		}
+		lit, _ := json.Marshal(key)
+		conds[i] = ".key == " + string(lit)
+	}
+	return strings.Join(conds, " or ")
+}
+
+// genPathAssignment updates a JSON variable in place by rewriting it with
+// a jq assignment.
+func (g *Generator) genPathAssignment(target, value ast.Node) {
+	q := g.newJqProgram()
+	root, path := q.path(target)
+	id, ok := root.(*ast.Identifier)
+	if !ok || !g.jsons[id.Name] {
+		g.writeln("# error: fields can only be assigned on a JSON variable (see parse_json)")
+		return
+	}
+	f := fmt.Sprintf("%s = %s", filter(path), q.value(value))
+	g.writeln(fmt.Sprintf("%s=$(%s)", id.Name, q.command("-c", f, quoteExpr(g.genExpr(root)))))
+}
+
+// genJSONFor iterates the elements of a JSON array (or the values of an
+// object), each a raw string or compact JSON. With two loop variables it
+// yields each key or index with its value. jq ends every item with a NUL,
+// which no Bash string holds, so strings with newlines stay one item, and
+// its status is checked after the loop like a pipeline's.
+func (g *Generator) genJSONFor(f *ast.ForStmt) {
+	q := g.newJqProgram()
+	root, path := q.path(f.Collection)
+	input := quoteExpr(g.genExpr(root))
+	const item = `(if type == "string" then . else tojson end), "\u0000"`
+
+	if f.ValueVar == "" {
+		g.jsons[f.Var] = true
+		source := q.command("-j", filter(path)+"[] | "+item, input)
+		g.genStreamFor(source, true, "''", []string{f.Var}, f.Body)
+		return
+	}
+
+	g.jsons[f.ValueVar] = true
+	source := q.command("-j", filter(path)+" | to_entries[] | (.key, .value) | "+item, input)
+	g.genStreamFor(source, true, "''", []string{f.Var, f.ValueVar}, f.Body)
+}
+
+// isJSONBuiltin reports whether name is one of the json_* builtins that
+// work on JSON text with jq.
+func isJSONBuiltin(name string) bool {
+	switch name {
+	case "json_set", "json_delete", "json_keys", "json_len", "json_merge", "json_valid":
+		return true
+	}
+	return false
+}
+
+// jsonParams names the arguments of each json_* builtin, for errors.
+var jsonParams = map[string][]string{
+	"json_set":    {"doc", "path", "value"},
+	"json_delete": {"doc", "path"},
+	"json_keys":   {"doc"},
+	"json_len":    {"doc"},
+	"json_merge":  {"doc", "other"},
+	"json_valid":  {"text"},
+}
+
+// describeParams renders params as "2 arguments (doc, path)".
+func describeParams(params []string) string {
+	noun := "arguments"
+	if len(params) == 1 {
+		noun = "argument"
+	}
+	return fmt.Sprintf("%d %s (%s)", len(params), noun, strings.Join(params, ", "))
+}
+
+// genJSONCall generates the json_* builtins. The document is always the
+// first argument, so they read naturally at the end of a pipe:
+// _body |> json_set(".replicas", 3).
+func (g *Generator) genJSONCall(call *ast.FuncCall) string {
+	params := jsonParams[call.Name]
+	if len(call.Args) != len(params) {
+		return fmt.Sprintf("# error: %s() requires %s", call.Name, describeParams(params))
+	}
+	q := g.newJqProgram()
+	doc := quoteExpr(g.genExpr(call.Args[0]))
+	switch call.Name {
+	case "json_set":
+		v := q.value(call.Args[2])
+		return fmt.Sprintf("$(%s)", q.commandWord("-c", q.pathFilter(call.Args[1], "(", ") = "+v), doc))
+	case "json_delete":
+		return fmt.Sprintf("$(%s)", q.commandWord("-c", q.pathFilter(call.Args[1], "del(", ")"), doc))
+	case "json_keys":
+		return fmt.Sprintf("$(%s)", q.command("-r", "keys_unsorted[]", doc))
+	case "json_len":
+		return fmt.Sprintf("$(%s)", q.command("-c", "length", doc))
+	case "json_merge":
+		other := q.bind("--argjson", quoteExpr(g.genExpr(call.Args[1])))
+		return fmt.Sprintf("$(%s)", q.command("-c", ". * "+other, doc))
+	default: // json_valid
+		return fmt.Sprintf(`"$(%s && echo true || echo false)"`, g.genJSONValid(call))
+	}
+}
+
+// genJSONValid tests that text holds exactly one JSON value.
+func (g *Generator) genJSONValid(call *ast.FuncCall) string {
+	if len(call.Args) != 1 {
+		return "# error: json_valid() requires " + describeParams(jsonParams["json_valid"])
+	}
+	q := g.newJqProgram()
+	return q.command("-en", "[inputs] | length == 1", quoteExpr(g.genExpr(call.Args[0]))) + " >/dev/null 2>&1"
+}
+
+// jsonKeysSource returns the jq command listing the keys for
+// json_keys(doc), or doc |> json_keys(), one per line.
+func (g *Generator) jsonKeysSource(node ast.Node) (string, bool) {
+	call, ok := node.(*ast.FuncCall)
+	if b, isPipe := node.(*ast.BinaryExpr); isPipe && b.Op == "|>" {
+		call, ok = pipeCall(b)
+	}
+	if !ok || call.Name != "json_keys" || len(call.Args) != 1 || g.funcs["json_keys"] != nil {
+		return "", false
+	}
+	return stripSubshell(g.genJSONCall(call)), true
+}
@@ -0,0 +1,97 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tasnimzotder/langz/internal/ast"
+)
+
+// logRuntime writes one log line to stderr: "<UTC time> <LEVEL> <msg>"
+// followed by key=value fields, or a JSON object when LOG_FORMAT=json.
+// LOG_LEVEL sets the lowest level written; the level is colored when
+// stderr is a terminal and NO_COLOR is unset. _log_quote escapes a value
+// as a JSON string, control characters included, which also keeps quoted
+// key=value fields on one line.
+const logRuntime = `_log_quote() {
+  local -n _log_ref=$1
+  local s=$2 c code
+  s=${s//\\/\\\\}
+  s=${s//\"/\\\"}
+  s=${s//$'\n'/\\n}
+  s=${s//$'\r'/\\r}
+  s=${s//$'\t'/\\t}
+  while [[ $s =~ [[:cntrl:]] ]]; do
+    c=${BASH_REMATCH[0]}
+    printf -v code '\\u%04x' "'$c"
+    s=${s//"$c"/"$code"}
+  done
+  _log_ref="\"$s\""
+}
+_log() {
+  local level=$1 msg=$2 rank=20 min=20 ts label line value
+  shift 2
+  case "$level" in debug) rank=10 ;; warn) rank=30 ;; error) rank=40 ;; esac
+  case "${LOG_LEVEL:-info}" in
+    debug | DEBUG) min=10 ;;
+    warn | WARN | warning | WARNING) min=30 ;;
+    error | ERROR) min=40 ;;
+  esac
+  [ "$rank" -ge "$min" ] || return 0
+  TZ=UTC0 printf -v ts '%(%Y-%m-%dT%H:%M:%SZ)T' -1
+  if [ "${LOG_FORMAT:-}" = json ]; then
+    _log_quote value "$msg"
+    line="{\"time\":\"$ts\",\"level\":\"$level\",\"msg\":$value"
+    while [ $# -ge 2 ]; do
+      _log_quote value "$2"
+      line+=",\"$1\":$value"
+      shift 2
+    done
+    printf '%s}\n' "$line" >&2
+    return 0
+  fi
+  printf -v label '%-5s' "${level^^}"
+  if [ -t 2 ] && [ -z "${NO_COLOR:-}" ]; then
+    case "$level" in
+      debug) label=$'\e[90m'$label$'\e[0m' ;;
+      info) label=$'\e[32m'$label$'\e[0m' ;;
+      warn) label=$'\e[33m'$label$'\e[0m' ;;
+      error) label=$'\e[31m'$label$'\e[0m' ;;
+    esac
+  fi
+  line="$ts $label $msg"
+  while [ $# -ge 2 ]; do
+    value=$2
+    if [ -z "$value" ] || [[ $value == *[[:space:]\"=]* ]]; then
+      _log_quote value "$value"
+    fi
+    line+=" $1=$value"
+    shift 2
+  done
+  printf '%s\n' "$line" >&2
+}`
+
+// logLevels are the methods of the log builtin.
+var logLevels = map[string]bool{"debug": true, "info": true, "warn": true, "error": true}
+
+// isLogCall reports whether m is log.<level>(...), the logging builtin.
+// An as import named log has been resolved to calls by now.
+func isLogCall(m *ast.MethodCall) bool {
+	id, ok := m.Object.(*ast.Identifier)
+	return ok && id.Name == "log" && logLevels[m.Method]
+}
+
+// genLog emits log.info(msg, key: value...) as a call to _log, keyword
+// arguments becoming the line's fields in order.
+func (g *Generator) genLog(m *ast.MethodCall) {
+	if len(m.Args) != 1 {
+		g.writeln(fmt.Sprintf("# error: log.%s() requires 1 argument (message)", m.Method))
+		return
+	}
+	g.useRuntime("log", logRuntime)
+	words := []string{"_log", m.Method, quoteExpr(g.genExpr(m.Args[0]))}
+	for _, kw := range m.KwArgs {
+		words = append(words, kw.Key, quoteExpr(g.genExpr(kw.Value)))
+	}
+	g.writeln(strings.Join(words, " "))
+}
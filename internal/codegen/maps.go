@@ -0,0 +1,99 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tasnimzotder/langz/internal/ast"
+)
+
+// isMap reports whether node is a variable known to hold a map.
+func (g *Generator) isMap(node ast.Node) bool {
+	id, ok := node.(*ast.Identifier)
+	return ok && g.maps[id.Name]
+}
+
+// genMapAssignment declares name as an associative array. Inside a
+// function -g keeps it global, like any other assignment.
+func (g *Generator) genMapAssignment(name string, m *ast.MapLiteral) {
+	g.maps[name] = true
+	entries := make([]string, len(m.Keys))
+	for i, key := range m.Keys {
+		entries[i] = fmt.Sprintf(`["%s"]=%s`, bashEscape(key), g.genExpr(m.Values[i]))
+	}
+	flag := "-A"
+	if g.mapParams != nil {
+		flag = "-gA"
+	}
+	g.writeln(fmt.Sprintf("declare %s %s=(%s)", flag, name, strings.Join(entries, " ")))
+}
+
+// genMapParam binds a map parameter to the caller's variable by name.
+// When the names match, dynamic scoping already reaches the caller's
+// variable and a nameref would be circular.
+func (g *Generator) genMapParam(name string, pos int) {
+	g.maps[name] = true
+	g.mapParams[name] = pos
+	g.writeln(fmt.Sprintf(`[[ "$%d" == %s ]] || local -n %s="$%d"`, pos, name, name, pos))
+}
+
+// genMapArg passes a map to a user function by variable name. A map
+// parameter forwards the name it was given, so chains of calls always
+// refer to the original variable.
+func (g *Generator) genMapArg(arg ast.Node) string {
+	id, ok := arg.(*ast.Identifier)
+	if !ok {
+		return "# error: maps are passed by name; assign the map to a variable first"
+	}
+	if pos, ok := g.mapParams[id.Name]; ok {
+		return fmt.Sprintf(`"$%d"`, pos)
+	}
+	return id.Name
+}
+
+// genListParam binds a list parameter to the caller's array by name, as
+// genMapParam does for maps, so the function sees every element.
+func (g *Generator) genListParam(name string, pos int) {
+	g.lists[name] = true
+	g.mapParams[name] = pos
+	g.writeln(fmt.Sprintf(`[[ "$%d" == %s ]] || local -n %s="$%d"`, pos, name, name, pos))
+}
+
+// genListArg passes a list to a user function by variable name, as
+// genMapArg does for maps.
+func (g *Generator) genListArg(arg ast.Node) string {
+	if _, ok := arg.(*ast.Identifier); !ok {
+		return "# error: lists are passed by name; assign the list to a variable first"
+	}
+	return g.genMapArg(arg)
+}
+
+// genMapOrPairs passes a map to a runtime helper: a map variable by name,
+// or "" followed by a literal's entries as key/value pairs.
+func (g *Generator) genMapOrPairs(node ast.Node, value func(ast.Node) string) string {
+	m, ok := node.(*ast.MapLiteral)
+	if !ok {
+		return g.genMapArg(node)
+	}
+	parts := []string{`""`}
+	for i, key := range m.Keys {
+		parts = append(parts, fmt.Sprintf(`"%s"`, bashEscape(key)), value(m.Values[i]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// genPairFor generates for key, value in collection. Maps iterate over
+// their keys and lists over their indices.
+func (g *Generator) genPairFor(f *ast.ForStmt) {
+	id, ok := f.Collection.(*ast.Identifier)
+	if !ok {
+		g.writeln(fmt.Sprintf("# error: for %s, %s needs a map or list variable", f.Var, f.ValueVar))
+		return
+	}
+	g.writeln(fmt.Sprintf(`for %s in "${!%s[@]}"; do`, f.Var, id.Name))
+	g.indent++
+	g.writeln(fmt.Sprintf(`%s="${%s[$%s]}"`, f.ValueVar, id.Name, f.Var))
+	g.indent--
+	g.genBlock(f.Body)
+	g.writeln("done")
+}
@@ -0,0 +1,226 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tasnimzotder/langz/internal/ast"
+)
+
+// isCommand reports whether node runs an external command: cmd(), run()
+// or a $ literal.
+func (g *Generator) isCommand(node ast.Node) bool {
+	call, ok := node.(*ast.FuncCall)
+	return ok && (call.Name == "cmd" || call.Name == "run") && g.funcs[call.Name] == nil
+}
+
+// pipeStages flattens a |> chain into its source followed by its stages.
+func pipeStages(node ast.Node) []ast.Node {
+	b, ok := node.(*ast.BinaryExpr)
+	if !ok || b.Op != "|>" {
+		return []ast.Node{node}
+	}
+	return append(pipeStages(b.Left), b.Right)
+}
+
+// pipeChain rebuilds a |> chain from its stages.
+func pipeChain(stages []ast.Node) ast.Node {
+	node := stages[0]
+	for _, stage := range stages[1:] {
+		node = &ast.BinaryExpr{Span: stage.NodeSpan(), Left: node, Op: "|>", Right: stage}
+	}
+	return node
+}
+
+// lastCommand returns the index of the last command stage, or -1.
+func (g *Generator) lastCommand(stages []ast.Node) int {
+	for i := len(stages) - 1; i >= 0; i-- {
+		if g.isCommand(stages[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// pipeCall turns a |> f(x) into the call f(a, x).
+func pipeCall(expr *ast.BinaryExpr) (*ast.FuncCall, bool) {
+	switch right := expr.Right.(type) {
+	case *ast.Identifier:
+		// data |> upper → upper(data)
+		return &ast.FuncCall{Span: right.Span, Name: right.Name, Args: []ast.Node{expr.Left}}, true
+	case *ast.FuncCall:
+		// data |> json_get(".name") → json_get(data, ".name")
+		args := make([]ast.Node, 0, 1+len(right.Args))
+		args = append(args, expr.Left)
+		args = append(args, right.Args...)
+		return &ast.FuncCall{Span: right.Span, Name: right.Name, Args: args, KwArgs: right.KwArgs}, true
+	}
+	return nil, false
+}
+
+// genShellPipeline renders stages ending in a command as a shell
+// pipeline. The commands at the end stream into each other through |;
+// whatever comes before them is written to the first one's stdin, except
+// that read(path) hands over the file itself rather than its contents.
+func (g *Generator) genShellPipeline(stages []ast.Node) string {
+	first := len(stages)
+	for first > 0 && g.isCommand(stages[first-1]) {
+		first--
+	}
+	cmds := make([]string, 0, len(stages)-first)
+	for _, stage := range stages[first:] {
+		cmds = append(cmds, g.genRunWords(stage.(*ast.FuncCall)))
+	}
+	if first == 0 {
+		return strings.Join(cmds, " | ")
+	}
+	input := pipeChain(stages[:first])
+	if call, ok := input.(*ast.FuncCall); ok && call.Name == "read" && len(call.Args) == 1 && g.funcs["read"] == nil {
+		cmds[0] += " < " + quoteExpr(g.genExpr(call.Args[0]))
+		return strings.Join(cmds, " | ")
+	}
+	var value string
+	if g.isListExpr(input) {
+		// One line per element
+		value = g.genForCollection(input)
+	} else {
+		value = quoteExpr(g.genExpr(input))
+	}
+	return fmt.Sprintf("printf '%%s\\n' %s | %s", value, strings.Join(cmds, " | "))
+}
+
+// genPipeValue renders a chain containing commands as a value. Builtin
+// stages after the last command take the pipeline's output as their input.
+func (g *Generator) genPipeValue(stages []ast.Node, last int) string {
+	output := fmt.Sprintf("$(%s)", g.genShellPipeline(stages[:last+1]))
+	if last == len(stages)-1 {
+		return output
+	}
+	value := &ast.Identifier{Span: stages[last].NodeSpan(), Name: "_pipe"}
+	g.shellValues[value] = quoteExpr(output)
+	return g.genExpr(pipeChain(append([]ast.Node{value}, stages[last+1:]...)))
+}
+
+// genPipeStmt generates a |> chain used as a statement. A chain ending in a
+// command runs as a pipeline with its output going to stdout; otherwise
+// the last stage is called like any other statement.
+func (g *Generator) genPipeStmt(expr *ast.BinaryExpr) {
+	stages := pipeStages(expr)
+	if g.isCommand(stages[len(stages)-1]) {
+		g.writeln(g.genShellPipeline(stages))
+		return
+	}
+	call, ok := pipeCall(expr)
+	if !ok {
+		g.writeln("# error: pipe target must be a function")
+		return
+	}
+	g.genFuncCallStmt(call)
+}
+
+// genPipeCondition tests a |> chain. A chain ending in a command succeeds
+// when every command in it does.
+func (g *Generator) genPipeCondition(expr *ast.BinaryExpr) string {
+	stages := pipeStages(expr)
+	if g.isCommand(stages[len(stages)-1]) {
+		return g.genShellPipeline(stages)
+	}
+	call, ok := pipeCall(expr)
+	if !ok {
+		return "# error: pipe target must be a function"
+	}
+	return g.genCondition(call)
+}
+
+// linesSource returns the command whose output lines(node) splits, and
+// whether it is a pipeline that can fail. node is either lines(value) or
+// value |> lines().
+func (g *Generator) linesSource(node ast.Node) (string, bool, bool) {
+	var stages []ast.Node
+	switch n := node.(type) {
+	case *ast.FuncCall:
+		if n.Name != "lines" || len(n.Args) != 1 || g.funcs["lines"] != nil {
+			return "", false, false
+		}
+		stages = append(pipeStages(n.Args[0]), nil)
+	case *ast.BinaryExpr:
+		stages = pipeStages(n)
+		id, ok := stages[len(stages)-1].(*ast.Identifier)
+		call, isCall := stages[len(stages)-1].(*ast.FuncCall)
+		if !(ok && id.Name == "lines") && !(isCall && call.Name == "lines" && len(call.Args) == 0) || g.funcs["lines"] != nil {
+			return "", false, false
+		}
+	default:
+		return "", false, false
+	}
+	input := stages[:len(stages)-1]
+	if g.isCommand(input[len(input)-1]) {
+		return g.genShellPipeline(input), true, true
+	}
+	return fmt.Sprintf("printf '%%s' %s", quoteExpr(g.genExpr(pipeChain(input)))), false, true
+}
+
+// genLinesAssignment splits the output of lines() into a list, one
+// element per line. A pipeline streams straight into mapfile, and waiting
+// for it afterwards keeps its exit status.
+func (g *Generator) genLinesAssignment(name, source string, pipeline bool) {
+	g.lists[name] = true
+	g.writeln(fmt.Sprintf("mapfile -t %s < <(%s)", name, source))
+	if pipeline {
+		g.writeln("wait $!")
+	}
+}
+
+// linesRuntime reads the loops over lines() and other streams.
+// _lines_next name fd eof delim reads the next item, ended by delim, into
+// name, keeping a last item that has no delimiter after it; at the end of
+// the stream it sets the variable named by eof to true and fails.
+// _lines_wait pid eof waits for the stream's producer and fails with its
+// status, except that a producer killed by SIGPIPE (141) is fine when the
+// loop was left early, since closing the stream is what killed it.
+const linesRuntime = `_lines_next() {
+  IFS= read -r -d "$4" "$1" <&"$2" && return 0
+  [ -z "${!1}" ] || return 0
+  printf -v "$3" true
+  return 1
+}
+_lines_wait() {
+  local status=0
+  wait "$1" || status=$?
+  [ "$status" -ne 141 ] || [ "$2" = true ] || return 0
+  return "$status"
+}`
+
+// genLinesFor loops over the output of lines() as it is produced.
+func (g *Generator) genLinesFor(f *ast.ForStmt, source string, pipeline bool) {
+	g.genStreamFor(source, pipeline, `$'\n'`, []string{f.Var}, f.Body)
+}
+
+// genStreamFor loops over the items source writes, ended by delim, reading
+// one into each of vars per iteration. The stream is read through its own
+// file descriptor so the loop body keeps stdin. A pipeline's status is
+// checked once the loop is done, so a failing producer still stops the
+// script, but one stopped by a break is not an error.
+func (g *Generator) genStreamFor(source string, pipeline bool, delim string, vars []string, body []ast.Node) {
+	g.useRuntime("lines", linesRuntime)
+	g.pipes++
+	fd := fmt.Sprintf("_lines_fd%d", g.pipes)
+	pid := fmt.Sprintf("_lines_pid%d", g.pipes)
+	eof := fmt.Sprintf("_lines_eof%d", g.pipes)
+	g.writeln(fmt.Sprintf("exec {%s}< <(%s)", fd, source))
+	if pipeline {
+		g.writeln(fmt.Sprintf("%s=$!", pid))
+	}
+	g.writeln(fmt.Sprintf("%s=false", eof))
+	reads := make([]string, len(vars))
+	for i, v := range vars {
+		reads[i] = fmt.Sprintf(`_lines_next %s "$%s" %s %s`, v, fd, eof, delim)
+	}
+	g.writeln(fmt.Sprintf("while %s; do", strings.Join(reads, " && ")))
+	g.genBlock(body)
+	g.writeln("done")
+	g.writeln(fmt.Sprintf("exec {%s}<&-", fd))
+	if pipeline {
+		g.writeln(fmt.Sprintf(`_lines_wait "$%s" "$%s"`, pid, eof))
+	}
+}
@@ -0,0 +1,172 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tasnimzotder/langz/internal/ast"
+	"github.com/tasnimzotder/langz/internal/codegen/builtins"
+)
+
+// promptRuntime asks questions on the terminal. When stdin isn't a
+// terminal, or the script was run with --yes or LANGZ_YES=1, nothing is
+// read: prompt(), choose() and secret() take their default and fail
+// without one, and confirm() answers yes under --yes and no otherwise.
+// --yes is taken out of the arguments before flags and args() see them.
+const promptRuntime = `_prompt_yes=false
+case "${LANGZ_YES:-}" in 1 | true | yes) _prompt_yes=true ;; esac
+_prompt_args=()
+while [ $# -gt 0 ]; do
+  case "$1" in
+    --yes) _prompt_yes=true ;;
+    --) _prompt_args+=("$@"); break ;;
+    *) _prompt_args+=("$1") ;;
+  esac
+  shift
+done
+set -- "${_prompt_args[@]}"
+_prompt_tty() {
+  [ "$_prompt_yes" = false ] && [ -t 0 ]
+}
+_prompt_fail() {
+  printf '%s: no answer for "%s": not running interactively and no default\n' "${0##*/}" "$1" >&2
+  exit 1
+}
+_prompt() {
+  local answer label=$1
+  if ! _prompt_tty; then
+    [ "$2" = 1 ] || _prompt_fail "$1"
+    printf '%s' "$3"
+    return 0
+  fi
+  [ -z "$3" ] || label+=" [$3]"
+  read -rp "$label " answer || true
+  printf '%s' "${answer:-$3}"
+}
+_secret() {
+  local answer
+  if ! _prompt_tty; then
+    [ "$2" = 1 ] || _prompt_fail "$1"
+    printf '%s' "$3"
+    return 0
+  fi
+  read -rsp "$1 " answer || true
+  printf '\n' >&2
+  printf '%s' "$answer"
+}
+_confirm() {
+  local answer hint='[y/N]'
+  [ "$2" = false ] || hint='[Y/n]'
+  if [ "$_prompt_yes" = true ]; then
+    printf '%s %s yes (--yes)\n' "$1" "$hint" >&2
+    return 0
+  fi
+  if ! [ -t 0 ]; then
+    printf '%s %s no (not a terminal; pass --yes to confirm)\n' "$1" "$hint" >&2
+    return 1
+  fi
+  while read -rp "$1 $hint " answer; do
+    case "$answer" in
+      [yY] | [yY][eE][sS]) return 0 ;;
+      [nN] | [nN][oO]) return 1 ;;
+      "") [ "$2" = true ]; return ;;
+    esac
+  done
+  return 1
+}
+_choose() {
+  local msg=$1 has=$2 def=$3 answer opt i=1
+  shift 3
+  if ! _prompt_tty; then
+    [ "$has" = 1 ] || _prompt_fail "$msg"
+    for opt in "$@"; do
+      [ "$opt" != "$def" ] || { printf '%s' "$def"; return 0; }
+    done
+    printf '%s: default "%s" for "%s" is not one of the options\n' "${0##*/}" "$def" "$msg" >&2
+    exit 1
+  fi
+  printf '%s\n' "$msg" >&2
+  for opt in "$@"; do
+    printf '  %d) %s\n' "$i" "$opt" >&2
+    i=$((i + 1))
+  done
+  while true; do
+    if ! read -rp "Choose 1-$#${def:+ [$def]}: " answer; then
+      [ "$has" = 1 ] || _prompt_fail "$msg"
+      answer=$def
+    fi
+    if [ -z "$answer" ] && [ "$has" = 1 ]; then
+      answer=$def
+    fi
+    if [[ $answer =~ ^[0-9]+$ ]] && [ "$answer" -ge 1 ] && [ "$answer" -le $# ]; then
+      printf '%s' "${!answer}"
+      return 0
+    fi
+    for opt in "$@"; do
+      [ "$opt" != "$answer" ] || { printf '%s' "$opt"; return 0; }
+    done
+    printf 'Please enter a number from 1 to %d.\n' $# >&2
+  done
+}`
+
+// isPrompt reports whether name is one of the interactive builtins.
+func isPrompt(name string) bool {
+	switch name {
+	case "prompt", "confirm", "choose", "secret":
+		return true
+	}
+	return false
+}
+
+// genPrompt renders a prompt builtin as a value. confirm() gives true or
+// false; as a condition genPromptCondition tests it directly.
+func (g *Generator) genPrompt(call *ast.FuncCall) string {
+	g.useRuntime("prompt", promptRuntime)
+	if call.Name == "confirm" {
+		return fmt.Sprintf(`"$(%s && echo true || echo false)"`, g.genPromptCondition(call))
+	}
+	want := 1
+	if call.Name == "choose" {
+		want = 2
+	}
+	if len(call.Args) != want {
+		return fmt.Sprintf("# error: %s() requires %d argument(s)", call.Name, want)
+	}
+	words := []string{"_" + call.Name, quoteExpr(g.genExpr(call.Args[0]))}
+	if def, ok := builtins.FindKwarg(call.KwArgs, "default"); ok {
+		words = append(words, "1", quoteExpr(g.genExpr(def)))
+	} else {
+		words = append(words, "0", `""`)
+	}
+	if call.Name == "choose" {
+		words = append(words, g.genListWords(call.Args[1]))
+	}
+	return fmt.Sprintf(`"$(%s)"`, strings.Join(words, " "))
+}
+
+// genPromptCondition renders confirm(msg, default: false) as a command
+// that succeeds when the answer is yes.
+func (g *Generator) genPromptCondition(call *ast.FuncCall) string {
+	g.useRuntime("prompt", promptRuntime)
+	if len(call.Args) != 1 {
+		return "# error: confirm() requires 1 argument"
+	}
+	def := "false"
+	if node, ok := builtins.FindKwarg(call.KwArgs, "default"); ok {
+		def = quoteExpr(g.genExpr(node))
+	}
+	return fmt.Sprintf("_confirm %s %s", quoteExpr(g.genExpr(call.Args[0])), def)
+}
+
+// genListWords renders a list as one word per element, such as the
+// options of choose() or the sources of archive().
+func (g *Generator) genListWords(node ast.Node) string {
+	if list, ok := node.(*ast.ListLiteral); ok {
+		words := make([]string, len(list.Elements))
+		for i, e := range list.Elements {
+			words[i] = quoteExpr(g.genExpr(e))
+		}
+		return strings.Join(words, " ")
+	}
+	return g.genForCollection(node)
+}
@@ -0,0 +1,85 @@
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/tasnimzotder/langz/internal/ast"
+)
+
+// genRegexFindAssignment assigns the capture groups of a match straight
+// from BASH_REMATCH, so groups containing spaces stay intact. No match
+// gives an empty list.
+func (g *Generator) genRegexFindAssignment(name string, call *ast.FuncCall) {
+	test := g.genFuncCallExpr(&ast.FuncCall{Name: "matches", Args: call.Args})
+	g.writeln(fmt.Sprintf("if %s; then", test))
+	g.indent++
+	g.writeln(fmt.Sprintf(`%s=("${BASH_REMATCH[@]:1}")`, name))
+	g.indent--
+	g.writeln("else")
+	g.indent++
+	g.writeln(fmt.Sprintf("%s=()", name))
+	g.indent--
+	g.writeln("fi")
+}
+
+// regexArmPattern returns the pattern of a matches("re") => match arm.
+func regexArmPattern(pattern ast.Node) (ast.Node, bool) {
+	call, ok := pattern.(*ast.FuncCall)
+	if !ok || call.Name != "matches" || len(call.Args) != 1 {
+		return nil, false
+	}
+	return call.Args[0], true
+}
+
+func hasRegexArm(m *ast.MatchStmt) bool {
+	for _, c := range m.Cases {
+		if _, ok := regexArmPattern(c.Pattern); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// genRegexMatch generates a match with regex arms as an if/elif chain,
+// since case only understands globs. Other arms keep their glob meaning
+// through [[ == ]], and the first matching arm wins as with case.
+func (g *Generator) genRegexMatch(m *ast.MatchStmt) {
+	subject := m.Expr
+	if _, ok := subject.(*ast.Identifier); !ok {
+		// Evaluate the subject once rather than in every arm
+		g.writeln(fmt.Sprintf("_match=%s", quoteExpr(g.genConditionOperand(m.Expr))))
+		subject = &ast.Identifier{Name: "_match"}
+	}
+
+	keyword := "if"
+	for _, c := range m.Cases {
+		if c.Pattern == nil && keyword == "elif" {
+			g.writeln("else")
+			g.genArmBody(c.Body)
+			break
+		}
+		var test string
+		if c.Pattern == nil {
+			test = "true"
+		} else if re, ok := regexArmPattern(c.Pattern); ok {
+			test = g.genFuncCallExpr(&ast.FuncCall{Name: "matches", Args: []ast.Node{subject, re}})
+		} else {
+			test = fmt.Sprintf("[[ %s == %s ]]", g.genExpr(subject), g.genRawValue(c.Pattern))
+		}
+		g.writeln(fmt.Sprintf("%s %s; then", keyword, test))
+		g.genArmBody(c.Body)
+		keyword = "elif"
+	}
+	g.writeln("fi")
+}
+
+// genArmBody generates a match arm body; Bash rejects an empty then/else.
+func (g *Generator) genArmBody(body []ast.Node) {
+	if len(body) == 0 {
+		g.indent++
+		g.writeln("true")
+		g.indent--
+		return
+	}
+	g.genBlock(body)
+}
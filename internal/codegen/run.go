@@ -0,0 +1,70 @@
+package codegen
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/tasnimzotder/langz/internal/ast"
+)
+
+// runRuntime runs a command given as separate words and records how it
+// went in the map named by $1. stderr goes through a temp file, from the
+// same place as tempfile()'s, so it can be kept apart from stdout.
+const runRuntime = `_run_capture() {
+  local -n _run_result=$1
+  local _run_stderr _run_code=0
+  _run_stderr=$(_temp_new run "")
+  _run_result[stdout]=$("${@:2}" 2>"$_run_stderr") || _run_code=$?
+  _run_result[stderr]=$(<"$_run_stderr")
+  rm -f "$_run_stderr"
+  _run_result[code]=$_run_code
+  _run_result[ok]=false
+  (( _run_code != 0 )) || _run_result[ok]=true
+}`
+
+// plainWordRegex matches words that mean the same to Bash unquoted.
+var plainWordRegex = regexp.MustCompile(`^[A-Za-z0-9_./=:@%+,-]+$`)
+
+// genRunWords renders the arguments of run() as the words of a command.
+// Each argument becomes exactly one word, whatever it contains, except
+// that a list variable becomes one word per element. Nothing is parsed by
+// the shell a second time, so values can't inject options or commands.
+func (g *Generator) genRunWords(call *ast.FuncCall) string {
+	words := make([]string, len(call.Args))
+	for i, arg := range call.Args {
+		switch n := arg.(type) {
+		case *ast.StringLiteral:
+			if n.Raw {
+				words[i] = singleQuote(n.Value)
+				continue
+			}
+			if plainWordRegex.MatchString(n.Value) {
+				words[i] = n.Value
+				continue
+			}
+		case *ast.Identifier:
+			if g.lists[n.Name] {
+				words[i] = fmt.Sprintf(`"${%s[@]}"`, n.Name)
+				continue
+			}
+		}
+		words[i] = quoteExpr(g.genExpr(arg))
+	}
+	return strings.Join(words, " ")
+}
+
+// genRunAssignment runs the command and binds name to a map holding its
+// stdout, stderr, exit code and whether it succeeded. A failing command is
+// reported in the map rather than stopping the script.
+func (g *Generator) genRunAssignment(name string, call *ast.FuncCall) {
+	g.useTemp()
+	g.useRuntime("run", runRuntime)
+	g.maps[name] = true
+	flag := "-A"
+	if g.mapParams != nil {
+		flag = "-gA"
+	}
+	g.writeln(fmt.Sprintf("declare %s %s=()", flag, name))
+	g.writeln(fmt.Sprintf("_run_capture %s %s", name, g.genRunWords(call)))
+}
@@ -9,29 +9,63 @@ import (
 )
 
 func (g *Generator) genStatement(node ast.Node) {
+	outer := g.enterStatement(node)
+	defer g.leaveStatement(outer)
+
+	if len(g.tryFrames) > 0 {
+		// Source line reported as err.line if this statement fails
+		g.writeln(fmt.Sprintf("_try_line=%d", node.NodeSpan().Start.Line))
+	}
+
 	switch n := node.(type) {
 	case *ast.Assignment:
 		g.genAssignment(n)
 	case *ast.FuncCall:
 		g.genFuncCallStmt(n)
+	case *ast.BinaryExpr:
+		if n.Op != "|>" {
+			g.writeln(fmt.Sprintf("# error: unhandled statement type %T", node))
+			break
+		}
+		g.genPipeStmt(n)
+	case *ast.MethodCall:
+		if !isLogCall(n) {
+			g.writeln(fmt.Sprintf("# error: unhandled statement type %T", node))
+			break
+		}
+		g.genLog(n)
 	case *ast.FuncDecl:
 		g.genFuncDecl(n)
+	case *ast.FlagsDecl:
+		g.genFlags(n)
 	case *ast.IfStmt:
 		g.genIf(n)
 	case *ast.ForStmt:
+		g.enterLoop()
 		g.genFor(n)
+		g.leaveLoop()
 	case *ast.MatchStmt:
 		g.genMatch(n)
 	case *ast.ReturnStmt:
 		g.genReturn(n)
 	case *ast.ContinueStmt:
-		g.writeln("continue")
+		g.writeln(g.exitWord("continue"))
 	case *ast.BreakStmt:
-		g.writeln("break")
+		g.writeln(g.exitWord("break"))
 	case *ast.IndexAssignment:
 		g.genIndexAssignment(n)
+	case *ast.PathAssignment:
+		g.genPathAssignment(n.Target, n.Value)
 	case *ast.WhileStmt:
+		g.enterLoop()
 		g.genWhile(n)
+		g.leaveLoop()
+	case *ast.TryStmt:
+		g.genTry(n)
+	case *ast.DeferStmt:
+		g.genDefer(n)
+	case *ast.WithStmt:
+		g.genWith(n)
 	case *ast.BashBlock:
 		g.genBashBlock(n)
 	case *ast.ImportStmt:
@@ -42,31 +76,76 @@ func (g *Generator) genStatement(node ast.Node) {
 }
 
 func (g *Generator) genAssignment(a *ast.Assignment) {
+	g.trackTyped(a.Name, a.Value)
+	if g.isJSON(a.Value) {
+		g.jsons[a.Name] = true
+	}
+	if g.isListExpr(a.Value) {
+		g.lists[a.Name] = true
+	}
 	if orExpr, ok := a.Value.(*ast.OrExpr); ok {
 		g.genOrAssignment(a.Name, orExpr)
 		return
 	}
+	if source, pipeline, ok := g.linesSource(a.Value); ok {
+		g.genLinesAssignment(a.Name, source, pipeline)
+		return
+	}
 	if mapLit, ok := a.Value.(*ast.MapLiteral); ok {
 		g.genMapAssignment(a.Name, mapLit)
 		return
 	}
+	if source, ok := g.jsonKeysSource(a.Value); ok {
+		g.genLinesAssignment(a.Name, source, true)
+		return
+	}
+	if source, ok := g.archiveListSource(a.Value); ok {
+		g.genLinesAssignment(a.Name, source, true)
+		return
+	}
 	if call, ok := a.Value.(*ast.FuncCall); ok && call.Name == "fetch" {
 		g.genFetchAssignment(a.Name, call)
 		return
 	}
+	if call, ok := a.Value.(*ast.FuncCall); ok && call.Name == "run" {
+		g.genRunAssignment(a.Name, call)
+		return
+	}
+	if call, ok := a.Value.(*ast.FuncCall); ok && call.Name == "read_ini" {
+		g.genReadIniAssignment(a.Name, call)
+		return
+	}
+	if call, ok := a.Value.(*ast.FuncCall); ok && call.Name == "spawn" {
+		g.genSpawn(a.Name, call)
+		return
+	}
+	if call, ok := a.Value.(*ast.FuncCall); ok && call.Name == "wait" {
+		g.genWaitAssignment(a.Name, call)
+		return
+	}
+	if call, ok := a.Value.(*ast.FuncCall); ok && call.Name == "regex_find" && len(call.Args) == 2 {
+		g.genRegexFindAssignment(a.Name, call)
+		return
+	}
 	if mc, ok := a.Value.(*ast.MethodCall); ok && mc.Method == "split" {
 		g.genSplitAssignment(a.Name, mc)
 		return
 	}
-	g.writeIndent()
-	value := g.genExpr(a.Value)
-	g.write(fmt.Sprintf("%s=%s\n", a.Name, value))
-}
-
-func (g *Generator) genMapAssignment(name string, m *ast.MapLiteral) {
-	for i, key := range m.Keys {
-		g.writeln(fmt.Sprintf("%s_%s=%s", name, sanitizeMapKey(key), g.genExpr(m.Values[i])))
+	if call, ok := a.Value.(*ast.FuncCall); ok && g.returnsValue(call.Name) && g.funcs[call.Name].ReturnType == "list" {
+		// List results come back one element per line. The function runs
+		// in a process substitution, so its status is taken with wait.
+		g.writeln(fmt.Sprintf("mapfile -t %s < <(%s)", a.Name, g.genUserCall(call)))
+		g.writeln("wait $!")
+		return
+	}
+	if g.isFloatExpr(a.Value) {
+		g.floats[a.Name] = true
 	}
+	if call, ok := a.Value.(*ast.FuncCall); ok && call.Name == "render" {
+		g.writeln(fmt.Sprintf("%s=%s", a.Name, g.genRenderInline(call)))
+		return
+	}
+	g.writeln(fmt.Sprintf("%s=%s", a.Name, g.genExpr(a.Value)))
 }
 
 func (g *Generator) genOrAssignment(name string, or *ast.OrExpr) {
@@ -92,8 +171,22 @@ func (g *Generator) genOrAssignment(name string, or *ast.OrExpr) {
 		return
 	}
 
+	// Special case: run(...) or fallback -> the fallback runs when it fails
+	if call, ok := or.Expr.(*ast.FuncCall); ok && call.Name == "run" {
+		g.genRunAssignment(name, call)
+		g.writeln(fmt.Sprintf(`if [ "${%s["ok"]}" != true ]; then`, name))
+		g.genOrFallback(name, or.Fallback)
+		g.writeln("fi")
+		return
+	}
+
 	// General case: if name=$(expr 2>/dev/null); then true; else fallback; fi
-	expr := g.genExpr(or.Expr)
+	var expr string
+	if call, ok := or.Expr.(*ast.FuncCall); ok && call.Name == "render" {
+		expr = g.genRenderCommand(call)
+	} else {
+		expr = g.genExpr(or.Expr)
+	}
 	g.writeln(fmt.Sprintf("if %s=$(%s 2>/dev/null); then", name, stripSubshell(expr)))
 	g.indent++
 	g.writeln("true")
@@ -117,7 +210,7 @@ func (g *Generator) genOrFallback(name string, fallback ast.Node) {
 		g.genBlock(n.Statements)
 	case *ast.ContinueStmt:
 		g.indent++
-		g.writeln("continue")
+		g.writeln(g.exitWord("continue"))
 		g.indent--
 	case *ast.FuncCall:
 		g.indent++
@@ -129,8 +222,7 @@ func (g *Generator) genOrFallback(name string, fallback ast.Node) {
 		g.indent--
 	default:
 		g.indent++
-		g.writeIndent()
-		g.write(fmt.Sprintf("%s=%s\n", name, g.genExpr(fallback)))
+		g.writeln(fmt.Sprintf("%s=%s", name, g.genExpr(fallback)))
 		g.indent--
 	}
 }
@@ -140,25 +232,126 @@ func (g *Generator) genFuncCallStmt(f *ast.FuncCall) {
 		g.genFetchStatement(f)
 		return
 	}
+	if f.Name == "spawn" {
+		g.genSpawn("", f)
+		return
+	}
+	if f.Name == "render_to" {
+		g.writeln(g.genRenderTo(f))
+		return
+	}
+	if f.Name == "load_env" {
+		g.writeln(g.genLoadEnv(f))
+		return
+	}
+	if f.Name == "write_env" {
+		g.writeln(g.genWriteEnv(f))
+		return
+	}
+	if f.Name == "archive" {
+		g.writeln(g.genArchive(f))
+		return
+	}
+	if f.Name == "extract" {
+		g.writeln(g.genExtract(f))
+		return
+	}
+	if g.isCommand(f) {
+		g.writeln(g.genRunWords(f))
+		return
+	}
 	result := builtins.GenStmt(f.Name, f.Args, f.KwArgs, g.genExpr, g.genRawValue)
+	if result.OK && f.Name == "print" && isValueType(g.returnType) {
+		// stdout carries the function's result, so its output goes to stderr
+		g.writeln(result.Code + " >&2")
+		return
+	}
+	if result.OK && f.Name == "exit" {
+		g.writeln(g.exitWord(result.Code))
+		return
+	}
 	if result.OK {
 		g.writeln(result.Code)
 		return
 	}
 
-	// User-defined function call
-	args := make([]string, len(f.Args))
-	for i, arg := range f.Args {
-		args[i] = g.genExpr(arg)
+	// User-defined function call; a discarded return value must not leak to stdout
+	if g.returnsValue(f.Name) {
+		g.writeln(fmt.Sprintf("%s >/dev/null", g.genUserCall(f)))
+		return
+	}
+	g.writeln(g.genUserCall(f))
+}
+
+// genUserCall renders a call to a user-defined function as a Bash command.
+// Keyword arguments are placed in their parameter's positional slot; skipped
+// slots are passed as "" so the callee's ${n:-default} applies.
+func (g *Generator) genUserCall(f *ast.FuncCall) string {
+	args := f.Args
+	if decl, ok := g.funcs[f.Name]; ok && len(f.KwArgs) > 0 && len(f.Args) <= len(decl.Params) {
+		args = append([]ast.Node{}, f.Args...)
+		for _, param := range decl.Params[len(f.Args):] {
+			kw, _ := builtins.FindKwarg(f.KwArgs, param.Name)
+			args = append(args, kw) // nil when not given
+		}
+		args = args[:lastNonNil(args)+1]
+	}
+
+	decl := g.funcs[f.Name]
+	parts := []string{f.Name}
+	for i, arg := range args {
+		if arg == nil {
+			parts = append(parts, `""`)
+			continue
+		}
+		if g.isMap(arg) || (decl != nil && i < len(decl.Params) && decl.Params[i].Type == "map") {
+			parts = append(parts, g.genMapArg(arg))
+			continue
+		}
+		if decl != nil && i < len(decl.Params) && decl.Params[i].Type == "list" {
+			parts = append(parts, g.genListArg(arg))
+			continue
+		}
+		parts = append(parts, g.genExpr(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+func lastNonNil(nodes []ast.Node) int {
+	for i := len(nodes) - 1; i >= 0; i-- {
+		if nodes[i] != nil {
+			return i
+		}
 	}
-	g.writeln(fmt.Sprintf("%s %s", f.Name, strings.Join(args, " ")))
+	return -1
 }
 
 func (g *Generator) genFuncDecl(f *ast.FuncDecl) {
 	g.writeln(fmt.Sprintf("%s() {", f.Name))
 	g.indent++
 
+	outer, outerMapParams, outerTryFrames := g.returnType, g.mapParams, g.tryFrames
+	g.returnType = f.ReturnType
+	g.mapParams = make(map[string]int)
+	g.tryFrames = nil
+	defer func() { g.returnType, g.mapParams, g.tryFrames = outer, outerMapParams, outerTryFrames }()
+
 	for i, param := range f.Params {
+		delete(g.typed, param.Name)
+		if param.Type == "float" {
+			g.floats[param.Name] = true
+		}
+		if param.Type == "json" {
+			g.jsons[param.Name] = true
+		}
+		if param.Type == "map" {
+			g.genMapParam(param.Name, i+1)
+			continue
+		}
+		if param.Type == "list" {
+			g.genListParam(param.Name, i+1)
+			continue
+		}
 		if param.Default != nil {
 			def := g.genRawValue(param.Default)
 			g.writeln(fmt.Sprintf(`local %s="${%d:-%s}"`, param.Name, i+1, def))
@@ -167,6 +360,9 @@ func (g *Generator) genFuncDecl(f *ast.FuncDecl) {
 		}
 	}
 
+	if hasDefer(f.Body) {
+		g.genDeferFrame()
+	}
 	for _, stmt := range f.Body {
 		g.genStatement(stmt)
 	}
@@ -185,7 +381,10 @@ func (g *Generator) genIf(i *ast.IfStmt) {
 func (g *Generator) genElseChain(elseBody []ast.Node) {
 	if len(elseBody) == 1 {
 		if elif, ok := elseBody[0].(*ast.IfStmt); ok {
-			g.writeln(fmt.Sprintf("elif %s; then", g.genCondition(elif.Condition)))
+			g.inlineDepth++
+			cond := g.genCondition(elif.Condition)
+			g.inlineDepth--
+			g.writeln(fmt.Sprintf("elif %s; then", cond))
 			g.genBlock(elif.Body)
 			g.genElseChain(elif.ElseBody)
 			return
@@ -198,6 +397,26 @@ func (g *Generator) genElseChain(elseBody []ast.Node) {
 }
 
 func (g *Generator) genFor(f *ast.ForStmt) {
+	if g.isJSON(f.Collection) {
+		g.genJSONFor(f)
+		return
+	}
+	if f.ValueVar != "" {
+		g.genPairFor(f)
+		return
+	}
+	if source, pipeline, ok := g.linesSource(f.Collection); ok {
+		g.genLinesFor(f, source, pipeline)
+		return
+	}
+	if source, ok := g.jsonKeysSource(f.Collection); ok {
+		g.genLinesFor(f, source, true)
+		return
+	}
+	if source, ok := g.archiveListSource(f.Collection); ok {
+		g.genLinesFor(f, source, true)
+		return
+	}
 	collection := g.genForCollection(f.Collection)
 	g.writeln(fmt.Sprintf("for %s in %s; do", f.Var, collection))
 	g.genBlock(f.Body)
@@ -205,6 +424,10 @@ func (g *Generator) genFor(f *ast.ForStmt) {
 }
 
 func (g *Generator) genMatch(m *ast.MatchStmt) {
+	if hasRegexArm(m) {
+		g.genRegexMatch(m)
+		return
+	}
 	expr := g.genConditionOperand(m.Expr)
 	g.writeln(fmt.Sprintf("case %s in", expr))
 	g.indent++
@@ -228,16 +451,35 @@ func (g *Generator) genMatch(m *ast.MatchStmt) {
 }
 
 func (g *Generator) genWhile(w *ast.WhileStmt) {
-	g.writeln(fmt.Sprintf("while %s; do", g.genCondition(w.Condition)))
+	g.inlineDepth++
+	cond := g.genCondition(w.Condition)
+	g.inlineDepth--
+	g.writeln(fmt.Sprintf("while %s; do", cond))
 	g.genBlock(w.Body)
 	g.writeln("done")
 }
 
 func (g *Generator) genIndexAssignment(n *ast.IndexAssignment) {
+	if g.jsons[n.Object] {
+		target := &ast.IndexExpr{Object: &ast.Identifier{Name: n.Object}, Index: n.Index}
+		g.genPathAssignment(target, n.Value)
+		return
+	}
+	switch idx := n.Index.(type) {
+	case *ast.IntLiteral:
+		g.trackTypedEntry(n.Object, idx.Value, n.Value)
+	default:
+		if s, ok := idx.(*ast.StringLiteral); ok && (s.Raw || !interpRegex.MatchString(s.Value)) {
+			g.trackTypedEntry(n.Object, s.Value, n.Value)
+		} else if !g.isTypedScalar(n.Value) {
+			// Any entry may now hold a string
+			delete(g.typed, n.Object)
+		}
+	}
 	val := g.genExpr(n.Value)
-	if strIdx, ok := n.Index.(*ast.StringLiteral); ok {
-		// Map assignment: config["host"] = "new" → config_host="new"
-		g.writeln(fmt.Sprintf(`%s_%s=%s`, n.Object, sanitizeMapKey(strIdx.Value), val))
+	if _, ok := n.Index.(*ast.StringLiteral); ok {
+		// Map assignment: config["host"] = "new" → config["host"]="new"
+		g.writeln(fmt.Sprintf(`%s[%s]=%s`, n.Object, g.genExpr(n.Index), val))
 	} else {
 		// Array assignment: items[0] = "new" → items[0]="new"
 		idx := g.genRawValue(n.Index)
@@ -251,10 +493,52 @@ func (g *Generator) genBashBlock(b *ast.BashBlock) {
 	}
 }
 
+// genReturn emits a return. Functions declared with a value return type
+// print their result for the caller's $( ) to capture; all others map the
+// value to an exit status.
 func (g *Generator) genReturn(r *ast.ReturnStmt) {
 	if r.Value == nil {
-		g.writeln("return")
+		g.writeln(g.exitWord("return"))
+		return
+	}
+	if isValueType(g.returnType) {
+		if args := g.genReturnValue(r.Value); args != "" {
+			g.writeln(fmt.Sprintf("printf '%%s\\n' %s", args))
+		}
+		g.writeln(g.exitWord("return 0"))
 		return
 	}
-	g.writeln(fmt.Sprintf("return %s", g.genExpr(r.Value)))
+	if b, ok := r.Value.(*ast.BoolLiteral); ok {
+		if b.Value {
+			g.writeln(g.exitWord("return 0"))
+		} else {
+			g.writeln(g.exitWord("return 1"))
+		}
+		return
+	}
+	if g.returnType == "bool" {
+		g.writeln(fmt.Sprintf("if %s; then %s; else %s; fi", g.genCondition(r.Value), g.exitWord("return 0"), g.exitWord("return 1")))
+		return
+	}
+	g.writeln(g.exitWord(fmt.Sprintf("return %s", g.genExpr(r.Value))))
+}
+
+// genReturnValue renders the printf arguments for a returned value.
+// Lists expand to one argument per element; an empty one prints nothing,
+// as printf with no arguments would still print an empty element.
+func (g *Generator) genReturnValue(node ast.Node) string {
+	if g.returnType == "list" {
+		if id, ok := node.(*ast.Identifier); ok {
+			g.writeln(fmt.Sprintf(`[ "${#%s[@]}" -gt 0 ] || %s`, id.Name, g.exitWord("return 0")))
+			return fmt.Sprintf(`"${%s[@]}"`, id.Name)
+		}
+		if list, ok := node.(*ast.ListLiteral); ok {
+			elems := make([]string, len(list.Elements))
+			for i, e := range list.Elements {
+				elems[i] = g.genExpr(e)
+			}
+			return strings.Join(elems, " ")
+		}
+	}
+	return quoteExpr(g.genExpr(node))
 }
@@ -0,0 +1,90 @@
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/tasnimzotder/langz/internal/ast"
+	"github.com/tasnimzotder/langz/internal/codegen/builtins"
+)
+
+// tempRuntime hands out temp files and directories from a private
+// directory, made with mktemp -d when the script starts. Removing that
+// directory sits at the bottom of the defer stack, so it happens last on
+// exit, after a failure under set -e and on the signals the defer traps
+// turn into an exit; nothing a script creates there outlives it.
+//
+// _temp_new kind suffix creates a file or directory there and prints its
+// path. Names combine the creating process and a counter, so calls from
+// subshells don't collide, and creation is exclusive, so a name that is
+// somehow taken is skipped. A suffix is kept as is, which mktemp can't do
+// portably. _temp_remove deletes a path early, but only one of ours.
+const tempRuntime = `_temp_root=$(mktemp -d)
+_defers+=('rm -rf "$_temp_root"')
+_temp_n=0
+_temp_new() {
+  local path tries=0
+  case $2 in
+    */*)
+      printf '%s: suffix "%s" must not contain /\n' "$1" "$2" >&2
+      return 1
+      ;;
+  esac
+  while :; do
+    _temp_n=$((_temp_n + 1))
+    path=$_temp_root/$BASHPID.$_temp_n$2
+    if [ "$1" = tempdir ]; then
+      mkdir "$path" 2>/dev/null && break
+    else
+      (set -C; : > "$path") 2>/dev/null && break
+    fi
+    tries=$((tries + 1))
+    if [ "$tries" -ge 100 ] || ! [ -d "$_temp_root" ]; then
+      printf '%s: cannot create %s in %s\n' "$1" "${path##*/}" "$_temp_root" >&2
+      return 1
+    fi
+  done
+  printf '%s\n' "$path"
+}
+_temp_remove() {
+  case $1 in
+    "$_temp_root"/*) rm -rf "$1" ;;
+  esac
+}`
+
+// useTemp registers the temp runtime, which needs the defer stack for its
+// cleanup.
+func (g *Generator) useTemp() {
+	g.useRuntime("defer", deferRuntime)
+	g.useRuntime("temp", tempRuntime)
+}
+
+// genTempCall generates tempfile(suffix:) and tempdir() as values: the path
+// of the new file or directory.
+func (g *Generator) genTempCall(call *ast.FuncCall) string {
+	if len(call.Args) != 0 {
+		return fmt.Sprintf("# error: %s() takes no positional arguments", call.Name)
+	}
+	g.useTemp()
+	suffix := `""`
+	if kw, ok := builtins.FindKwarg(call.KwArgs, "suffix"); ok && call.Name == "tempfile" {
+		suffix = quoteExpr(g.genExpr(kw))
+	}
+	return fmt.Sprintf(`"$(_temp_new %s %s)"`, call.Name, suffix)
+}
+
+// genWith binds the name to a new temp path for the length of the block
+// and removes the path when the block ends. Sema keeps return, break and
+// continue from leaving the block, so the end is always reached unless the
+// script stops, in which case the exit cleanup removes the path instead.
+func (g *Generator) genWith(w *ast.WithStmt) {
+	call, ok := w.Value.(*ast.FuncCall)
+	if !ok || (call.Name != "tempfile" && call.Name != "tempdir") {
+		g.writeln("# error: with needs tempfile() or tempdir()")
+		return
+	}
+	g.writeln(fmt.Sprintf("%s=%s", w.Var, g.genTempCall(call)))
+	for _, stmt := range w.Body {
+		g.genStatement(stmt)
+	}
+	g.writeln(fmt.Sprintf(`_temp_remove "$%s"`, w.Var))
+}
@@ -0,0 +1,235 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tasnimzotder/langz/internal/ast"
+	"github.com/tasnimzotder/langz/internal/codegen/builtins"
+)
+
+// renderRuntime fills in templates. _render takes the template path, the
+// name of a map holding its variables (or "") and further key/value pairs,
+// and prints the result. Values are only ever concatenated, never
+// evaluated, so quotes, $ and backticks in them come out as they went in.
+//
+// {name} substitutes a variable. {for item in list} repeats the lines up
+// to its {end} once per line of list, and {if name} ... {else} ... {end}
+// keeps one side, taking a value as false when it is empty or "false".
+// Tags must be alone on their line, which is dropped from the output. A
+// variable the template uses but vars doesn't define is an error.
+const renderRuntime = `_render_tag='^[[:space:]]*\{(else|end|(for|if)[[:space:]]+([^}]*))\}[[:space:]]*$'
+_render_for='^([A-Za-z_][A-Za-z0-9_]*)[[:space:]]+in[[:space:]]+([A-Za-z_][A-Za-z0-9_]*)[[:space:]]*$'
+_render_if='^(!?)[[:space:]]*([A-Za-z_][A-Za-z0-9_]*)[[:space:]]*$'
+_render_name='^([A-Za-z_][A-Za-z0-9_]*)\}'
+_render_fail() {
+  printf 'render: %s, line %d: %s\n' "$1" "$2" "$3" >&2
+}
+_render_value() {
+  if [[ -v _render_loop[$3] ]]; then
+    _render_val=${_render_loop[$3]}
+  elif [[ -v _render_vars[$3] ]]; then
+    _render_val=${_render_vars[$3]}
+  else
+    _render_fail "$1" "$2" "undefined variable $3"
+    return 1
+  fi
+}
+_render_line() {
+  local rest=$3 out=""
+  while [[ $rest == *"{"* ]]; do
+    out+=${rest%%"{"*}
+    rest=${rest#*"{"}
+    if [[ $rest =~ $_render_name ]]; then
+      _render_value "$1" "$2" "${BASH_REMATCH[1]}" || return 1
+      out+=$_render_val
+      rest=${rest#*"}"}
+    else
+      out+="{"
+    fi
+  done
+  _render_out+=$out$rest$'\n'
+}
+_render_block() {
+  local i=$2 j depth els kind rest var neg taken item old had items
+  while [ "$i" -lt "$3" ]; do
+    if ! [[ ${_render_lines[i]} =~ $_render_tag ]]; then
+      _render_line "$1" $((i + 1)) "${_render_lines[i]}" || return 1
+      i=$((i + 1))
+      continue
+    fi
+    kind=${BASH_REMATCH[2]:-${BASH_REMATCH[1]}} rest=${BASH_REMATCH[3]}
+    if [ "$kind" = else ] || [ "$kind" = end ]; then
+      _render_fail "$1" $((i + 1)) "unexpected {$kind}"
+      return 1
+    fi
+    depth=0 els=-1 j=$((i + 1))
+    while [ "$j" -lt "$3" ]; do
+      if [[ ${_render_lines[j]} =~ $_render_tag ]]; then
+        case ${BASH_REMATCH[2]:-${BASH_REMATCH[1]}} in
+          for | if) depth=$((depth + 1)) ;;
+          else)
+            if [ "$depth" -eq 0 ]; then
+              if [ "$kind" = for ] || [ "$els" -ge 0 ]; then
+                _render_fail "$1" $((j + 1)) "unexpected {else}"
+                return 1
+              fi
+              els=$j
+            fi
+            ;;
+          end)
+            [ "$depth" -gt 0 ] || break
+            depth=$((depth - 1))
+            ;;
+        esac
+      fi
+      j=$((j + 1))
+    done
+    if [ "$j" -ge "$3" ]; then
+      _render_fail "$1" $((i + 1)) "{$kind} without {end}"
+      return 1
+    fi
+    if [ "$kind" = for ]; then
+      if ! [[ $rest =~ $_render_for ]]; then
+        _render_fail "$1" $((i + 1)) "malformed {for}, want {for item in list}"
+        return 1
+      fi
+      var=${BASH_REMATCH[1]}
+      _render_value "$1" $((i + 1)) "${BASH_REMATCH[2]}" || return 1
+      items=()
+      [ -z "$_render_val" ] || mapfile -t items <<< "$_render_val"
+      had=false
+      if [[ -v _render_loop[$var] ]]; then
+        had=true old=${_render_loop[$var]}
+      fi
+      for item in "${items[@]}"; do
+        _render_loop[$var]=$item
+        _render_block "$1" $((i + 1)) "$j" || return 1
+      done
+      if [ "$had" = true ]; then
+        _render_loop[$var]=$old
+      else
+        unset "_render_loop[$var]"
+      fi
+    else
+      if ! [[ $rest =~ $_render_if ]]; then
+        _render_fail "$1" $((i + 1)) "malformed {if}, want {if name} or {if !name}"
+        return 1
+      fi
+      neg=${BASH_REMATCH[1]}
+      _render_value "$1" $((i + 1)) "${BASH_REMATCH[2]}" || return 1
+      taken=true
+      if [ -z "$_render_val" ] || [ "$_render_val" = false ]; then
+        taken=false
+      fi
+      case $neg$taken in
+        true | '!false') _render_block "$1" $((i + 1)) $((els < 0 ? j : els)) || return 1 ;;
+        *) [ "$els" -lt 0 ] || _render_block "$1" $((els + 1)) "$j" || return 1 ;;
+      esac
+    fi
+    i=$((j + 1))
+  done
+}
+_render() {
+  local _render_file=$1 _render_key _render_val _render_out=""
+  local -a _render_lines=()
+  local -A _render_vars=() _render_loop=()
+  if [ -n "$2" ]; then
+    local -n _render_map=$2
+    for _render_key in "${!_render_map[@]}"; do
+      _render_vars[$_render_key]=${_render_map[$_render_key]}
+    done
+  fi
+  shift 2
+  while [ $# -gt 1 ]; do
+    _render_vars[$1]=$2
+    shift 2
+  done
+  if ! [ -r "$_render_file" ]; then
+    printf 'render: cannot read template %s\n' "$_render_file" >&2
+    return 1
+  fi
+  mapfile -t _render_lines < "$_render_file"
+  _render_block "$_render_file" 0 "${#_render_lines[@]}" || return 1
+  printf '%s' "$_render_out"
+}
+_render_to() {
+  local text
+  text=$(_render "$1" "${@:3}" && printf .) || return 1
+  printf '%s' "${text%.}" > "$2"
+}`
+
+// genRender generates render() as a value: the filled-in template, less
+// its trailing newline like any command output. The template is rendered
+// into a variable in a statement of its own first, so a failure stops the
+// script under set -e before the value is written or passed anywhere;
+// inside a $( ) it would go unnoticed.
+func (g *Generator) genRender(call *ast.FuncCall) string {
+	value := g.genRenderInline(call)
+	if g.inlineDepth > 0 || len(call.Args) != 1 {
+		return value
+	}
+	g.renders++
+	name := fmt.Sprintf("_rendered%d", g.renders)
+	g.writeln(fmt.Sprintf("%s=%s", name, value))
+	return fmt.Sprintf(`"$%s"`, name)
+}
+
+// genRenderInline generates render() as a command substitution, for
+// assignments, whose status set -e already checks.
+func (g *Generator) genRenderInline(call *ast.FuncCall) string {
+	cmd := g.genRenderCommand(call)
+	if len(call.Args) != 1 {
+		return cmd
+	}
+	return fmt.Sprintf(`"$(%s)"`, cmd)
+}
+
+// genRenderCommand generates the command printing the filled-in template.
+func (g *Generator) genRenderCommand(call *ast.FuncCall) string {
+	if len(call.Args) != 1 {
+		return "# error: render() requires 1 argument (template path)"
+	}
+	g.useRuntime("render", renderRuntime)
+	return fmt.Sprintf("_render %s %s", g.genExpr(call.Args[0]), g.genRenderVars(call))
+}
+
+// genRenderTo generates render_to(template, dest), which writes the
+// filled-in template only once it has rendered without errors.
+func (g *Generator) genRenderTo(call *ast.FuncCall) string {
+	if len(call.Args) != 2 {
+		return "# error: render_to() requires 2 arguments (template path, destination)"
+	}
+	g.useRuntime("render", renderRuntime)
+	return fmt.Sprintf("_render_to %s %s %s", g.genExpr(call.Args[0]), g.genExpr(call.Args[1]), g.genRenderVars(call))
+}
+
+// genRenderVars passes vars: to _render. A map variable goes by name; a
+// map literal's entries follow as key/value pairs, with lists joined one
+// element per line.
+func (g *Generator) genRenderVars(call *ast.FuncCall) string {
+	vars, ok := builtins.FindKwarg(call.KwArgs, "vars")
+	if !ok {
+		return `""`
+	}
+	return g.genMapOrPairs(vars, g.genTemplateValue)
+}
+
+func (g *Generator) genTemplateValue(node ast.Node) string {
+	switch n := node.(type) {
+	case *ast.ListLiteral:
+		if len(n.Elements) == 0 {
+			return `""`
+		}
+		elems := make([]string, len(n.Elements))
+		for i, e := range n.Elements {
+			elems[i] = g.genExpr(e)
+		}
+		return fmt.Sprintf(`"$(printf '%%s\n' %s)"`, strings.Join(elems, " "))
+	case *ast.Identifier:
+		if g.lists[n.Name] {
+			return fmt.Sprintf(`"$(printf '%%s\n' "${%s[@]}")"`, n.Name)
+		}
+	}
+	return quoteExpr(g.genExpr(node))
+}
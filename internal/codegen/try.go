@@ -0,0 +1,183 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tasnimzotder/langz/internal/ast"
+)
+
+// tryRuntime holds the helpers for try blocks. _try_catch runs from the
+// ERR trap of a try block: it records the first failure in the named
+// array, and fails unless the failing command is the call of the block's
+// body itself, so that the trap unwinds out of the body. _try_raise fails
+// with a recorded error so that the enclosing try block, or errexit, sees
+// the original one.
+const tryRuntime = `_try_catch() {
+  (( ${#BASH_SOURCE[@]} - 1 > $3 )) || return 0
+  local -n _err=$1
+  [[ -v _err ]] || _err=("$2" "$_try_line" "${_try_command:-$BASH_COMMAND}")
+  unset _try_command
+  return 1
+}
+
+_try_raise() {
+  local -n _err=$1
+  _try_line=${_err[1]}
+  _try_command=${_err[2]}
+  return "${_err[0]}"
+}`
+
+// tryFrame is a try block whose body is being generated, with the number
+// of loops opened inside the body so far, and whether the body has a
+// return, break or continue leaving it.
+type tryFrame struct {
+	id    string
+	loops int
+	exits bool
+}
+
+// innerTry returns the innermost try block being generated in the current
+// function, or nil.
+func (g *Generator) innerTry() *tryFrame {
+	if len(g.tryFrames) == 0 {
+		return nil
+	}
+	return g.tryFrames[len(g.tryFrames)-1]
+}
+
+// exitWord renders a return, break, continue or exit. A try block's body
+// runs as a function, so one that leaves the body is recorded and returns
+// from it instead, to be done once the try block has finished.
+func (g *Generator) exitWord(word string) string {
+	f := g.innerTry()
+	if f == nil || (!leavesFunction(word) && f.loops > 0) {
+		return word
+	}
+	f.exits = true
+	return fmt.Sprintf("{ %s_exit=(%s); return 0; }", f.id, word)
+}
+
+// genTry runs the try block's body as a function in the current shell,
+// so everything it does stays visible after it. errexit is off while it
+// runs, and an ERR trap, inherited by functions and subshells with set -E,
+// records the failing command and unwinds: a subshell exits, a function
+// returns, and so on up to the body. The trap and errexit are put back
+// before catch and finally run. An uncaught failure is raised again once
+// finally has run, and a return, break, continue or exit() from the body
+// is done last.
+func (g *Generator) genTry(t *ast.TryStmt) {
+	g.useRuntime("try", tryRuntime)
+	g.tries++
+	id := fmt.Sprintf("_try%d", g.tries)
+	errVar, exit := id+"_err", id+"_exit"
+	opts, trap, depth, sub := id+"_opts", id+"_trap", id+"_depth", id+"_sub"
+
+	if g.mapParams != nil {
+		// Recursive calls keep their own state
+		g.writeln(fmt.Sprintf("local %s %s %s %s %s %s", errVar, exit, opts, trap, depth, sub))
+	}
+	g.writeln(fmt.Sprintf("unset %s %s", errVar, exit))
+	g.writeln(fmt.Sprintf(`%s=$- %s=$(trap -p ERR) %s=${#BASH_SOURCE[@]} %s=$BASH_SUBSHELL`, opts, trap, depth, sub))
+	g.writeln(id + "_body() {")
+	g.indent++
+	frame := &tryFrame{id: id}
+	g.tryFrames = append(g.tryFrames, frame)
+	outerMapParams := g.mapParams
+	if g.mapParams == nil {
+		// The body is a function, so maps must be declared global there
+		g.mapParams = make(map[string]int)
+	}
+	for _, stmt := range t.Body {
+		g.genStatement(stmt)
+	}
+	g.mapParams = outerMapParams
+	g.tryFrames = g.tryFrames[:len(g.tryFrames)-1]
+	g.writeln("return 0")
+	g.indent--
+	g.writeln("}")
+	g.writeln("set +e -E")
+	g.writeln(fmt.Sprintf(`trap '_try_catch %s "$?" "$%s" || { (( BASH_SUBSHELL == %s )) || exit "${%s[0]}"; return "${%s[0]}"; }' ERR`,
+		errVar, depth, sub, errVar, errVar))
+	g.writeln(id + `_body "$@"`)
+	g.writeln(fmt.Sprintf(`eval "${%s:-trap - ERR}"`, trap))
+	g.writeln(fmt.Sprintf(`[[ $%s != *e* ]] || set -e`, opts))
+
+	if t.HasCatch {
+		g.writeln(fmt.Sprintf("if [[ -v %s ]]; then", errVar))
+		g.indent++
+		if t.ErrVar != "" {
+			g.genErrMap(t.ErrVar, errVar)
+		}
+		g.indent--
+		g.genBlock(t.Catch)
+		g.writeln("fi")
+	}
+	for _, stmt := range t.Finally {
+		g.genStatement(stmt)
+	}
+	if !t.HasCatch {
+		g.writeln(fmt.Sprintf("if [[ -v %s ]]; then", errVar))
+		g.indent++
+		g.writeln(fmt.Sprintf("_try_raise %s", errVar))
+		g.indent--
+		g.writeln("fi")
+	}
+	if frame.exits {
+		g.genTryExit(exit)
+	}
+}
+
+// genTryExit does the return, break, continue or exit recorded in exit,
+// which may leave an enclosing try block's body in turn.
+func (g *Generator) genTryExit(exit string) {
+	word := fmt.Sprintf(`"${%s[@]}"`, exit)
+	g.writeln(fmt.Sprintf("if [[ -v %s ]]; then", exit))
+	g.indent++
+	outer := g.innerTry()
+	switch {
+	case outer == nil:
+		g.writeln(word)
+	case outer.loops == 0:
+		outer.exits = true
+		g.writeln(fmt.Sprintf("%s_exit=(%s)", outer.id, word))
+		g.writeln("return 0")
+	default:
+		outer.exits = true
+		g.writeln(fmt.Sprintf("[[ ${%s[0]} == break || ${%s[0]} == continue ]] || { %s_exit=(%s); return 0; }", exit, exit, outer.id, word))
+		g.writeln(word)
+	}
+	g.indent--
+	g.writeln("fi")
+}
+
+// genErrMap binds the catch variable to a map describing the failure.
+func (g *Generator) genErrMap(name, info string) {
+	g.maps[name] = true
+	flag := "-A"
+	if g.mapParams != nil {
+		flag = "-gA"
+	}
+	g.writeln(fmt.Sprintf(`declare %s %s=(["code"]="${%s[0]}" ["line"]="${%s[1]}" ["command"]="${%s[2]}")`,
+		flag, name, info, info, info))
+}
+
+// leavesFunction reports whether word, a return or exit, leaves the
+// function it is in, rather than a loop.
+func leavesFunction(word string) bool {
+	return strings.HasPrefix(word, "return") || strings.HasPrefix(word, "exit")
+}
+
+// enterLoop and leaveLoop count the loops opened inside the innermost try
+// block's body, which break and continue may leave without leaving it.
+func (g *Generator) enterLoop() {
+	if f := g.innerTry(); f != nil {
+		f.loops++
+	}
+}
+
+func (g *Generator) leaveLoop() {
+	if f := g.innerTry(); f != nil {
+		f.loops--
+	}
+}
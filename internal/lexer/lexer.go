@@ -93,34 +93,113 @@ func (l *Lexer) skipComment() {
 // was properly terminated. An unterminated string returns (partial, false).
 func (l *Lexer) readString() (string, bool) {
 	l.advance() // skip opening "
-	var buf []byte
+	start := l.pos
 	for l.pos < len(l.input) && l.current != '"' {
 		if l.current == '\\' && l.pos+1 < len(l.input) {
-			next := l.input[l.pos+1]
-			switch next {
-			case '"':
-				buf = append(buf, '"')
-			case 'n':
-				buf = append(buf, '\n')
-			case 't':
-				buf = append(buf, '\t')
-			case '\\':
-				buf = append(buf, '\\')
-			default:
-				buf = append(buf, '\\', next)
-			}
 			l.advance() // skip '\'
-			l.advance() // skip escaped char
-		} else {
-			buf = append(buf, l.input[l.pos])
-			l.advance()
 		}
+		l.advance()
 	}
+	raw := l.input[start:l.pos]
 	if l.current != '"' {
-		return string(buf), false
+		return unescape(raw), false
 	}
 	l.advance() // skip closing "
-	return string(buf), true
+	return unescape(raw), true
+}
+
+// readText reads a triple-quoted string. The content is dedented, and
+// escapes are processed unless raw is set. Returns whether the string was
+// terminated.
+func (l *Lexer) readText(raw bool) (string, bool) {
+	for i := 0; i < 3; i++ {
+		l.advance() // skip opening """
+	}
+	start := l.pos
+	for l.pos < len(l.input) && !strings.HasPrefix(l.input[l.pos:], `"""`) {
+		if !raw && l.current == '\\' && l.pos+1 < len(l.input) {
+			l.advance() // skip '\'
+		}
+		l.advance()
+	}
+	text := dedent(l.input[start:l.pos])
+	if !raw {
+		text = unescape(text)
+	}
+	if l.pos >= len(l.input) {
+		return text, false
+	}
+	for i := 0; i < 3; i++ {
+		l.advance() // skip closing """
+	}
+	return text, true
+}
+
+// unescape processes the escape sequences of a string literal.
+// Unknown escapes are kept as written.
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var buf []byte
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			buf = append(buf, s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case '"':
+			buf = append(buf, '"')
+		case 'n':
+			buf = append(buf, '\n')
+		case 't':
+			buf = append(buf, '\t')
+		case '\\':
+			buf = append(buf, '\\')
+		default:
+			buf = append(buf, '\\', s[i])
+		}
+	}
+	return string(buf)
+}
+
+// dedent normalizes the body of a triple-quoted string: a line break right
+// after the opening quotes is dropped, as is the whitespace before the
+// closing quotes when they sit on their own line, and the indentation
+// common to all non-blank lines is removed.
+func dedent(s string) string {
+	s = strings.TrimPrefix(s, "\r")
+	s = strings.TrimPrefix(s, "\n")
+	lines := strings.Split(s, "\n")
+	if len(lines) > 1 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	margin := ""
+	first := true
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if first {
+			margin, first = indent, false
+			continue
+		}
+		for !strings.HasPrefix(indent, margin) {
+			margin = margin[:len(margin)-1]
+		}
+	}
+
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			lines[i] = ""
+			continue
+		}
+		lines[i] = strings.TrimPrefix(line, margin)
+	}
+	return strings.Join(lines, "\n")
 }
 
 func (l *Lexer) readIdent() string {
@@ -131,12 +210,21 @@ func (l *Lexer) readIdent() string {
 	return l.input[start:l.pos]
 }
 
-func (l *Lexer) readNumber() string {
+// readNumber reads an integer, or a float when the digits are followed by
+// a dot and more digits.
+func (l *Lexer) readNumber() (TokenType, string) {
 	start := l.pos
 	for l.pos < len(l.input) && isDigit(l.current) {
 		l.advance()
 	}
-	return l.input[start:l.pos]
+	if l.current != '.' || !isDigit(l.peekRune()) {
+		return INT, l.input[start:l.pos]
+	}
+	l.advance() // skip .
+	for l.pos < len(l.input) && isDigit(l.current) {
+		l.advance()
+	}
+	return FLOAT, l.input[start:l.pos]
 }
 
 // readBashContent reads raw content inside a bash { } block,
@@ -183,6 +271,70 @@ func (l *Lexer) readBashContent() string {
 	return strings.TrimSpace(l.input[start:l.pos])
 }
 
+// readCommand reads the text of a $ command literal: the rest of the
+// line, stopping early at a } that closes an enclosing block or at a |>
+// piping the command into another stage. A { left
+// open at the end of the line starts a block, as in `if $ cmd {`. Quoted
+// text is kept as is, and a # after whitespace starts a comment.
+func (l *Lexer) readCommand() string {
+	input := l.input
+	depth, open := 0, -1
+	end, stop := len(input), len(input)
+scan:
+	for i := l.pos; i < len(input); i++ {
+		switch input[i] {
+		case '\n':
+			end, stop = i, i
+			break scan
+		case '{':
+			if depth == 0 {
+				open = i
+			}
+			depth++
+		case '}':
+			if depth == 0 {
+				end, stop = i, i
+				break scan
+			}
+			depth--
+		case '|':
+			if depth == 0 && i+1 < len(input) && input[i+1] == '>' {
+				end, stop = i, i
+				break scan
+			}
+		case '"', '\'':
+			quote := input[i]
+			for i++; i < len(input) && input[i] != quote && input[i] != '\n'; i++ {
+				if input[i] == '\\' && quote == '"' {
+					i++
+				}
+			}
+			if i >= len(input) || input[i] == '\n' {
+				i--
+			}
+		case '#':
+			if i == l.pos || input[i-1] == ' ' || input[i-1] == '\t' {
+				end = i
+				stop = strings.IndexByte(input[i:], '\n')
+				if stop < 0 {
+					stop = len(input)
+				} else {
+					stop += i
+				}
+				break scan
+			}
+		}
+	}
+	if depth > 0 && strings.TrimSpace(input[open+1:end]) == "" {
+		end, stop = open, open
+	}
+	text := strings.TrimSpace(input[l.pos:end])
+	for l.pos < stop {
+		l.advance()
+	}
+	return text
+}
+
 func isLetter(ch rune) bool {
 	return unicode.IsLetter(ch)
 }
@@ -214,6 +366,7 @@ func (l *Lexer) Tokenize() []Token {
 		}
 
 		line, col := l.line, l.col
+		first := len(tokens)
 
 		switch {
 		case l.current == '=':
@@ -318,9 +471,27 @@ func (l *Lexer) Tokenize() []Token {
 			tokens = append(tokens, l.token(PIPE, "|>", line, col))
 			l.advance()
 			l.advance()
+		case l.current == '$' && (l.peekByte() == ' ' || l.peekByte() == '\t'):
+			l.advance() // skip $
+			tokens = append(tokens, l.token(COMMAND, l.readCommand(), line, col))
 		case l.current == '.':
 			tokens = append(tokens, l.token(DOT, ".", line, col))
 			l.advance()
+		case strings.HasPrefix(l.input[l.pos:], `"""`), strings.HasPrefix(l.input[l.pos:], `r"""`):
+			raw := l.current == 'r'
+			if raw {
+				l.advance() // skip r
+			}
+			text, ok := l.readText(raw)
+			typ := MULTILINE_STRING
+			if raw {
+				typ = RAW_STRING
+			}
+			if ok {
+				tokens = append(tokens, l.token(typ, text, line, col))
+			} else {
+				tokens = append(tokens, l.token(ILLEGAL, "unterminated string", line, col))
+			}
 		case l.current == '"':
 			str, ok := l.readString()
 			if ok {
@@ -329,7 +500,8 @@ func (l *Lexer) Tokenize() []Token {
 				tokens = append(tokens, l.token(ILLEGAL, "unterminated string", line, col))
 			}
 		case isDigit(l.current):
-			tokens = append(tokens, l.token(INT, l.readNumber(), line, col))
+			typ, num := l.readNumber()
+			tokens = append(tokens, l.token(typ, num, line, col))
 		case l.current == '_' && !isAlphanumeric(l.peekRune()):
 			tokens = append(tokens, l.token(UNDERSCORE, "_", line, col))
 			l.advance()
@@ -339,6 +511,7 @@ func (l *Lexer) Tokenize() []Token {
 				tokens = append(tokens, l.token(kwType, word, line, col))
 				// After BASH keyword, capture raw content between { }
 				if kwType == BASH {
+					tokens[len(tokens)-1].EndLine, tokens[len(tokens)-1].EndCol = l.line, l.col
 					l.skipWhitespace()
 					if l.pos < len(l.input) && l.current == '{' {
 						l.advance() // skip opening {
@@ -354,8 +527,17 @@ func (l *Lexer) Tokenize() []Token {
 			tokens = append(tokens, l.token(ILLEGAL, string(l.current), line, col))
 			l.advance()
 		}
+
+		// Tokens end where the lexer stopped, unless already set above
+		for i := first; i < len(tokens); i++ {
+			if tokens[i].EndLine == 0 {
+				tokens[i].EndLine, tokens[i].EndCol = l.line, l.col
+			}
+		}
 	}
 
-	tokens = append(tokens, l.token(EOF, "", l.line, l.col))
+	eof := l.token(EOF, "", l.line, l.col)
+	eof.EndLine, eof.EndCol = l.line, l.col
+	tokens = append(tokens, eof)
 	return tokens
 }
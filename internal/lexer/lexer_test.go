@@ -35,6 +35,23 @@ func TestIntegerLiteral(t *testing.T) {
 	})
 }
 
+func TestFloatLiteral(t *testing.T) {
+	assertTokens(t, `ratio = 0.75`, []Token{
+		{Type: IDENT, Value: "ratio"},
+		{Type: ASSIGN, Value: "="},
+		{Type: FLOAT, Value: "0.75"},
+	})
+}
+
+func TestNumberFollowedByDot(t *testing.T) {
+	// A dot not followed by a digit is not part of the number
+	assertTokens(t, `1.x`, []Token{
+		{Type: INT, Value: "1"},
+		{Type: DOT, Value: "."},
+		{Type: IDENT, Value: "x"},
+	})
+}
+
 func TestKeywords(t *testing.T) {
 	assertTokens(t, `if true { return }`, []Token{
 		{Type: IF, Value: "if"},
@@ -375,6 +392,23 @@ func TestTokenPositions(t *testing.T) {
 	assert.Equal(t, 1, tokens[3].Col, "y col")
 }
 
+func TestTokenEndPositions(t *testing.T) {
+	tokens := New("name = \"a\\\"b\"\nbash { ls }").Tokenize()
+
+	// name spans cols 1-4, so it ends at col 5
+	assert.Equal(t, 1, tokens[0].EndLine, "name end line")
+	assert.Equal(t, 5, tokens[0].EndCol, "name end col")
+
+	// The string ends after its closing quote, escapes included
+	assert.Equal(t, STRING, tokens[2].Type)
+	assert.Equal(t, 14, tokens[2].EndCol, "string end col")
+
+	// The bash keyword ends before its raw content
+	assert.Equal(t, BASH, tokens[3].Type)
+	assert.Equal(t, 2, tokens[3].EndLine, "bash end line")
+	assert.Equal(t, 5, tokens[3].EndCol, "bash end col")
+}
+
 func TestUnterminatedString(t *testing.T) {
 	tokens := New(`x = "unterminated`).Tokenize()
 	// Should produce: IDENT, ASSIGN, ILLEGAL, EOF
@@ -386,6 +420,66 @@ func TestUnterminatedString(t *testing.T) {
 	assert.Equal(t, EOF, tokens[len(tokens)-1].Type)
 }
 
+func TestTripleQuotedString(t *testing.T) {
+	input := "x = \"\"\"\n    server {\n        listen {port};\n    }\n    \"\"\""
+	assertTokens(t, input, []Token{
+		{Type: IDENT, Value: "x"},
+		{Type: ASSIGN, Value: "="},
+		{Type: MULTILINE_STRING, Value: "server {\n    listen {port};\n}"},
+	})
+}
+
+func TestTripleQuotedEscapes(t *testing.T) {
+	assertTokens(t, `"""say \"hi\"\tnow \d"""`, []Token{
+		{Type: MULTILINE_STRING, Value: "say \"hi\"\tnow \\d"},
+	})
+}
+
+func TestTripleQuotedKeepsTrailingContent(t *testing.T) {
+	// The closing quotes on a content line don't drop that line
+	assertTokens(t, "\"\"\"\n  a\n  b\"\"\"", []Token{
+		{Type: MULTILINE_STRING, Value: "a\nb"},
+	})
+}
+
+func TestTripleQuotedBlankLinesDoNotSetMargin(t *testing.T) {
+	assertTokens(t, "\"\"\"\n    a\n\n      b\n    \"\"\"", []Token{
+		{Type: MULTILINE_STRING, Value: "a\n\n  b"},
+	})
+}
+
+func TestRawString(t *testing.T) {
+	assertTokens(t, "r\"\"\"\n  \\d+ {name} \"q\"\n  \"\"\"", []Token{
+		{Type: RAW_STRING, Value: `\d+ {name} "q"`},
+	})
+}
+
+func TestIdentifierStartingWithR(t *testing.T) {
+	assertTokens(t, `r = "x"`, []Token{
+		{Type: IDENT, Value: "r"},
+		{Type: ASSIGN, Value: "="},
+		{Type: STRING, Value: "x"},
+	})
+}
+
+func TestTripleQuotedEndPosition(t *testing.T) {
+	tokens := New("x = \"\"\"\n  a\n  \"\"\"\ny = 1").Tokenize()
+
+	assert.Equal(t, MULTILINE_STRING, tokens[2].Type)
+	assert.Equal(t, 1, tokens[2].Line)
+	assert.Equal(t, 3, tokens[2].EndLine)
+	assert.Equal(t, 6, tokens[2].EndCol)
+	assert.Equal(t, 4, tokens[3].Line)
+}
+
+func TestUnterminatedTripleQuotedString(t *testing.T) {
+	tokens := New("x = \"\"\"\nabc").Tokenize()
+
+	require.Len(t, tokens, 4)
+	assert.Equal(t, ILLEGAL, tokens[2].Type)
+	assert.Equal(t, "unterminated string", tokens[2].Value)
+}
+
 func TestUnknownCharacter(t *testing.T) {
 	tokens := New(`x = @`).Tokenize()
 	// Should produce: IDENT, ASSIGN, ILLEGAL("@"), EOF
@@ -492,3 +586,31 @@ func TestBashKeyword(t *testing.T) {
 	assert.Equal(t, BASH, tokens[0].Type)
 	assert.Equal(t, EOF, tokens[1].Type)
 }
+
+func TestCommandLiteral(t *testing.T) {
+	tokens := New("$ git tag -a {tag} -m \"a } b\" # note\nx = 1").Tokenize()
+	assert.Equal(t, COMMAND, tokens[0].Type)
+	assert.Equal(t, `git tag -a {tag} -m "a } b"`, tokens[0].Value)
+	assert.Equal(t, IDENT, tokens[1].Type)
+}
+
+func TestCommandLiteralEndsAtClosingBrace(t *testing.T) {
+	tokens := New("if x { $ echo {a}#b }").Tokenize()
+	assert.Equal(t, COMMAND, tokens[3].Type)
+	assert.Equal(t, "echo {a}#b", tokens[3].Value)
+	assert.Equal(t, RBRACE, tokens[4].Type)
+}
+
+func TestCommandLiteralLeavesBlockBrace(t *testing.T) {
+	tokens := New("if $ git diff --quiet {f} {\n\tprint(f)\n}").Tokenize()
+	assert.Equal(t, COMMAND, tokens[1].Type)
+	assert.Equal(t, "git diff --quiet {f}", tokens[1].Value)
+	assert.Equal(t, LBRACE, tokens[2].Type)
+}
+
+func TestCommandLiteralEndsAtPipe(t *testing.T) {
+	tokens := New(`$ journalctl -u "a |> b" |> lines()`).Tokenize()
+	assert.Equal(t, COMMAND, tokens[0].Type)
+	assert.Equal(t, `journalctl -u "a |> b"`, tokens[0].Value)
+	assert.Equal(t, PIPE, tokens[1].Type)
+}
@@ -7,8 +7,12 @@ const (
 	// Literals
 	IDENT  TokenType = "IDENT"
 	INT    TokenType = "INT"
+	FLOAT  TokenType = "FLOAT"
 	STRING TokenType = "STRING"
 
+	MULTILINE_STRING TokenType = "MULTILINE_STRING" // """..."""
+	RAW_STRING       TokenType = "RAW_STRING"       // r"""...""", no escapes or interpolation
+
 	// Operators & punctuation
 	ASSIGN       TokenType = "ASSIGN"       // =
 	PLUS_ASSIGN  TokenType = "PLUS_ASSIGN"  // +=
@@ -58,8 +62,13 @@ const (
 	WHILE    TokenType = "WHILE"
 	BASH     TokenType = "BASH"
 	IMPORT   TokenType = "IMPORT"
+	TRY      TokenType = "TRY"
+	CATCH    TokenType = "CATCH"
+	FINALLY  TokenType = "FINALLY"
+	DEFER    TokenType = "DEFER"
 
 	BASH_CONTENT TokenType = "BASH_CONTENT"
+	COMMAND      TokenType = "COMMAND" // $ cmd args..., to the end of the line
 
 	EOF     TokenType = "EOF"
 	ILLEGAL TokenType = "ILLEGAL"
@@ -82,6 +91,10 @@ var keywords = map[string]TokenType{
 	"while":    WHILE,
 	"bash":     BASH,
 	"import":   IMPORT,
+	"try":      TRY,
+	"catch":    CATCH,
+	"finally":  FINALLY,
+	"defer":    DEFER,
 }
 
 // KeywordNames returns all keyword strings.
@@ -94,9 +107,13 @@ func KeywordNames() []string {
 }
 
 // Token represents a single lexical token with position information.
+// Line/Col mark the first character; EndLine/EndCol mark the position
+// just past the last character.
 type Token struct {
-	Type  TokenType
-	Value string
-	Line  int
-	Col   int
+	Type    TokenType
+	Value   string
+	Line    int
+	Col     int
+	EndLine int
+	EndCol  int
 }
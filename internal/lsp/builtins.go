@@ -4,10 +4,19 @@ package lsp
 var builtinDocs = map[string]string{
 	// I/O
 	"print":  "```\nprint(args...)\n```\nPrint values to stdout.\n\nTranspiles to `echo`.",
-	"write":  "```\nwrite(path, content)\n```\nWrite content to a file.\n\nTranspiles to `echo content > path`.",
-	"append": "```\nappend(path, content)\n```\nAppend content to a file.\n\nTranspiles to `echo content >> path`.",
+	"write":  "```\nwrite(path, content)\n```\nWrite content to a file.\n\nTranspiles to `printf '%s\\n' content > path`, or a quoted heredoc for raw strings.",
+	"append": "```\nappend(path, content)\n```\nAppend content to a file.\n\nTranspiles to `printf '%s\\n' content >> path`, or a quoted heredoc for raw strings.",
 	"read":   "```\nread(path) -> string\n```\nRead file contents.\n\nTranspiles to `$(cat path)`.",
 
+	// Templates
+	"render":    "```\nrender(template, vars: map) -> string\n```\nFill in a template file: `{name}` substitutes a variable, `{for item in list}` and `{if name}`/`{else}` blocks close with `{end}`. Values are never evaluated by the shell. A variable missing from `vars` is an error, found at compile time when the path is a literal.\n\nTranspiles to `$(_render template ...)`.",
+	"render_to": "```\nrender_to(template, dest, vars: map)\n```\nFill in a template file and write it to `dest`. Nothing is written when rendering fails.\n\nTranspiles to `_render_to template dest ...`.",
+
+	// Settings files
+	"load_env":  "```\nload_env(path, override: false)\n```\nExport the `KEY=value` lines of a dotenv file without running it. Quotes, comments and `export` prefixes are understood; nothing is expanded. Variables already set are kept unless `override` is true.\n\nTranspiles to `_load_env path override`.",
+	"read_ini":  "```\nread_ini(path) -> map\n```\nRead an INI file into a map keyed `section.key`.\n\nTranspiles to `_read_ini path name`.",
+	"write_env": "```\nwrite_env(path, map)\n```\nWrite a map as a dotenv file, sorted by key, quoting values so they read back unchanged.\n\nTranspiles to `_write_env path map`.",
+
 	// File operations
 	"rm":    "```\nrm(path)\n```\nRemove a file.\n\nTranspiles to `rm -f path`.",
 	"rmdir": "```\nrmdir(path)\n```\nRemove a directory recursively.\n\nTranspiles to `rm -rf path`.",
@@ -17,18 +26,38 @@ var builtinDocs = map[string]string{
 	"chmod": "```\nchmod(path, mode)\n```\nChange file permissions.\n\nTranspiles to `chmod mode path`.",
 	"glob":  "```\nglob(pattern) -> list\n```\nExpand a glob pattern.\n\nTranspiles to `(pattern)`.",
 
+	// Temp files
+	"tempfile": "```\ntempfile(suffix:) -> string\n```\nCreate an empty temp file and return its path. It is removed when the script exits, fails or is interrupted; `with f = tempfile() { ... }` removes it when the block ends.\n\nTranspiles to `$(_temp_new tempfile suffix)`.",
+	"tempdir":  "```\ntempdir() -> string\n```\nCreate a temp directory and return its path. It is removed with everything in it when the script exits, fails or is interrupted; `with d = tempdir() { ... }` removes it when the block ends.\n\nTranspiles to `$(_temp_new tempdir \"\")`.",
+
+	// Archives
+	"archive":      "```\narchive(dest, sources, format:, exclude:)\n```\nPack files and directories into a tar.gz, tar.zst, tar or zip archive. The format follows from the name of `dest` unless `format:` is given; `exclude:` patterns match any part of a path.\n\nTranspiles to `_archive dest format excludes sources...`.",
+	"extract":      "```\nextract(archive, dest, strip:)\n```\nUnpack an archive into `dest`, creating it. `strip: n` drops the first n path components.\n\nTranspiles to `_extract archive dest strip`.",
+	"list_archive": "```\nlist_archive(path) -> list\n```\nThe entries of an archive, one per element. Assign the result or loop over it.\n\nTranspiles to `mapfile -t name < <(_list_archive path)`.",
+
 	// File checks
 	"exists":  "```\nexists(path) -> bool\n```\nCheck if a path exists.\n\nTranspiles to `[ -e path ]`.",
 	"is_file": "```\nis_file(path) -> bool\n```\nCheck if path is a regular file.\n\nTranspiles to `[ -f path ]`.",
 	"is_dir":  "```\nis_dir(path) -> bool\n```\nCheck if path is a directory.\n\nTranspiles to `[ -d path ]`.",
 
 	// Execution
-	"exec": "```\nexec(command) -> string\n```\nExecute a shell command and capture output.\n\nTranspiles to `$(command)`.",
+	"exec": "```\nexec(command) -> string\n```\nExecute a shell command and capture output. As a statement, the command runs directly.\n\nTranspiles to `$(command)`.",
+	"run":  "```\nrun(command, args...) -> map\n```\nRun a command with each argument passed as exactly one word, never re-parsed by the shell. `$ cmd args` is shorthand. Assigned, the result has `stdout`, `stderr`, `code` and `ok`.\n\nTranspiles to `command \"arg\"...`.",
+	"cmd":  "```\ncmd(command, args...) -> string\n```\nA command as a pipeline stage: `x |> cmd(\"grep\", \"ERROR\")` writes `x` to its stdin. Consecutive commands stream through one shell pipeline. As a value, gives the command's output.\n\nTranspiles to `... | command \"arg\"...`.",
 	"exit": "```\nexit(code)\n```\nExit the script with a status code.\n\nTranspiles to `exit code`.",
 
 	// Environment
 	"env": "```\nenv(name) -> string\n```\nGet an environment variable.\n\nTranspiles to `\"${NAME}\"`.",
 
+	// Prompts
+	"prompt":  "```\nprompt(msg, default:) -> string\n```\nAsk for a line of input on the terminal. Without a terminal, or with `--yes`, gives the default, and stops the script when there is none.\n\nTranspiles to `read -rp`.",
+	"confirm": "```\nconfirm(msg, default: false) -> bool\n```\nAsk a yes/no question on the terminal. Without a terminal the answer is no, unless the script was run with `--yes` or `LANGZ_YES=1`.\n\nTranspiles to `read -rp`.",
+	"choose":  "```\nchoose(msg, options, default:) -> string\n```\nShow a numbered list of options and return the one picked. Without a terminal, or with `--yes`, gives the default.\n\nTranspiles to `read -rp`.",
+	"secret":  "```\nsecret(msg, default:) -> string\n```\nAsk for input without echoing it, e.g. a password. Without a terminal, or with `--yes`, gives the default.\n\nTranspiles to `read -rsp`.",
+
+	// Logging
+	"log": "```\nlog.debug(msg, key: value...)\nlog.info(msg, key: value...)\nlog.warn(msg, key: value...)\nlog.error(msg, key: value...)\n```\nWrite a timestamped line to stderr, with keyword arguments as `key=value` fields. `LOG_LEVEL` sets the lowest level written, `LOG_FORMAT=json` writes JSON lines, and the level is colored when stderr is a terminal.\n\nTranspiles to `_log level \"msg\" key \"value\"...`.",
+
 	// System info
 	"os":       "```\nos() -> string\n```\nGet OS name (lowercase).\n\nTranspiles to `$(uname -s | tr '[:upper:]' '[:lower:]')`.",
 	"arch":     "```\narch() -> string\n```\nGet CPU architecture.\n\nTranspiles to `$(uname -m)`.",
@@ -43,11 +72,55 @@ var builtinDocs = map[string]string{
 	"upper": "```\nupper(str) -> string\n```\nConvert string to uppercase.\n\nTranspiles to `$(echo str | tr '[:lower:]' '[:upper:]')`.",
 	"lower": "```\nlower(str) -> string\n```\nConvert string to lowercase.\n\nTranspiles to `$(echo str | tr '[:upper:]' '[:lower:]')`.",
 	"trim": "```\ntrim(str) -> string\n```\nTrim leading/trailing whitespace.\n\nTranspiles to `$(echo str | xargs)`.",
-	"len": "```\nlen(list) -> int\n```\nGet the length of a list.\n\nTranspiles to `${#list[@]}`.",
+	"lines": "```\nlines(text) -> list\n```\nSplit text or a pipeline's output into lines. Assign the result or loop over it; a loop reads the pipeline while it runs.\n\nTranspiles to `mapfile -t`.",
+	"len": "```\nlen(list) -> int\n```\nGet the length of a list, map or JSON value.\n\nTranspiles to `${#list[@]}`.",
+
+	// Maps
+	"keys":   "```\nkeys(map) -> list\n```\nGet the keys of a map, in no particular order.\n\nTranspiles to `(\"${!map[@]}\")`.",
+	"values": "```\nvalues(map) -> list\n```\nGet the values of a map.\n\nTranspiles to `(\"${map[@]}\")`.",
+	"has":    "```\nhas(map, key) -> bool\n```\nCheck if a map contains a key.\n\nTranspiles to `[[ -v map[key] ]]`.",
+	"delete": "```\ndelete(map, key)\n```\nRemove a key from a map.\n\nTranspiles to `_key=key; unset 'map[$_key]'`.",
+
+	// Numbers
+	"round": "```\nround(x, digits) -> int\n```\nRound half away from zero. With `digits`, round to that many decimal places and return a float.\n\nTranspiles to an `awk` one-liner.",
+	"floor": "```\nfloor(x) -> int\n```\nRound down to the nearest integer.\n\nTranspiles to an `awk` one-liner.",
+	"ceil":  "```\nceil(x) -> int\n```\nRound up to the nearest integer.\n\nTranspiles to an `awk` one-liner.",
+
+	// Regex
+	"matches":       "```\nmatches(str, regex) -> bool\n```\nCheck if a string matches a POSIX extended regex.\n\nTranspiles to `[[ str =~ $regex ]]`.",
+	"regex_find":    "```\nregex_find(str, regex) -> list\n```\nCapture groups of the first match, or an empty list.\n\nTranspiles to `(\"${BASH_REMATCH[@]:1}\")`.",
+	"replace_regex": "```\nreplace_regex(str, regex, repl) -> string\n```\nReplace every match of `regex`. `\\1` in `repl` refers to a capture group.\n\nTranspiles to `sed -E \"s/regex/repl/g\"`.",
 
 	// Networking
 	"fetch": "```\nfetch(url, method:, body:, headers:, timeout:, retries:) -> string\n```\nHTTP request via curl. Sets convention variables:\n- `_status` — HTTP status code\n- `_body` — response body\n- `_headers` — response headers\n\nSupports `or` fallback: `data = fetch(url) or \"default\"`\n\nTranspiles to multi-line `curl` with tmpfile handling.",
 	"json_get": "```\njson_get(data, path) -> string\n```\nExtract a value from JSON using a jq path.\n\nRequires `jq`. Transpiles to `$(echo data | jq -r path)`.",
+	"parse_json": "```\nparse_json(text) -> json\n```\nParse text into a JSON value, readable with `data.items[0].name`, `for` and `len()`.\n\nRequires `jq`. Transpiles to `$(jq -c . <<< text)`.",
+	"to_json": "```\nto_json(value) -> json\n```\nSerialize any value, including lists and maps, as compact JSON text.\n\nRequires `jq`.",
+	"json_set": "```\njson_set(doc, path, value) -> json\n```\nReturn `doc` with the value at the jq path `path` set, creating objects on the way. Lists, maps, numbers and booleans are inserted as JSON.\n\nRequires `jq`. Transpiles to `$(jq -c '(path) = $v' <<< doc)`.",
+	"json_delete": "```\njson_delete(doc, path) -> json\n```\nReturn `doc` without the value at the jq path `path`.\n\nRequires `jq`. Transpiles to `$(jq -c 'del(path)' <<< doc)`.",
+	"json_keys": "```\njson_keys(doc) -> list\n```\nThe keys of a JSON object in document order, or the indices of an array.\n\nRequires `jq`. Transpiles to `jq -r 'keys_unsorted[]'`.",
+	"json_len": "```\njson_len(doc) -> int\n```\nThe number of keys or elements in a JSON document.\n\nRequires `jq`. Transpiles to `$(jq -c length <<< doc)`.",
+	"json_merge": "```\njson_merge(doc, other) -> json\n```\nMerge two JSON objects recursively; values in `other` win.\n\nRequires `jq`. Transpiles to `$(jq -c '. * $other' <<< doc)`.",
+	"json_valid": "```\njson_valid(text) -> bool\n```\nCheck that text is exactly one valid JSON value.\n\nRequires `jq`.",
+
+	// Hashing and encoding
+	"sha256":          "```\nsha256(path_or_string) -> string\n```\nSHA-256 of a file, or of the string itself when it doesn't name one, as lowercase hex.\n\nUses `sha256sum`, `shasum -a 256` or `openssl dgst`, whichever is installed.",
+	"sha1":            "```\nsha1(path_or_string) -> string\n```\nSHA-1 of a file, or of the string itself when it doesn't name one, as lowercase hex.\n\nUses `sha1sum`, `shasum -a 1` or `openssl dgst`, whichever is installed.",
+	"md5":             "```\nmd5(path_or_string) -> string\n```\nMD5 of a file, or of the string itself when it doesn't name one, as lowercase hex.\n\nUses `md5sum`, `md5` or `openssl dgst`, whichever is installed.",
+	"verify_checksum": "```\nverify_checksum(path, expected) -> bool\n```\nCheck a file against a sha256, sha1 or md5 checksum, picked by length or a `sha256:` prefix. A line of `sha256sum` output works too.\n\nTranspiles to `_verify_checksum path expected`.",
+	"base64_encode":   "```\nbase64_encode(text) -> string\n```\nBase64 of text, on one line.\n\nUses `base64` or `openssl base64`.",
+	"base64_decode":   "```\nbase64_decode(text) -> string\n```\nDecode base64 text. Invalid input stops the script.\n\nUses `base64 -d` or `openssl base64 -d`.",
+	"hex_encode":      "```\nhex_encode(text) -> string\n```\nLowercase hex of the bytes of text.\n\nDone in Bash; needs no tools.",
+	"url_encode":      "```\nurl_encode(text) -> string\n```\nPercent-encode everything but `A-Z a-z 0-9 - . _ ~`.\n\nDone in Bash; needs no tools.",
+	"url_decode":      "```\nurl_decode(text) -> string\n```\nTurn `%XX` escapes back into bytes. `+` is left as is.\n\nDone in Bash; needs no tools.",
+
+	// Jobs
+	"spawn":      "```\nspawn(cmd, log:) -> int\n```\nStart a command in the background and return its job handle (pid). Running jobs are killed when the script exits.\n\nTranspiles to `{ cmd; } > log 2>&1 &`.",
+	"wait":       "```\nwait(job) -> int\n```\nWait for a job to finish. Assigned, gives its exit code.\n\nTranspiles to `wait \"$job\"`.",
+	"wait_all":   "```\nwait_all()\n```\nWait for every background job.\n\nTranspiles to `wait`.",
+	"kill":       "```\nkill(job, signal:)\n```\nSend a signal (default `TERM`) to a job. A job that already exited is ignored.\n\nTranspiles to `kill -s signal -- -\"$job\"`.",
+	"is_running": "```\nis_running(job) -> bool\n```\nCheck if a job is still running.\n\nTranspiles to `kill -0 \"$job\"`.",
+	"pid":        "```\npid() -> int\n```\nPID of the running script.\n\nTranspiles to `$$`.",
 
 	// Date/time
 	"timestamp": "```\ntimestamp() -> string\n```\nGet current Unix timestamp.\n\nTranspiles to `$(date +%s)`.",
@@ -69,6 +142,7 @@ var methodDocs = map[string]string{
 	"split":       "```\nstr.split(sep) -> list\n```\nSplit string into array by separator.\n\nTranspiles to `IFS='sep' read -ra arr <<< \"$str\"`.",
 	"join":        "```\nlist.join(sep) -> string\n```\nJoin array elements with separator.\n\nTranspiles to `$(IFS='sep'; echo \"${list[*]}\")`.",
 	"length":      "```\nstr.length() -> int\n```\nGet string length.\n\nTranspiles to `${#str}`.",
+	"matches":     "```\nstr.matches(regex) -> bool\n```\nCheck if string matches a POSIX extended regex.\n\nTranspiles to `[[ \"$str\" =~ $regex ]]`.",
 }
 
 // kwargDoc describes a single keyword argument for a builtin function.
@@ -86,4 +160,41 @@ var builtinKwargs = map[string][]kwargDoc{
 		{Name: "timeout", Desc: "Max seconds to wait for response"},
 		{Name: "retries", Desc: "Number of retry attempts on failure"},
 	},
+	"render": {
+		{Name: "vars", Desc: "Template variables as a map, e.g. `{name: \"web\", hosts: hosts}`; lists loop one element per line"},
+	},
+	"render_to": {
+		{Name: "vars", Desc: "Template variables as a map, e.g. `{name: \"web\", hosts: hosts}`; lists loop one element per line"},
+	},
+	"archive": {
+		{Name: "format", Desc: "`\"tar.gz\"`, `\"tar.zst\"`, `\"tar\"` or `\"zip\"`; inferred from the name of `dest` by default"},
+		{Name: "exclude", Desc: "Patterns to leave out, e.g. `[\"*.log\", \".git\"]`"},
+	},
+	"tempfile": {
+		{Name: "suffix", Desc: "End of the file name, e.g. `\".json\"` for tools that go by extension"},
+	},
+	"extract": {
+		{Name: "strip", Desc: "Number of leading path components to drop (default `0`)"},
+	},
+	"load_env": {
+		{Name: "override", Desc: "Replace variables that are already set (default `false`)"},
+	},
+	"prompt": {
+		{Name: "default", Desc: "Answer for an empty reply, and when no one can be asked"},
+	},
+	"confirm": {
+		{Name: "default", Desc: "Answer for an empty reply (default `false`)"},
+	},
+	"choose": {
+		{Name: "default", Desc: "Option for an empty reply, and when no one can be asked"},
+	},
+	"secret": {
+		{Name: "default", Desc: "Value when no one can be asked, e.g. `env(\"TOKEN\")`"},
+	},
+	"spawn": {
+		{Name: "log", Desc: "File to write the job's stdout and stderr to"},
+	},
+	"kill": {
+		{Name: "signal", Desc: "Signal name or number, e.g. `\"KILL\"` (default `TERM`)"},
+	},
 }
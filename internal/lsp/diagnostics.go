@@ -1,17 +1,24 @@
 package lsp
 
 import (
+	"net/url"
+	"path/filepath"
+
 	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 
+	"github.com/tasnimzotder/langz/internal/ast"
+	"github.com/tasnimzotder/langz/internal/codegen"
 	"github.com/tasnimzotder/langz/internal/lexer"
+	"github.com/tasnimzotder/langz/internal/modules"
 	"github.com/tasnimzotder/langz/internal/parser"
+	"github.com/tasnimzotder/langz/internal/sema"
 )
 
 // publishDiagnostics uses cached tokens to parse and send diagnostics to the client.
 func (s *Server) publishDiagnostics(ctx *glsp.Context, uri protocol.DocumentUri, _ string) {
 	tokens := s.getTokens(uri)
-	diags := getDiagnosticsFromTokens(tokens)
+	diags := getDiagnosticsFromTokens(tokens, uriToPath(uri))
 	ctx.Notify(protocol.ServerTextDocumentPublishDiagnostics, &protocol.PublishDiagnosticsParams{
 		URI:         uri,
 		Diagnostics: diags,
@@ -21,12 +28,16 @@ func (s *Server) publishDiagnostics(ctx *glsp.Context, uri protocol.DocumentUri,
 // getDiagnostics lexes + parses source, returns LSP diagnostics for all errors.
 func getDiagnostics(source string) []protocol.Diagnostic {
 	tokens := lexer.New(source).Tokenize()
-	return getDiagnosticsFromTokens(tokens)
+	return getDiagnosticsFromTokens(tokens, "")
 }
 
 // getDiagnosticsFromTokens parses pre-tokenized input and returns LSP diagnostics.
-func getDiagnosticsFromTokens(tokens []lexer.Token) []protocol.Diagnostic {
-	_, errs := parser.New(tokens).ParseAllErrors()
+// Like the CLI, each stage only runs once the previous one is clean: parse
+// errors, then import errors, then semantic errors, then codegen errors.
+// Imports are only resolved when the document is a file, at path; errors in
+// imported files are left to those files' own diagnostics.
+func getDiagnosticsFromTokens(tokens []lexer.Token, path string) []protocol.Diagnostic {
+	prog, errs := parser.NewFile(tokens, path).ParseAllErrors()
 
 	diags := make([]protocol.Diagnostic, 0, len(errs))
 	severity := protocol.DiagnosticSeverityError
@@ -53,5 +64,115 @@ func getDiagnosticsFromTokens(tokens []lexer.Token) []protocol.Diagnostic {
 			Message:  e.Message,
 		})
 	}
+
+	if len(errs) > 0 {
+		return diags
+	}
+
+	if path != "" {
+		if importDiags := resolveImports(prog, path); len(importDiags) > 0 {
+			return importDiags
+		}
+	}
+
+	semaErrs := sema.Check(prog)
+	for _, e := range semaErrs {
+		if !inFile(e.Span, path) {
+			continue
+		}
+		diags = append(diags, protocol.Diagnostic{
+			Range:    spanToRange(e.Span),
+			Severity: &severity,
+			Source:   &sourceName,
+			Message:  e.Message,
+		})
+	}
+	if len(semaErrs) > 0 {
+		return diags
+	}
+
+	_, genErrs := codegen.Generate(prog)
+	for _, e := range genErrs {
+		if !inFile(e.Span, path) {
+			continue
+		}
+		diags = append(diags, protocol.Diagnostic{
+			Range:    spanToRange(e.Span),
+			Severity: &severity,
+			Source:   &sourceName,
+			Message:  e.Message,
+		})
+	}
 	return diags
 }
+
+// resolveImports links the modules prog imports, the same way the CLI does,
+// returning diagnostics for any that fail. A failure inside an imported
+// file is reported on the first import, since the file may be imported
+// indirectly.
+func resolveImports(prog *ast.Program, path string) []protocol.Diagnostic {
+	var first *ast.ImportStmt
+	for _, stmt := range prog.Statements {
+		if imp, ok := stmt.(*ast.ImportStmt); ok {
+			first = imp
+			break
+		}
+	}
+	if first == nil {
+		return nil
+	}
+
+	severity := protocol.DiagnosticSeverityError
+	sourceName := "langz"
+	diag := func(span ast.Span, msg string) protocol.Diagnostic {
+		return protocol.Diagnostic{Range: spanToRange(span), Severity: &severity, Source: &sourceName, Message: msg}
+	}
+
+	resolver, err := modules.NewResolver(path)
+	if err != nil {
+		return []protocol.Diagnostic{diag(first.Span, err.Error())}
+	}
+	var diags []protocol.Diagnostic
+	for _, e := range modules.Resolve(prog, path, resolver) {
+		if e.File == path {
+			diags = append(diags, diag(ast.Span{Start: e.Pos, End: ast.Pos{Line: e.Pos.Line, Col: e.Pos.Col + 1}}, e.Message))
+		} else {
+			diags = append(diags, diag(first.Span, e.Error()))
+		}
+	}
+	return diags
+}
+
+// inFile reports whether span is in the document at path rather than in a
+// module it imports.
+func inFile(span ast.Span, path string) bool {
+	return span.File == "" || span.File == path
+}
+
+// uriToPath returns the file path of a file:// URI, or "" for other URIs.
+func uriToPath(uri protocol.DocumentUri) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+// spanToRange converts a 1-based AST span to a 0-based LSP range.
+func spanToRange(span ast.Span) protocol.Range {
+	return protocol.Range{
+		Start: posToPosition(span.Start),
+		End:   posToPosition(span.End),
+	}
+}
+
+func posToPosition(pos ast.Pos) protocol.Position {
+	var p protocol.Position
+	if pos.Line > 0 {
+		p.Line = protocol.UInteger(pos.Line - 1)
+	}
+	if pos.Col > 0 {
+		p.Character = protocol.UInteger(pos.Col - 1)
+	}
+	return p
+}
@@ -59,14 +59,26 @@ func FormatSource(source string, tabSize int, insertSpaces bool) string {
 	lines := strings.Split(source, "\n")
 	result := make([]string, len(lines))
 	level := 0
+	inText := false
 
 	for i, line := range lines {
+		if inText {
+			// Triple-quoted string content is kept byte for byte
+			result[i] = line
+			var code string
+			code, inText = scanLine(line, true)
+			if strings.HasSuffix(code, "{") {
+				level++
+			}
+			continue
+		}
 		trimmed := strings.TrimSpace(line)
 		if trimmed == "" {
 			result[i] = ""
 			continue
 		}
-		code := codePart(trimmed)
+		var code string
+		code, inText = scanLine(trimmed, false)
 		if strings.HasPrefix(code, "}") {
 			level--
 			if level < 0 {
@@ -85,14 +97,29 @@ func FormatSource(source string, tabSize int, insertSpaces bool) string {
 // codePart extracts the structural code from a line, stripping
 // string contents and trailing comments so brace detection is accurate.
 func codePart(line string) string {
+	code, _ := scanLine(line, false)
+	return code
+}
+
+// scanLine is codePart for a line that may start inside a triple-quoted
+// string (inText). It also reports whether the line ends inside one.
+func scanLine(line string, inText bool) (string, bool) {
 	var b strings.Builder
 	inString := false
 	for i := 0; i < len(line); i++ {
 		ch := line[i]
-		if inString && ch == '\\' && i+1 < len(line) {
+		if (inString || inText) && ch == '\\' && i+1 < len(line) {
 			i++ // skip escaped character
 			continue
 		}
+		if !inString && strings.HasPrefix(line[i:], `"""`) {
+			inText = !inText
+			i += 2
+			continue
+		}
+		if inText {
+			continue
+		}
 		if ch == '"' {
 			inString = !inString
 			continue
@@ -105,5 +132,5 @@ func codePart(line string) string {
 		}
 		b.WriteByte(ch)
 	}
-	return strings.TrimSpace(b.String())
+	return strings.TrimSpace(b.String()), inText
 }
@@ -76,3 +76,9 @@ func TestFormatEscapedQuoteInString(t *testing.T) {
 	expected := "x = \"hello \\\" world {\"\nif y > 1 {\n    print(x)\n}"
 	assert.Equal(t, expected, FormatSource(input, 4, true))
 }
+
+func TestFormatKeepsTripleQuotedContent(t *testing.T) {
+	input := "fn conf() {\nx = \"\"\"\n  server {\n      listen 80;\n  }\n  \"\"\"\nprint(x)\n}"
+	expected := "fn conf() {\n    x = \"\"\"\n  server {\n      listen 80;\n  }\n  \"\"\"\n    print(x)\n}"
+	assert.Equal(t, expected, FormatSource(input, 4, true))
+}
@@ -1,6 +1,8 @@
 package lsp
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -33,9 +35,26 @@ func TestGetDiagnosticsPositionConversion(t *testing.T) {
 	assert.Equal(t, protocol.UInteger(3), diags[0].Range.Start.Character)
 }
 
+func TestGetDiagnosticsSemanticError(t *testing.T) {
+	diags := getDiagnostics("x = 1\n  write(\"out.txt\")")
+	require.Len(t, diags, 1)
+	assert.Contains(t, diags[0].Message, "write()")
+	assert.Equal(t, protocol.UInteger(1), diags[0].Range.Start.Line)
+	assert.Equal(t, protocol.UInteger(2), diags[0].Range.Start.Character)
+	assert.Equal(t, protocol.UInteger(18), diags[0].Range.End.Character)
+}
+
+func TestGetDiagnosticsCodegenError(t *testing.T) {
+	diags := getDiagnostics("x = 1\nprint({a: 1})")
+	require.Len(t, diags, 1)
+	assert.Contains(t, diags[0].Message, "map literals")
+	assert.Equal(t, protocol.UInteger(1), diags[0].Range.Start.Line)
+}
+
 func TestGetDiagnosticsValidProgram(t *testing.T) {
 	source := `
 name = "hello"
+x = 20
 print(name)
 if x > 10 {
 	print("big")
@@ -45,6 +64,33 @@ if x > 10 {
 	assert.Empty(t, diags)
 }
 
+func TestGetDiagnosticsResolvesImports(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lib.lz"), []byte("fn greet(name: str) {\n\tprint(name)\n}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.lz"), []byte("fn (\n"), 0644))
+	path := filepath.Join(dir, "main.lz")
+
+	diags := getDiagnosticsFromTokens(lexer.New("import \"lib.lz\" as lib\nlib.greet(\"x\")").Tokenize(), path)
+	assert.Empty(t, diags)
+
+	diags = getDiagnosticsFromTokens(lexer.New("import \"lib.lz\" as lib\nlib.hello()").Tokenize(), path)
+	require.Len(t, diags, 1)
+	assert.Equal(t, "module lib has no function hello", diags[0].Message)
+	assert.Equal(t, protocol.UInteger(1), diags[0].Range.Start.Line)
+
+	// Errors in an imported file are reported on the import
+	diags = getDiagnosticsFromTokens(lexer.New("x = 1\nimport \"broken.lz\"").Tokenize(), path)
+	require.NotEmpty(t, diags)
+	assert.Contains(t, diags[0].Message, filepath.Join(dir, "broken.lz")+":1:4:")
+	assert.Equal(t, protocol.UInteger(1), diags[0].Range.Start.Line)
+}
+
+func TestURIToPath(t *testing.T) {
+	assert.Equal(t, "/home/me/deploy.lz", uriToPath("file:///home/me/deploy.lz"))
+	assert.Equal(t, "/tmp/a b.lz", uriToPath("file:///tmp/a%20b.lz"))
+	assert.Equal(t, "", uriToPath("untitled:Untitled-1"))
+}
+
 // --- Token lookup ---
 
 func TestFindTokenAtBuiltin(t *testing.T) {
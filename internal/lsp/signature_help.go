@@ -33,6 +33,35 @@ var builtinSignatures = map[string]protocol.SignatureInformation{
 			{Label: "path", Documentation: "jq path expression (e.g. \".name\")"},
 		},
 	},
+	"json_set": {
+		Label: "json_set(doc, path, value)",
+		Parameters: []protocol.ParameterInformation{
+			{Label: "doc", Documentation: "JSON document"},
+			{Label: "path", Documentation: "jq path to set (e.g. \".spec.replicas\")"},
+			{Label: "value", Documentation: "Value to insert as JSON"},
+		},
+	},
+	"json_delete": {
+		Label: "json_delete(doc, path)",
+		Parameters: []protocol.ParameterInformation{
+			{Label: "doc", Documentation: "JSON document"},
+			{Label: "path", Documentation: "jq path to delete (e.g. \".debug\")"},
+		},
+	},
+	"json_merge": {
+		Label: "json_merge(doc, other)",
+		Parameters: []protocol.ParameterInformation{
+			{Label: "doc", Documentation: "JSON object"},
+			{Label: "other", Documentation: "JSON object whose values win"},
+		},
+	},
+	"verify_checksum": {
+		Label: "verify_checksum(path, expected)",
+		Parameters: []protocol.ParameterInformation{
+			{Label: "path", Documentation: "File to check"},
+			{Label: "expected", Documentation: "Hex digest, e.g. \"sha256:9f86d0...\" or a line of sha256sum output"},
+		},
+	},
 	"print": {
 		Label: "print(args...)",
 		Parameters: []protocol.ParameterInformation{
@@ -58,6 +87,113 @@ var builtinSignatures = map[string]protocol.SignatureInformation{
 			{Label: "command", Documentation: "Shell command to execute"},
 		},
 	},
+	"run": {
+		Label: "run(command, args...)",
+		Parameters: []protocol.ParameterInformation{
+			{Label: "command", Documentation: "Command to run"},
+			{Label: "args...", Documentation: "Arguments, each passed as one word"},
+		},
+	},
+	"cmd": {
+		Label: "cmd(command, args...)",
+		Parameters: []protocol.ParameterInformation{
+			{Label: "command", Documentation: "Command to run"},
+			{Label: "args...", Documentation: "Arguments, each passed as one word"},
+		},
+	},
+	"lines": {
+		Label: "lines(text)",
+		Parameters: []protocol.ParameterInformation{
+			{Label: "text", Documentation: "Text or pipeline output to split into lines"},
+		},
+	},
+	"render": {
+		Label: "render(template, vars:)",
+		Parameters: []protocol.ParameterInformation{
+			{Label: "template", Documentation: "Template file path"},
+			{Label: "vars:", Documentation: "Map of template variables"},
+		},
+	},
+	"render_to": {
+		Label: "render_to(template, dest, vars:)",
+		Parameters: []protocol.ParameterInformation{
+			{Label: "template", Documentation: "Template file path"},
+			{Label: "dest", Documentation: "File to write"},
+			{Label: "vars:", Documentation: "Map of template variables"},
+		},
+	},
+	"archive": {
+		Label: "archive(dest, sources, format:, exclude:)",
+		Parameters: []protocol.ParameterInformation{
+			{Label: "dest", Documentation: "Archive to write, e.g. \"backup.tar.gz\""},
+			{Label: "sources", Documentation: "List of files and directories to pack"},
+			{Label: "format:", Documentation: "tar.gz, tar.zst, tar or zip"},
+			{Label: "exclude:", Documentation: "List of patterns to leave out"},
+		},
+	},
+	"extract": {
+		Label: "extract(archive, dest, strip:)",
+		Parameters: []protocol.ParameterInformation{
+			{Label: "archive", Documentation: "Archive to unpack"},
+			{Label: "dest", Documentation: "Directory to unpack into"},
+			{Label: "strip:", Documentation: "Leading path components to drop"},
+		},
+	},
+	"tempfile": {
+		Label: "tempfile(suffix:)",
+		Parameters: []protocol.ParameterInformation{
+			{Label: "suffix:", Documentation: "End of the file name, e.g. \".json\""},
+		},
+	},
+	"load_env": {
+		Label: "load_env(path, override:)",
+		Parameters: []protocol.ParameterInformation{
+			{Label: "path", Documentation: "Dotenv file to read"},
+			{Label: "override:", Documentation: "Replace variables that are already set"},
+		},
+	},
+	"read_ini": {
+		Label: "read_ini(path)",
+		Parameters: []protocol.ParameterInformation{
+			{Label: "path", Documentation: "INI file to read"},
+		},
+	},
+	"write_env": {
+		Label: "write_env(path, map)",
+		Parameters: []protocol.ParameterInformation{
+			{Label: "path", Documentation: "File to write"},
+			{Label: "map", Documentation: "Variables to write"},
+		},
+	},
+	"prompt": {
+		Label: "prompt(msg, default:)",
+		Parameters: []protocol.ParameterInformation{
+			{Label: "msg", Documentation: "Question to show"},
+			{Label: "default:", Documentation: "Answer for an empty reply or without a terminal"},
+		},
+	},
+	"confirm": {
+		Label: "confirm(msg, default:)",
+		Parameters: []protocol.ParameterInformation{
+			{Label: "msg", Documentation: "Yes/no question to show"},
+			{Label: "default:", Documentation: "Answer for an empty reply"},
+		},
+	},
+	"choose": {
+		Label: "choose(msg, options, default:)",
+		Parameters: []protocol.ParameterInformation{
+			{Label: "msg", Documentation: "Question to show"},
+			{Label: "options", Documentation: "List of options to pick from"},
+			{Label: "default:", Documentation: "Option for an empty reply or without a terminal"},
+		},
+	},
+	"secret": {
+		Label: "secret(msg, default:)",
+		Parameters: []protocol.ParameterInformation{
+			{Label: "msg", Documentation: "Question to show"},
+			{Label: "default:", Documentation: "Value without a terminal"},
+		},
+	},
 	"copy": {
 		Label: "copy(src, dst)",
 		Parameters: []protocol.ParameterInformation{
@@ -86,6 +222,49 @@ var builtinSignatures = map[string]protocol.SignatureInformation{
 			{Label: "owner", Documentation: "Owner (user or user:group)"},
 		},
 	},
+	"round": {
+		Label: "round(x, digits)",
+		Parameters: []protocol.ParameterInformation{
+			{Label: "x", Documentation: "Number to round"},
+			{Label: "digits", Documentation: "Decimal places to keep (optional)"},
+		},
+	},
+	"has": {
+		Label: "has(map, key)",
+		Parameters: []protocol.ParameterInformation{
+			{Label: "map", Documentation: "Map variable"},
+			{Label: "key", Documentation: "Key to look up"},
+		},
+	},
+	"delete": {
+		Label: "delete(map, key)",
+		Parameters: []protocol.ParameterInformation{
+			{Label: "map", Documentation: "Map variable"},
+			{Label: "key", Documentation: "Key to remove"},
+		},
+	},
+	"matches": {
+		Label: "matches(str, regex)",
+		Parameters: []protocol.ParameterInformation{
+			{Label: "str", Documentation: "String to test"},
+			{Label: "regex", Documentation: "POSIX extended regex"},
+		},
+	},
+	"regex_find": {
+		Label: "regex_find(str, regex)",
+		Parameters: []protocol.ParameterInformation{
+			{Label: "str", Documentation: "String to search"},
+			{Label: "regex", Documentation: "POSIX extended regex with capture groups"},
+		},
+	},
+	"replace_regex": {
+		Label: "replace_regex(str, regex, repl)",
+		Parameters: []protocol.ParameterInformation{
+			{Label: "str", Documentation: "Input string"},
+			{Label: "regex", Documentation: "POSIX extended regex"},
+			{Label: "repl", Documentation: "Replacement; \\1 refers to a group"},
+		},
+	},
 }
 
 func (s *Server) textDocumentSignatureHelp(ctx *glsp.Context, params *protocol.SignatureHelpParams) (result *protocol.SignatureHelp, err error) {
@@ -35,6 +35,18 @@ func findSymbols(source string) []symbolInfo {
 				Line: next.Line,
 				Col:  next.Col,
 			})
+		case t.Type == lexer.IDENT && t.Value == "flags" && i+1 < len(tokens) && tokens[i+1].Type == lexer.LBRACE:
+			// each "name:" in the block declares a variable
+			for i += 2; i < len(tokens) && tokens[i].Type != lexer.RBRACE && tokens[i].Type != lexer.EOF; i++ {
+				if tokens[i].Type == lexer.IDENT && i+1 < len(tokens) && tokens[i+1].Type == lexer.COLON {
+					symbols = append(symbols, symbolInfo{
+						Name: tokens[i].Value,
+						Kind: "variable",
+						Line: tokens[i].Line,
+						Col:  tokens[i].Col,
+					})
+				}
+			}
 		case t.Type == lexer.FOR && i+1 < len(tokens) && tokens[i+1].Type == lexer.IDENT:
 			next := tokens[i+1]
 			symbols = append(symbols, symbolInfo{
@@ -31,6 +31,16 @@ func TestFindSymbolsForVar(t *testing.T) {
 	assert.Equal(t, "for_var", symbols[0].Kind)
 }
 
+func TestFindSymbolsFlags(t *testing.T) {
+	symbols := findSymbols("flags {\n  env: str = \"prod\"\n  dry_run: bool\n}\nx = 1")
+	require.Len(t, symbols, 3)
+	assert.Equal(t, "env", symbols[0].Name)
+	assert.Equal(t, "variable", symbols[0].Kind)
+	assert.Equal(t, 2, symbols[0].Line)
+	assert.Equal(t, "dry_run", symbols[1].Name)
+	assert.Equal(t, "x", symbols[2].Name)
+}
+
 func TestFindSymbolsMixed(t *testing.T) {
 	source := `x = 1
 fn greet(name: string) {
@@ -59,8 +69,9 @@ func TestFindSymbolsEmpty(t *testing.T) {
 
 func TestKeywordNames(t *testing.T) {
 	names := lexer.KeywordNames()
-	assert.Len(t, names, 16)
+	assert.Len(t, names, 20)
 	assert.Contains(t, names, "if")
+	assert.Contains(t, names, "try")
 	assert.Contains(t, names, "fn")
 	assert.Contains(t, names, "while")
 	assert.Contains(t, names, "or")
@@ -0,0 +1,345 @@
+package modules
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/tasnimzotder/langz/internal/ast"
+)
+
+// declare records the functions and globals mod declares. Functions are
+// those declared at the top level; globals are the variables assigned
+// anywhere, since Bash variables are global unless they are parameters.
+func declare(mod *module, stmts []ast.Node) {
+	bashName := func(name string) string {
+		if mod.prefix == "" {
+			return name
+		}
+		return mod.prefix + "__" + name
+	}
+	for _, stmt := range stmts {
+		if fn, ok := stmt.(*ast.FuncDecl); ok {
+			mod.funcs[fn.Name] = bashName(fn.Name)
+		}
+	}
+	assigned(stmts, nil, func(name string) {
+		mod.vars[name] = bashName(name)
+	})
+}
+
+// assigned calls add for each variable stmts assign, other than the
+// parameters of the enclosing function.
+func assigned(stmts []ast.Node, params map[string]bool, add func(string)) {
+	addVar := func(name string) {
+		if name != "" && !params[name] {
+			add(name)
+		}
+	}
+	for _, stmt := range stmts {
+		switch n := stmt.(type) {
+		case *ast.Assignment:
+			addVar(n.Name)
+			if or, ok := n.Value.(*ast.OrExpr); ok {
+				if block, ok := or.Fallback.(*ast.BlockExpr); ok {
+					assigned(block.Statements, params, add)
+				}
+			}
+		case *ast.IndexAssignment:
+			addVar(n.Object)
+		case *ast.FlagsDecl:
+			for _, f := range n.Flags {
+				addVar(f.Name)
+			}
+		case *ast.FuncDecl:
+			inner := make(map[string]bool)
+			for _, p := range n.Params {
+				inner[p.Name] = true
+			}
+			assigned(n.Body, inner, add)
+		case *ast.ForStmt:
+			addVar(n.Var)
+			addVar(n.ValueVar)
+			assigned(n.Body, params, add)
+		case *ast.IfStmt:
+			assigned(n.Body, params, add)
+			assigned(n.ElseBody, params, add)
+		case *ast.WhileStmt:
+			assigned(n.Body, params, add)
+		case *ast.MatchStmt:
+			for _, c := range n.Cases {
+				assigned(c.Body, params, add)
+			}
+		case *ast.TryStmt:
+			assigned(n.Body, params, add)
+			addVar(n.ErrVar)
+			assigned(n.Catch, params, add)
+			assigned(n.Finally, params, add)
+		case *ast.DeferStmt:
+			assigned(n.Body, params, add)
+		case *ast.WithStmt:
+			addVar(n.Var)
+			assigned(n.Body, params, add)
+		}
+	}
+}
+
+// binding is the Bash name an unqualified name refers to in a module.
+type binding struct {
+	target string
+	from   string // path of the module it was imported from; "" if declared here
+	// plain is set for names brought in by a plain import, which the
+	// module's own declarations and from imports take precedence over.
+	plain bool
+	// ambiguous is set when plain imports of two modules both bring in the
+	// name; using it is then an error.
+	ambiguous string
+}
+
+// scope holds the names visible in a module.
+type scope struct {
+	funcs      map[string]binding
+	vars       map[string]binding
+	namespaces map[string]*module
+}
+
+func newScope(mod *module) *scope {
+	s := &scope{funcs: make(map[string]binding), vars: make(map[string]binding), namespaces: make(map[string]*module)}
+	for name, target := range mod.funcs {
+		s.funcs[name] = binding{target: target}
+	}
+	for name, target := range mod.vars {
+		s.vars[name] = binding{target: target}
+	}
+	return s
+}
+
+// bind makes the names imp imports from dep visible, returning any
+// conflicts. A plain import brings in everything dep declares.
+func (s *scope) bind(imp *ast.ImportStmt, dep *module) []string {
+	var errs []string
+	switch ns := imp.Namespace(); {
+	case ns != "":
+		if b, ok := s.vars[ns]; ok && b.from == "" {
+			errs = append(errs, fmt.Sprintf("import name %s is already a variable in this file", ns))
+		}
+		s.namespaces[ns] = dep
+	case imp.Names != nil:
+		for _, name := range imp.Names {
+			fn, isFunc := dep.funcs[name]
+			v, isVar := dep.vars[name]
+			if !isFunc && !isVar {
+				errs = append(errs, fmt.Sprintf("%s has no function or variable %s", imp.Path, name))
+				continue
+			}
+			if isFunc {
+				if b, ok := s.funcs[name]; ok && b.from == "" {
+					errs = append(errs, fmt.Sprintf("imported function %s is also declared in this file", name))
+					continue
+				}
+				s.funcs[name] = binding{target: fn, from: dep.path}
+			}
+			if isVar {
+				s.vars[name] = binding{target: v, from: dep.path}
+			}
+		}
+	default:
+		for name, target := range dep.funcs {
+			bindPlain(s.funcs, name, target, dep.path)
+		}
+		for name, target := range dep.vars {
+			bindPlain(s.vars, name, target, dep.path)
+		}
+	}
+	return errs
+}
+
+func bindPlain(names map[string]binding, name, target, from string) {
+	prev, ok := names[name]
+	switch {
+	case !ok:
+		names[name] = binding{target: target, from: from, plain: true}
+	case prev.plain && prev.from != from:
+		prev.ambiguous = fmt.Sprintf("%s is declared in both %s and %s; import one of them with as", name, prev.from, from)
+		names[name] = prev
+	}
+}
+
+var interpRegex = regexp.MustCompile(`\{([A-Za-z_]\w*)\}`)
+
+// renamer rewrites a module's references to names to their Bash names.
+type renamer struct {
+	l      *loader
+	s      *scope
+	file   string
+	params map[string]bool // parameters of the enclosing function
+	caller string          // Bash name of the enclosing function
+}
+
+func (r *renamer) resolve(names map[string]binding, name string, node ast.Node) string {
+	if r.params[name] {
+		return name
+	}
+	b, ok := names[name]
+	if !ok {
+		return name
+	}
+	if b.ambiguous != "" {
+		r.l.errorf(r.file, node.NodeSpan().Start, "%s", b.ambiguous)
+		return name
+	}
+	return b.target
+}
+
+func (r *renamer) varName(name string, node ast.Node) string {
+	if name == "" {
+		return name
+	}
+	return r.resolve(r.s.vars, name, node)
+}
+
+func (r *renamer) funcName(name string, node ast.Node) string {
+	return r.resolve(r.s.funcs, name, node)
+}
+
+// namespace returns the module node names through an as import, and
+// that name.
+func (r *renamer) namespace(node ast.Node) (*module, string, bool) {
+	id, ok := node.(*ast.Identifier)
+	if !ok || r.params[id.Name] {
+		return nil, "", false
+	}
+	mod, ok := r.s.namespaces[id.Name]
+	return mod, id.Name, ok
+}
+
+func (r *renamer) block(stmts []ast.Node) {
+	for i, stmt := range stmts {
+		stmts[i] = r.node(stmt)
+	}
+}
+
+func (r *renamer) kwargs(kwargs []ast.KeywordArg) {
+	for i := range kwargs {
+		kwargs[i].Value = r.node(kwargs[i].Value)
+	}
+}
+
+// node renames the names in n, returning the node to use in its place:
+// members of a namespace become plain calls and variables.
+func (r *renamer) node(n ast.Node) ast.Node {
+	switch n := n.(type) {
+	case *ast.Assignment:
+		n.Name = r.varName(n.Name, n)
+		n.Value = r.node(n.Value)
+	case *ast.IndexAssignment:
+		n.Object = r.varName(n.Object, n)
+		n.Index = r.node(n.Index)
+		n.Value = r.node(n.Value)
+	case *ast.PathAssignment:
+		n.Target = r.node(n.Target)
+		n.Value = r.node(n.Value)
+		// k8s.namespace = "x" sets the module's variable
+		if id, ok := n.Target.(*ast.Identifier); ok {
+			return &ast.Assignment{Span: n.Span, Name: id.Name, Value: n.Value}
+		}
+	case *ast.Identifier:
+		n.Name = r.varName(n.Name, n)
+	case *ast.StringLiteral:
+		if !n.Raw {
+			n.Value = interpRegex.ReplaceAllStringFunc(n.Value, func(m string) string {
+				return "{" + r.varName(m[1:len(m)-1], n) + "}"
+			})
+		}
+	case *ast.FuncCall:
+		n.Name = r.funcName(n.Name, n)
+		r.l.calls[r.caller] = append(r.l.calls[r.caller], n.Name)
+		r.block(n.Args)
+		r.kwargs(n.KwArgs)
+	case *ast.MethodCall:
+		r.block(n.Args)
+		r.kwargs(n.KwArgs)
+		if mod, alias, ok := r.namespace(n.Object); ok {
+			name, ok := mod.funcs[n.Method]
+			if !ok {
+				r.l.errorf(r.file, n.Span.Start, "module %s has no function %s", alias, n.Method)
+			}
+			r.l.calls[r.caller] = append(r.l.calls[r.caller], name)
+			return &ast.FuncCall{Span: n.Span, Name: name, Args: n.Args, KwArgs: n.KwArgs}
+		}
+		n.Object = r.node(n.Object)
+	case *ast.DotExpr:
+		if mod, alias, ok := r.namespace(n.Object); ok {
+			name, ok := mod.vars[n.Field]
+			if !ok {
+				r.l.errorf(r.file, n.Span.Start, "module %s has no variable %s", alias, n.Field)
+			}
+			return &ast.Identifier{Span: n.Span, Name: name}
+		}
+		n.Object = r.node(n.Object)
+	case *ast.FuncDecl:
+		n.Name = r.funcName(n.Name, n)
+		inner := &renamer{l: r.l, s: r.s, file: r.file, params: make(map[string]bool), caller: n.Name}
+		for i, p := range n.Params {
+			n.Params[i].Default = r.node(p.Default)
+			inner.params[p.Name] = true
+		}
+		inner.block(n.Body)
+	case *ast.FlagsDecl:
+		for i, f := range n.Flags {
+			n.Flags[i].Default = r.node(f.Default)
+		}
+	case *ast.BinaryExpr:
+		n.Left = r.node(n.Left)
+		n.Right = r.node(n.Right)
+	case *ast.UnaryExpr:
+		n.Operand = r.node(n.Operand)
+	case *ast.ListLiteral:
+		r.block(n.Elements)
+	case *ast.MapLiteral:
+		r.block(n.Values)
+	case *ast.IndexExpr:
+		n.Object = r.node(n.Object)
+		n.Index = r.node(n.Index)
+	case *ast.OrExpr:
+		n.Expr = r.node(n.Expr)
+		n.Fallback = r.node(n.Fallback)
+	case *ast.BlockExpr:
+		r.block(n.Statements)
+	case *ast.ReturnStmt:
+		n.Value = r.node(n.Value)
+	case *ast.ExitCall:
+		n.Code = r.node(n.Code)
+	case *ast.IfStmt:
+		n.Condition = r.node(n.Condition)
+		r.block(n.Body)
+		r.block(n.ElseBody)
+	case *ast.ForStmt:
+		n.Var = r.varName(n.Var, n)
+		n.ValueVar = r.varName(n.ValueVar, n)
+		n.Collection = r.node(n.Collection)
+		r.block(n.Body)
+	case *ast.WhileStmt:
+		n.Condition = r.node(n.Condition)
+		r.block(n.Body)
+	case *ast.MatchStmt:
+		n.Expr = r.node(n.Expr)
+		for i := range n.Cases {
+			n.Cases[i].Pattern = r.node(n.Cases[i].Pattern)
+			r.block(n.Cases[i].Body)
+		}
+	case *ast.TryStmt:
+		r.block(n.Body)
+		n.ErrVar = r.varName(n.ErrVar, n)
+		r.block(n.Catch)
+		r.block(n.Finally)
+	case *ast.DeferStmt:
+		r.block(n.Body)
+	case *ast.WithStmt:
+		n.Var = r.varName(n.Var, n)
+		n.Value = r.node(n.Value)
+		r.block(n.Body)
+	case *ast.BashBlock:
+		r.l.bash[r.caller] = append(r.l.bash[r.caller], n.Content)
+	}
+	return n
+}
@@ -0,0 +1,256 @@
+// Package modules resolves import statements by linking the imported files
+// into the importing program. Each module is included once, where it is
+// first imported, so its top-level code runs once however many files import
+// it. The functions and globals a module declares are prefixed with the
+// module's name in the generated Bash, so two modules declaring the same
+// name cannot clobber each other; the main file's names are left alone.
+package modules
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/tasnimzotder/langz/internal/ast"
+	"github.com/tasnimzotder/langz/internal/lexer"
+	"github.com/tasnimzotder/langz/internal/parser"
+	"github.com/tasnimzotder/langz/std"
+)
+
+// Error is a problem found while resolving imports, located in the file it
+// was found in.
+type Error struct {
+	File    string
+	Pos     ast.Pos
+	Message string
+}
+
+func (e Error) Error() string {
+	if e.Pos.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.File, e.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Pos.Line, e.Pos.Col, e.Message)
+}
+
+// module is a linked source file and the Bash names of what it declares.
+type module struct {
+	path    string
+	prefix  string            // "" for the main file
+	funcs   map[string]string // declared function → Bash name
+	vars    map[string]string // global variable → Bash name
+	loading bool              // its imports are being linked; importing it now is a cycle
+	std     bool              // part of the standard library
+}
+
+type loader struct {
+	resolver Resolver
+	modules  map[string]*module // by absolute path, or std/<name>
+	prefixes map[string]bool
+	errs     []Error
+	// calls and bash record, by the Bash name of the calling function ("" for
+	// top-level code), the functions called and the bash blocks run, so that
+	// unused standard library functions can be dropped.
+	calls map[string][]string
+	bash  map[string][]string
+}
+
+// Resolve replaces the import statements of prog, which was parsed from
+// file, with the modules they import, and rewrites the references to
+// imported names to their Bash names. Imports are found through r, except
+// for std/<name>, which is the embedded standard library. Standard library
+// functions the program doesn't use are left out.
+func Resolve(prog *ast.Program, file string, r Resolver) []Error {
+	l := &loader{
+		resolver: r,
+		modules:  make(map[string]*module),
+		prefixes: make(map[string]bool),
+		calls:    make(map[string][]string),
+		bash:     make(map[string][]string),
+	}
+	main := newModule(file, "")
+	if abs, err := filepath.Abs(file); err == nil {
+		l.modules[abs] = main
+	}
+	prog.Statements = l.prune(l.link(prog.Statements, main))
+	return l.errs
+}
+
+func newModule(path, prefix string) *module {
+	return &module{path: path, prefix: prefix, funcs: make(map[string]string), vars: make(map[string]string), loading: true}
+}
+
+func (l *loader) errorf(file string, pos ast.Pos, format string, args ...any) {
+	l.errs = append(l.errs, Error{File: file, Pos: pos, Message: fmt.Sprintf(format, args...)})
+}
+
+// link returns mod's statements with its imports replaced by the modules
+// they include and its names rewritten. Imports bind names for the whole
+// file, wherever they appear in it.
+func (l *loader) link(stmts []ast.Node, mod *module) []ast.Node {
+	declare(mod, stmts)
+	s := newScope(mod)
+
+	included := make(map[int][]ast.Node)
+	for i, stmt := range stmts {
+		imp, ok := stmt.(*ast.ImportStmt)
+		if !ok {
+			continue
+		}
+		dep, linked := l.load(imp, mod)
+		if dep == nil {
+			continue
+		}
+		included[i] = linked
+		for _, msg := range s.bind(imp, dep) {
+			l.errorf(mod.path, imp.Span.Start, "%s", msg)
+		}
+	}
+
+	r := &renamer{l: l, s: s, file: mod.path}
+	var out []ast.Node
+	for i, stmt := range stmts {
+		if _, ok := stmt.(*ast.ImportStmt); ok {
+			out = append(out, included[i]...)
+			continue
+		}
+		// a module's flags would parse the arguments meant for the script
+		if d, ok := stmt.(*ast.FlagsDecl); ok && mod.prefix != "" {
+			l.errorf(mod.path, d.Span.Start, "flags can only be declared in the main script")
+			continue
+		}
+		out = append(out, r.node(stmt))
+	}
+	mod.loading = false
+	return out
+}
+
+// load returns the module imp refers to, with its linked statements if
+// this is the first import of it.
+func (l *loader) load(imp *ast.ImportStmt, from *module) (*module, []ast.Node) {
+	name, isStd := strings.CutPrefix(imp.Path, "std/")
+	path, key := imp.Path+".lz", imp.Path
+	if !isStd {
+		var err error
+		if path, err = l.resolver.Find(imp.Path, from.path); err == nil {
+			key, err = filepath.Abs(path)
+		}
+		if err != nil {
+			l.errorf(from.path, imp.Span.Start, "import %q: %v", imp.Path, err)
+			return nil, nil
+		}
+	}
+	if dep, ok := l.modules[key]; ok {
+		if dep.loading {
+			l.errorf(from.path, imp.Span.Start, "circular import detected: %s", imp.Path)
+			return nil, nil
+		}
+		return dep, nil
+	}
+
+	var data []byte
+	if isStd {
+		var ok bool
+		if data, ok = std.Source(name); !ok {
+			l.errorf(from.path, imp.Span.Start, "import %q: no such standard library module", imp.Path)
+			return nil, nil
+		}
+	} else {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			l.errorf(from.path, imp.Span.Start, "import %q: %v", imp.Path, err)
+			return nil, nil
+		}
+	}
+	tokens := lexer.New(string(data)).Tokenize()
+	prog, parseErrs := parser.NewFile(tokens, path).ParseAllErrors()
+	if len(parseErrs) > 0 {
+		for _, e := range parseErrs {
+			l.errorf(path, ast.Pos{Line: e.Line, Col: e.Col}, "%s", e.Message)
+		}
+		return nil, nil
+	}
+
+	prefix := path
+	if isStd {
+		prefix = "std_" + name
+	}
+	dep := newModule(path, l.prefix(prefix))
+	dep.std = isStd
+	l.modules[key] = dep
+	return dep, l.link(prog.Statements, dep)
+}
+
+// prune drops the standard library functions that nothing outside the
+// standard library calls, directly or through other functions. A function
+// whose name appears in a bash block that runs counts as called.
+func (l *loader) prune(stmts []ast.Node) []ast.Node {
+	library := make(map[string]bool)
+	for _, mod := range l.modules {
+		if mod.std {
+			for _, name := range mod.funcs {
+				library[name] = true
+			}
+		}
+	}
+	if len(library) == 0 {
+		return stmts
+	}
+
+	used := make(map[string]bool)
+	var use func(caller string)
+	use = func(caller string) {
+		for _, name := range l.calls[caller] {
+			if library[name] && !used[name] {
+				used[name] = true
+				use(name)
+			}
+		}
+		for _, content := range l.bash[caller] {
+			for name := range library {
+				if !used[name] && strings.Contains(content, name) {
+					used[name] = true
+					use(name)
+				}
+			}
+		}
+	}
+	use("")
+	for _, stmt := range stmts {
+		if fn, ok := stmt.(*ast.FuncDecl); ok && !library[fn.Name] {
+			use(fn.Name)
+		}
+	}
+
+	var out []ast.Node
+	for _, stmt := range stmts {
+		if fn, ok := stmt.(*ast.FuncDecl); ok && library[fn.Name] && !used[fn.Name] {
+			continue
+		}
+		out = append(out, stmt)
+	}
+	return out
+}
+
+// prefix picks a unique Bash-safe prefix for the module at path, based on
+// its file name.
+func (l *loader) prefix(path string) string {
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	name := strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return '_'
+	}, base)
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "m" + name
+	}
+
+	candidate := name
+	for i := 2; l.prefixes[candidate]; i++ {
+		candidate = fmt.Sprintf("%s%d", name, i)
+	}
+	l.prefixes[candidate] = true
+	return candidate
+}
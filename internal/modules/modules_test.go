@@ -0,0 +1,278 @@
+package modules
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tasnimzotder/langz/internal/ast"
+	"github.com/tasnimzotder/langz/internal/codegen"
+	"github.com/tasnimzotder/langz/internal/lexer"
+	"github.com/tasnimzotder/langz/internal/parser"
+)
+
+// writeFiles creates files under a temporary directory and returns it.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return dir
+}
+
+// resolve parses main.lz in dir and links its imports.
+func resolve(t *testing.T, dir string) (*ast.Program, []Error) {
+	t.Helper()
+	file := filepath.Join(dir, "main.lz")
+	source, err := os.ReadFile(file)
+	require.NoError(t, err)
+	prog, err := parser.NewFile(lexer.New(string(source)).Tokenize(), file).ParseWithErrors()
+	require.NoError(t, err)
+	return prog, Resolve(prog, file, &SearchPath{})
+}
+
+func generate(t *testing.T, prog *ast.Program) string {
+	t.Helper()
+	output, errs := codegen.Generate(prog)
+	require.Empty(t, errs)
+	return output
+}
+
+func messages(errs []Error) []string {
+	var msgs []string
+	for _, e := range errs {
+		msgs = append(msgs, e.Message)
+	}
+	return msgs
+}
+
+func TestPlainImportKeepsNamesVisible(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"helpers.lz": "greeting = \"Hello\"\nfn greet(name: str) {\n\tprint(\"{greeting} {name}\")\n}\n",
+		"main.lz":    "import \"helpers.lz\"\ngreet(\"World\")\nprint(greeting)\n",
+	})
+	prog, errs := resolve(t, dir)
+	require.Empty(t, errs)
+
+	output := generate(t, prog)
+	assert.Contains(t, output, "helpers__greeting=\"Hello\"\nhelpers__greet() {")
+	assert.Contains(t, output, `echo "${helpers__greeting} ${name}"`)
+	assert.Contains(t, output, "helpers__greet \"World\"\necho \"$helpers__greeting\"")
+}
+
+func TestNamespacedImport(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"lib/k8s.lz": "namespace = \"default\"\nfn apply(file: str, wait: bool = false) {\n\tprint(file, namespace)\n}\n",
+		"main.lz":    "import \"lib/k8s.lz\" as k8s\nk8s.apply(\"app.yaml\", wait: true)\nprint(k8s.namespace)\n",
+	})
+	prog, errs := resolve(t, dir)
+	require.Empty(t, errs)
+
+	output := generate(t, prog)
+	assert.Contains(t, output, "k8s__apply \"app.yaml\" true")
+	assert.Contains(t, output, `echo "$k8s__namespace"`)
+}
+
+func TestAssignNamespacedVariable(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"k8s.lz":  "namespace = \"default\"\n",
+		"main.lz": "import \"k8s.lz\" as k8s\nk8s.namespace = \"staging\"\n",
+	})
+	prog, errs := resolve(t, dir)
+	require.Empty(t, errs)
+
+	output := generate(t, prog)
+	assert.Contains(t, output, "k8s__namespace=\"default\"\nk8s__namespace=\"staging\"")
+}
+
+func TestSameNameInTwoModules(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.lz":    "fn log(msg: str) {\n\tprint(\"a\", msg)\n}\n",
+		"b.lz":    "fn log(msg: str) {\n\tprint(\"b\", msg)\n}\n",
+		"main.lz": "import \"a.lz\" as a\nfrom \"b.lz\" import log\na.log(\"x\")\nlog(\"y\")\n",
+	})
+	prog, errs := resolve(t, dir)
+	require.Empty(t, errs)
+
+	output := generate(t, prog)
+	assert.Contains(t, output, "a__log() {")
+	assert.Contains(t, output, "b__log() {")
+	assert.Contains(t, output, "a__log \"x\"\nb__log \"y\"")
+}
+
+func TestModuleIncludedOnce(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"log.lz":  "print(\"log loaded\")\nfn log(msg: str) {\n\tprint(msg)\n}\n",
+		"a.lz":    "import \"log.lz\"\nfn a() {\n\tlog(\"a\")\n}\n",
+		"main.lz": "import \"a.lz\"\nimport \"log.lz\" as l\na()\nl.log(\"main\")\n",
+	})
+	prog, errs := resolve(t, dir)
+	require.Empty(t, errs)
+
+	loaded := 0
+	for _, stmt := range prog.Statements {
+		if call, ok := stmt.(*ast.FuncCall); ok && call.Name == "print" {
+			loaded++
+		}
+	}
+	assert.Equal(t, 1, loaded)
+}
+
+func TestPlainImportsDeclaringSameName(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.lz":    "fn log(msg: str) {\n\tprint(msg)\n}\nfn only_a() {\n\tprint(\"a\")\n}\n",
+		"b.lz":    "fn log(msg: str) {\n\tprint(msg)\n}\n",
+		"main.lz": "import \"a.lz\"\nimport \"b.lz\"\nonly_a()\nlog(\"x\")\n",
+	})
+	_, errs := resolve(t, dir)
+
+	require.Len(t, errs, 1)
+	assert.Equal(t, filepath.Join(dir, "main.lz"), errs[0].File)
+	assert.Equal(t, 4, errs[0].Pos.Line)
+	assert.Contains(t, errs[0].Message, "log is declared in both")
+}
+
+func TestImportErrors(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"util.lz": "fn retry(n: int) -> int {\n\treturn n\n}\n",
+		"main.lz": "import \"util.lz\" as util\nfrom \"util.lz\" import backoff\nutil.retries(3)\nprint(util.count)\n",
+	})
+	_, errs := resolve(t, dir)
+
+	assert.Equal(t, []string{
+		"util.lz has no function or variable backoff",
+		"module util has no function retries",
+		"module util has no variable count",
+	}, messages(errs))
+}
+
+func TestCircularImport(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.lz":    "import \"main.lz\"\n",
+		"main.lz": "import \"a.lz\"\n",
+	})
+	_, errs := resolve(t, dir)
+
+	require.Len(t, errs, 1)
+	assert.Equal(t, filepath.Join(dir, "a.lz"), errs[0].File)
+	assert.Equal(t, "circular import detected: main.lz", errs[0].Message)
+}
+
+func TestParseErrorsInImportedFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"lib.lz":  "x = 1\ny = @\nz = @\n",
+		"main.lz": "import \"lib.lz\"\n",
+	})
+	_, errs := resolve(t, dir)
+
+	require.Len(t, errs, 2)
+	lib := filepath.Join(dir, "lib.lz")
+	assert.Equal(t, lib, errs[0].File)
+	assert.Equal(t, ast.Pos{Line: 2, Col: 5}, errs[0].Pos)
+	assert.Equal(t, 3, errs[1].Pos.Line)
+	assert.Equal(t, lib+":2:5: "+errs[0].Message, errs[0].Error())
+}
+
+func TestImportedSpansRecordFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"lib.lz":  "fn f(a: str) {\n\tprint(a)\n}\n",
+		"main.lz": "import \"lib.lz\"\nf(\"x\")\n",
+	})
+	prog, errs := resolve(t, dir)
+	require.Empty(t, errs)
+
+	assert.Equal(t, filepath.Join(dir, "lib.lz"), prog.Statements[0].NodeSpan().File)
+	assert.Equal(t, filepath.Join(dir, "main.lz"), prog.Statements[1].NodeSpan().File)
+}
+
+func TestParametersShadowModuleGlobals(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"lib.lz":  "name = \"lib\"\nfn hello(name: str) {\n\tprint(\"hi {name}\")\n}\n",
+		"main.lz": "import \"lib.lz\" as lib\nlib.hello(\"x\")\n",
+	})
+	prog, errs := resolve(t, dir)
+	require.Empty(t, errs)
+
+	output := generate(t, prog)
+	assert.Contains(t, output, "lib__hello() {\n  local name=\"$1\"\n  echo \"hi ${name}\"")
+}
+
+func TestStdModuleKeepsOnlyUsedFunctions(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.lz": "import \"std/retry\"\nretry.shell(\"true\")\n",
+	})
+	prog, errs := resolve(t, dir)
+	require.Empty(t, errs)
+
+	output := generate(t, prog)
+	assert.Contains(t, output, "std_retry__shell() {")
+	assert.Contains(t, output, "std_retry__succeeds() {")
+	// retry logs through std/log, and only with warn and error
+	assert.Contains(t, output, "std_log__warn() {\n  local msg=\"$1\"\n  _log warn \"$msg\"\n}")
+	assert.NotContains(t, output, "std_log__info")
+	assert.NotContains(t, output, "std_log__debug")
+	assert.Contains(t, output, "std_retry__shell \"true\"")
+}
+
+func TestStdModuleImportedByName(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.lz": "import \"std/semver\" as sv\nfrom \"std/log\" import info\nif sv.valid(\"1.0.0\") {\n\tinfo(\"ok\")\n}\n",
+	})
+	prog, errs := resolve(t, dir)
+	require.Empty(t, errs)
+
+	output := generate(t, prog)
+	assert.Contains(t, output, "if std_semver__valid \"1.0.0\"; then\n  std_log__info \"ok\"")
+	assert.NotContains(t, output, "std_semver__compare")
+}
+
+func TestUnusedStdModuleAddsNoFunctions(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.lz": "import \"std/semver\"\nprint(\"hi\")\n",
+	})
+	prog, errs := resolve(t, dir)
+	require.Empty(t, errs)
+
+	assert.NotContains(t, generate(t, prog), "std_semver__")
+}
+
+func TestUnknownStdModule(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.lz": "import \"std/nope\"\n",
+	})
+	_, errs := resolve(t, dir)
+
+	assert.Equal(t, []string{`import "std/nope": no such standard library module`}, messages(errs))
+}
+
+func TestStdFunctionCalledFromBashBlockIsKept(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.lz": "import \"std/log\"\nbash { std_log__info \"from bash\" }\n",
+	})
+	prog, errs := resolve(t, dir)
+	require.Empty(t, errs)
+
+	output := generate(t, prog)
+	assert.Contains(t, output, "std_log__info() {")
+	assert.NotContains(t, output, "std_log__warn() {")
+}
+
+func TestFlagsOnlyInMainScript(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"config.lz": "region = \"eu-west-1\"\n",
+		"cli.lz":    "flags { verbose: bool }\n",
+		"main.lz":   "import \"config.lz\" as config\nimport \"cli.lz\"\nflags { region: str = config.region }\nprint(region)\n",
+	})
+	prog, errs := resolve(t, dir)
+	assert.Equal(t, []string{"flags can only be declared in the main script"}, messages(errs))
+
+	output := generate(t, prog)
+	assert.Contains(t, output, `[ -n "${region+x}" ] || region="$config__region"`)
+	assert.Contains(t, output, `echo "$region"`)
+}
@@ -0,0 +1,149 @@
+package modules
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PathEnv names the environment variable listing library roots, separated
+// like PATH.
+const PathEnv = "LANGZ_PATH"
+
+// ModFileName is the name of the file marking a project's root.
+const ModFileName = "langz.mod"
+
+// A Resolver finds the file an import refers to. The CLI, the LSP and the
+// tests all resolve imports through one, so they agree on what an import
+// means.
+type Resolver interface {
+	// Find returns the path of the file imported as path by importer.
+	Find(path, importer string) (string, error)
+}
+
+// SearchPath resolves imports relative to the importing file, then in the
+// vendor directory, then in each library root in turn. Paths starting with
+// ./ or ../ are only resolved relative to the importing file.
+type SearchPath struct {
+	Vendor string   // searched before the roots; "" for none
+	Roots  []string // library roots, in the order they are searched
+}
+
+// NewResolver returns the search path for file: the vendor directory and
+// library roots of the langz.mod in its directory or the nearest parent,
+// followed by the roots in LANGZ_PATH.
+func NewResolver(file string) (*SearchPath, error) {
+	dir, err := filepath.Abs(filepath.Dir(file))
+	if err != nil {
+		return nil, err
+	}
+	sp := &SearchPath{}
+	mod, err := FindModFile(dir)
+	if err != nil {
+		return nil, err
+	}
+	if mod != nil {
+		sp.Vendor = mod.Vendor
+		sp.Roots = append(sp.Roots, mod.Paths...)
+	}
+	sp.Roots = append(sp.Roots, envRoots()...)
+	return sp, nil
+}
+
+// envRoots returns the library roots listed in LANGZ_PATH.
+func envRoots() []string {
+	var roots []string
+	for _, root := range filepath.SplitList(os.Getenv(PathEnv)) {
+		if root != "" {
+			roots = append(roots, root)
+		}
+	}
+	return roots
+}
+
+func (sp *SearchPath) Find(path, importer string) (string, error) {
+	if filepath.IsAbs(path) {
+		return path, nil
+	}
+	if strings.HasPrefix(path, "./") || strings.HasPrefix(path, "../") {
+		return filepath.Join(filepath.Dir(importer), path), nil
+	}
+
+	dirs := []string{filepath.Dir(importer)}
+	if sp.Vendor != "" {
+		dirs = append(dirs, sp.Vendor)
+	}
+	dirs = append(dirs, sp.Roots...)
+	for _, dir := range dirs {
+		candidate := filepath.Join(dir, path)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("not found in %s", strings.Join(dirs, ", "))
+}
+
+// ModFile is a parsed langz.mod. Each line holds one directive:
+//
+//	path <dir>    a library root, searched in the order listed
+//	vendor <dir>  where `langz vendor` copies modules (default vendor)
+//
+// Directories are relative to the langz.mod; # starts a comment.
+type ModFile struct {
+	Dir    string   // directory containing the langz.mod
+	Paths  []string // library roots
+	Vendor string   // vendor directory
+}
+
+// FindModFile reads the langz.mod in dir or its nearest parent, returning
+// nil if there is none.
+func FindModFile(dir string) (*ModFile, error) {
+	for {
+		data, err := os.ReadFile(filepath.Join(dir, ModFileName))
+		if err == nil {
+			return ParseModFile(data, dir)
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// ParseModFile parses the contents of the langz.mod in dir.
+func ParseModFile(data []byte, dir string) (*ModFile, error) {
+	mod := &ModFile{Dir: dir, Vendor: filepath.Join(dir, "vendor")}
+	file := filepath.Join(dir, ModFileName)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected a directive and a directory", file, line)
+		}
+		target := fields[1]
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(dir, target)
+		}
+		switch fields[0] {
+		case "path":
+			mod.Paths = append(mod.Paths, target)
+		case "vendor":
+			mod.Vendor = target
+		default:
+			return nil, fmt.Errorf("%s:%d: unknown directive %q", file, line, fields[0])
+		}
+	}
+	return mod, scanner.Err()
+}
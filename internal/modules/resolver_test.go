@@ -0,0 +1,97 @@
+package modules
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindSearchOrder(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"app/log.lz":          "",
+		"app/vendor/k8s.lz":   "",
+		"lib/k8s.lz":          "",
+		"lib/util.lz":         "",
+		"shared/util.lz":      "",
+		"shared/infra/aws.lz": "",
+	})
+	sp := &SearchPath{
+		Vendor: filepath.Join(dir, "app/vendor"),
+		Roots:  []string{filepath.Join(dir, "lib"), filepath.Join(dir, "shared")},
+	}
+	importer := filepath.Join(dir, "app/main.lz")
+
+	for path, want := range map[string]string{
+		"log.lz":       "app/log.lz",
+		"k8s.lz":       "app/vendor/k8s.lz",
+		"util.lz":      "lib/util.lz",
+		"infra/aws.lz": "shared/infra/aws.lz",
+	} {
+		found, err := sp.Find(path, importer)
+		require.NoError(t, err, path)
+		assert.Equal(t, filepath.Join(dir, want), found, path)
+	}
+}
+
+func TestFindRelativePathsOnlyNextToImporter(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"lib/util.lz": ""})
+	sp := &SearchPath{Roots: []string{filepath.Join(dir, "lib")}}
+	importer := filepath.Join(dir, "app/main.lz")
+
+	found, err := sp.Find("./util.lz", importer)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "app/util.lz"), found)
+
+	_, err = sp.Find("missing.lz", importer)
+	assert.EqualError(t, err, "not found in "+filepath.Join(dir, "app")+", "+filepath.Join(dir, "lib"))
+}
+
+func TestNewResolverReadsModFileAndEnv(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"langz.mod": "# shared libraries\npath lib\npath /opt/langz  # pinned\nvendor third_party\n",
+	})
+	t.Setenv(PathEnv, "/usr/share/langz"+string(os.PathListSeparator)+"/home/me/langz")
+
+	sp, err := NewResolver(filepath.Join(dir, "scripts/deploy/main.lz"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "third_party"), sp.Vendor)
+	assert.Equal(t, []string{filepath.Join(dir, "lib"), "/opt/langz", "/usr/share/langz", "/home/me/langz"}, sp.Roots)
+}
+
+func TestParseModFileErrors(t *testing.T) {
+	_, err := ParseModFile([]byte("path lib\nrequire k8s\n"), "/p")
+	assert.EqualError(t, err, `/p/langz.mod:2: unknown directive "require"`)
+
+	_, err = ParseModFile([]byte("path\n"), "/p")
+	assert.EqualError(t, err, "/p/langz.mod:1: expected a directive and a directory")
+}
+
+func TestVendorCopiesModulesFromRoots(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"shared/infra/k8s.lz": "import \"log.lz\"\nfn apply() {\n\tlog(\"apply\")\n}\n",
+		"shared/infra/log.lz": "fn log(msg: str) {\n\tprint(msg)\n}\n",
+		"shared/unused.lz":    "",
+		"app/langz.mod":       "path ../shared\n",
+		"app/lib/util.lz":     "fn util() {\n\tprint(\"util\")\n}\n",
+		"app/main.lz":         "import \"infra/k8s.lz\" as k8s\nimport \"lib/util.lz\"\nk8s.apply()\n",
+	})
+	mod, err := FindModFile(filepath.Join(dir, "app"))
+	require.NoError(t, err)
+	files, err := ProjectFiles(mod)
+	require.NoError(t, err)
+
+	written, errs := Vendor(mod, files)
+	require.Empty(t, errs)
+	assert.Equal(t, []string{"vendor/infra/k8s.lz", "vendor/infra/log.lz"}, written)
+	data, err := os.ReadFile(filepath.Join(dir, "app/vendor/infra/log.lz"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "fn log(msg: str)")
+
+	// Vendored files are not project sources of their own
+	files, err = ProjectFiles(mod)
+	require.NoError(t, err)
+	assert.Len(t, files, 2)
+}
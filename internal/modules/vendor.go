@@ -0,0 +1,120 @@
+package modules
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tasnimzotder/langz/internal/ast"
+	"github.com/tasnimzotder/langz/internal/lexer"
+	"github.com/tasnimzotder/langz/internal/parser"
+)
+
+// recorder is a Resolver noting every file it finds.
+type recorder struct {
+	Resolver
+	found []string
+}
+
+func (r *recorder) Find(path, importer string) (string, error) {
+	found, err := r.Resolver.Find(path, importer)
+	if err == nil {
+		r.found = append(r.found, found)
+	}
+	return found, err
+}
+
+// Vendor copies the modules that files import from library roots outside
+// the project into its vendor directory, keeping their paths relative to
+// the root. Since the vendor directory is searched before the roots, the
+// copies are what the project builds with from then on. Imports are
+// resolved without the vendor directory, so vendoring again refreshes the
+// copies. It returns the files written, relative to the project.
+func Vendor(mod *ModFile, files []string) ([]string, []Error) {
+	roots := append(append([]string(nil), mod.Paths...), envRoots()...)
+	r := &recorder{Resolver: &SearchPath{Roots: roots}}
+
+	var errs []Error
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			errs = append(errs, Error{File: file, Message: err.Error()})
+			continue
+		}
+		prog, parseErrs := parser.NewFile(lexer.New(string(data)).Tokenize(), file).ParseAllErrors()
+		for _, e := range parseErrs {
+			errs = append(errs, Error{File: file, Pos: ast.Pos{Line: e.Line, Col: e.Col}, Message: e.Message})
+		}
+		if len(parseErrs) == 0 {
+			errs = append(errs, Resolve(prog, file, r)...)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	copies := make(map[string]string) // destination → source
+	for _, found := range r.found {
+		abs, err := filepath.Abs(found)
+		if err != nil || within(abs, mod.Dir) {
+			continue
+		}
+		for _, root := range roots {
+			root, err := filepath.Abs(root)
+			if err == nil && within(abs, root) {
+				rel, _ := filepath.Rel(root, abs)
+				copies[filepath.Join(mod.Vendor, rel)] = abs
+				break
+			}
+		}
+	}
+
+	var written []string
+	for dest, src := range copies {
+		data, err := os.ReadFile(src)
+		if err == nil {
+			err = os.MkdirAll(filepath.Dir(dest), 0755)
+		}
+		if err == nil {
+			err = os.WriteFile(dest, data, 0644)
+		}
+		if err != nil {
+			errs = append(errs, Error{File: src, Message: err.Error()})
+			continue
+		}
+		rel, err := filepath.Rel(mod.Dir, dest)
+		if err != nil {
+			rel = dest
+		}
+		written = append(written, rel)
+	}
+	sort.Strings(written)
+	return written, errs
+}
+
+// ProjectFiles returns the .lz files in the project, outside its vendor
+// directory.
+func ProjectFiles(mod *ModFile) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(mod.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && path == mod.Vendor {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && strings.HasSuffix(path, ".lz") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// within reports whether path is dir or inside it.
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
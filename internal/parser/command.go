@@ -0,0 +1,110 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/tasnimzotder/langz/internal/ast"
+)
+
+// parseCommand turns a $ command literal into a call to run(), one argument
+// per word. Words split on whitespace like in a shell; quotes group words,
+// and single quotes also turn off {name} interpolation. A word that is just
+// {name} passes the variable itself, so a list becomes several arguments.
+func (p *Parser) parseCommand(start ast.Pos, text string) ast.Node {
+	words, err := splitCommand(text)
+	if err != nil {
+		p.addError(err.Error())
+		return nil
+	}
+	if len(words) == 0 {
+		p.addError("expected a command after $")
+		return nil
+	}
+	span := p.spanFrom(start)
+	args := make([]ast.Node, len(words))
+	for i, w := range words {
+		switch {
+		case w.raw:
+			args[i] = &ast.StringLiteral{Span: span, Value: w.text, Raw: true}
+		case !w.quoted && isInterpolation(w.text):
+			args[i] = &ast.Identifier{Span: span, Name: w.text[1 : len(w.text)-1]}
+		default:
+			args[i] = &ast.StringLiteral{Span: span, Value: w.text}
+		}
+	}
+	return &ast.FuncCall{Span: span, Name: "run", Args: args}
+}
+
+type commandWord struct {
+	text   string
+	quoted bool // some of the word was quoted
+	raw    bool // all of the word was single-quoted
+}
+
+func splitCommand(text string) ([]commandWord, error) {
+	var words []commandWord
+	var cur strings.Builder
+	inWord, quoted, raw := false, false, true
+	flush := func() {
+		if inWord {
+			words = append(words, commandWord{text: cur.String(), quoted: quoted, raw: raw && quoted})
+		}
+		cur.Reset()
+		inWord, quoted, raw = false, false, true
+	}
+	for i := 0; i < len(text); i++ {
+		switch ch := text[i]; ch {
+		case ' ', '\t':
+			flush()
+		case '\'', '"':
+			end := strings.IndexByte(text[i+1:], ch)
+			if ch == '"' {
+				end = closingQuote(text[i+1:])
+			}
+			if end < 0 {
+				return nil, errors.New("unterminated quote in command")
+			}
+			part := text[i+1 : i+1+end]
+			if ch == '"' {
+				part = strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(part)
+				raw = false
+			}
+			cur.WriteString(part)
+			inWord, quoted = true, true
+			i += end + 1
+		default:
+			cur.WriteByte(ch)
+			inWord, raw = true, false
+		}
+	}
+	flush()
+	return words, nil
+}
+
+// closingQuote returns the index of the first unescaped " in s, or -1.
+func closingQuote(s string) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// isInterpolation reports whether s is exactly {name}.
+func isInterpolation(s string) bool {
+	if len(s) < 3 || s[0] != '{' || s[len(s)-1] != '}' {
+		return false
+	}
+	for i, ch := range s[1 : len(s)-1] {
+		letter := ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+		if !letter && (i == 0 || ch < '0' || ch > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -5,14 +5,19 @@ import (
 	"github.com/tasnimzotder/langz/internal/lexer"
 )
 
-// parsePipeExpr handles |> at the lowest precedence for assignment context.
+// parsePipeExpr handles |> at the lowest precedence for assignments,
+// conditions, for collections and expression statements.
 // a |> f |> g parses as ((a |> f) |> g) — left-associative.
 func (p *Parser) parsePipeExpr() ast.Node {
-	left := p.parseExpression()
+	return p.parsePipeRest(p.parseExpression())
+}
+
+// parsePipeRest parses the |> stages following left.
+func (p *Parser) parsePipeRest(left ast.Node) ast.Node {
 	for p.current.Type == lexer.PIPE {
 		p.advance()
 		right := p.parseExpression()
-		left = &ast.BinaryExpr{Left: left, Op: "|>", Right: right}
+		left = &ast.BinaryExpr{Span: p.spanFrom(p.nodeStart(left)), Left: left, Op: "|>", Right: right}
 	}
 	return left
 }
@@ -21,12 +26,12 @@ func (p *Parser) parsePipeExpr() ast.Node {
 // in condition contexts (if/while). This keeps `or` out of parseExpression
 // so that assignment fallback (`x = expr or fallback`) still works.
 func (p *Parser) parseCondition() ast.Node {
-	left := p.parseExpression()
+	left := p.parsePipeExpr()
 	for p.current.Type == lexer.OR {
 		op := p.current.Value
 		p.advance()
-		right := p.parseExpression()
-		left = &ast.BinaryExpr{Left: left, Op: op, Right: right}
+		right := p.parsePipeExpr()
+		left = &ast.BinaryExpr{Span: p.spanFrom(p.nodeStart(left)), Left: left, Op: op, Right: right}
 	}
 	return left
 }
@@ -39,7 +44,7 @@ func (p *Parser) parseExpression() ast.Node {
 		op := p.current.Value
 		p.advance()
 		right := p.parseComparison()
-		left = &ast.BinaryExpr{Left: left, Op: op, Right: right}
+		left = &ast.BinaryExpr{Span: p.spanFrom(p.nodeStart(left)), Left: left, Op: op, Right: right}
 	}
 
 	return left
@@ -52,7 +57,7 @@ func (p *Parser) parseComparison() ast.Node {
 		op := p.current.Value
 		p.advance()
 		right := p.parseAdditive()
-		left = &ast.BinaryExpr{Left: left, Op: op, Right: right}
+		left = &ast.BinaryExpr{Span: p.spanFrom(p.nodeStart(left)), Left: left, Op: op, Right: right}
 	}
 
 	return left
@@ -65,7 +70,7 @@ func (p *Parser) parseAdditive() ast.Node {
 		op := p.current.Value
 		p.advance()
 		right := p.parseMultiplicative()
-		left = &ast.BinaryExpr{Left: left, Op: op, Right: right}
+		left = &ast.BinaryExpr{Span: p.spanFrom(p.nodeStart(left)), Left: left, Op: op, Right: right}
 	}
 
 	return left
@@ -78,31 +83,35 @@ func (p *Parser) parseMultiplicative() ast.Node {
 		op := p.current.Value
 		p.advance()
 		right := p.parseUnary()
-		left = &ast.BinaryExpr{Left: left, Op: op, Right: right}
+		left = &ast.BinaryExpr{Span: p.spanFrom(p.nodeStart(left)), Left: left, Op: op, Right: right}
 	}
 
 	return left
 }
 
 func (p *Parser) parseUnary() ast.Node {
-	left := p.parsePrimary()
+	start := p.startPos()
+	return p.parsePostfix(start, p.parsePrimary())
+}
 
-	// Handle postfix operators: dot access, method calls, and bracket indexing
+// parsePostfix handles postfix operators: dot access, method calls, and
+// bracket indexing.
+func (p *Parser) parsePostfix(start ast.Pos, left ast.Node) ast.Node {
 	for {
 		if p.current.Type == lexer.DOT {
 			p.advance()
 			field := p.expect(lexer.IDENT)
 			if p.current.Type == lexer.LPAREN {
 				// Method call: obj.method(args)
-				left = p.parseMethodCallArgs(left, field.Value)
+				left = p.parseMethodCallArgs(start, left, field.Value)
 			} else {
-				left = &ast.DotExpr{Object: left, Field: field.Value}
+				left = &ast.DotExpr{Span: p.spanFrom(start), Object: left, Field: field.Value}
 			}
 		} else if p.current.Type == lexer.LBRACKET {
 			p.advance()
 			index := p.parseExpression()
 			p.expect(lexer.RBRACKET)
-			left = &ast.IndexExpr{Object: left, Index: index}
+			left = &ast.IndexExpr{Span: p.spanFrom(start), Object: left, Index: index}
 		} else {
 			break
 		}
@@ -117,29 +126,66 @@ func isComparisonOp(t lexer.TokenType) bool {
 }
 
 func (p *Parser) parsePrimary() ast.Node {
+	start := p.startPos()
 	switch p.current.Type {
 	case lexer.STRING:
 		tok := p.current
 		p.advance()
-		return &ast.StringLiteral{Value: tok.Value}
+		return &ast.StringLiteral{Span: p.spanFrom(start), Value: tok.Value}
+
+	case lexer.MULTILINE_STRING, lexer.RAW_STRING:
+		tok := p.current
+		p.advance()
+		return &ast.StringLiteral{
+			Span:      p.spanFrom(start),
+			Value:     tok.Value,
+			Multiline: true,
+			Raw:       tok.Type == lexer.RAW_STRING,
+		}
 
 	case lexer.INT:
 		tok := p.current
 		p.advance()
-		return &ast.IntLiteral{Value: tok.Value}
+		return &ast.IntLiteral{Span: p.spanFrom(start), Value: tok.Value}
+
+	case lexer.FLOAT:
+		tok := p.current
+		p.advance()
+		return &ast.FloatLiteral{Span: p.spanFrom(start), Value: tok.Value}
+
+	case lexer.MINUS:
+		// Negative number literal: -1, -0.5
+		next := p.peek()
+		if next.Type != lexer.INT && next.Type != lexer.FLOAT {
+			p.addError("unexpected token: " + string(p.current.Type))
+			p.advance()
+			return nil
+		}
+		p.advance()
+		p.advance()
+		if next.Type == lexer.FLOAT {
+			return &ast.FloatLiteral{Span: p.spanFrom(start), Value: "-" + next.Value}
+		}
+		return &ast.IntLiteral{Span: p.spanFrom(start), Value: "-" + next.Value}
+
+	case lexer.COMMAND:
+		text := p.current.Value
+		p.advance()
+		return p.parseCommand(start, text)
 
 	case lexer.TRUE:
 		p.advance()
-		return &ast.BoolLiteral{Value: true}
+		return &ast.BoolLiteral{Span: p.spanFrom(start), Value: true}
 
 	case lexer.FALSE:
 		p.advance()
-		return &ast.BoolLiteral{Value: false}
+		return &ast.BoolLiteral{Span: p.spanFrom(start), Value: false}
 
 	case lexer.BANG:
+		// Binds looser than postfix: !s.contains("x") negates the call
 		p.advance()
-		operand := p.parsePrimary()
-		return &ast.UnaryExpr{Op: "!", Operand: operand}
+		operand := p.parseUnary()
+		return &ast.UnaryExpr{Span: p.spanFrom(start), Op: "!", Operand: operand}
 
 	case lexer.LPAREN:
 		// Check if this is a function call (handled elsewhere) or grouped expression
@@ -153,11 +199,14 @@ func (p *Parser) parsePrimary() ast.Node {
 		return p.parseListLiteral()
 
 	case lexer.LBRACE:
-		// Distinguish map literal from block: { ident/string : ... } is a map
+		// Distinguish map literal from block: { ident/string : ... } or {} is a map
 		nextType := p.peek().Type
 		if (nextType == lexer.IDENT || nextType == lexer.STRING) && p.peekAt(2).Type == lexer.COLON {
 			return p.parseMapLiteral()
 		}
+		if nextType == lexer.RBRACE {
+			return p.parseMapLiteral()
+		}
 		return nil
 
 	case lexer.IDENT:
@@ -166,7 +215,7 @@ func (p *Parser) parsePrimary() ast.Node {
 		}
 		tok := p.current
 		p.advance()
-		return &ast.Identifier{Name: tok.Value}
+		return &ast.Identifier{Span: p.spanFrom(start), Name: tok.Value}
 
 	case lexer.ILLEGAL:
 		p.addError(p.current.Value)
@@ -180,7 +229,15 @@ func (p *Parser) parsePrimary() ast.Node {
 }
 
 func (p *Parser) parseFuncCall() *ast.FuncCall {
+	start := p.startPos()
 	name := p.expect(lexer.IDENT)
+	args, kwargs := p.parseCallArgs()
+	return &ast.FuncCall{Span: p.spanFrom(start), Name: name.Value, Args: args, KwArgs: kwargs}
+}
+
+// parseCallArgs parses a parenthesized argument list: positional arguments
+// followed by keyword arguments.
+func (p *Parser) parseCallArgs() ([]ast.Node, []ast.KeywordArg) {
 	p.expect(lexer.LPAREN)
 
 	var args []ast.Node
@@ -191,10 +248,11 @@ func (p *Parser) parseFuncCall() *ast.FuncCall {
 		// Detect keyword arg: IDENT followed by COLON
 		if p.current.Type == lexer.IDENT && p.peek().Type == lexer.COLON {
 			seenKwarg = true
+			kwStart := p.startPos()
 			key := p.expect(lexer.IDENT)
 			p.expect(lexer.COLON)
 			value := p.parseExpression()
-			kwargs = append(kwargs, ast.KeywordArg{Key: key.Value, Value: value})
+			kwargs = append(kwargs, ast.KeywordArg{Span: p.spanFrom(kwStart), Key: key.Value, Value: value})
 		} else {
 			if seenKwarg {
 				p.addError("positional argument after keyword argument")
@@ -210,28 +268,16 @@ func (p *Parser) parseFuncCall() *ast.FuncCall {
 	}
 
 	p.expect(lexer.RPAREN)
-	return &ast.FuncCall{Name: name.Value, Args: args, KwArgs: kwargs}
+	return args, kwargs
 }
 
-func (p *Parser) parseMethodCallArgs(object ast.Node, method string) *ast.MethodCall {
-	p.expect(lexer.LPAREN)
-
-	var args []ast.Node
-	for p.current.Type != lexer.RPAREN && p.current.Type != lexer.EOF {
-		arg := p.parseExpression()
-		if arg != nil {
-			args = append(args, arg)
-		}
-		if p.current.Type == lexer.COMMA {
-			p.advance()
-		}
-	}
-
-	p.expect(lexer.RPAREN)
-	return &ast.MethodCall{Object: object, Method: method, Args: args}
+func (p *Parser) parseMethodCallArgs(start ast.Pos, object ast.Node, method string) *ast.MethodCall {
+	args, kwargs := p.parseCallArgs()
+	return &ast.MethodCall{Span: p.spanFrom(start), Object: object, Method: method, Args: args, KwArgs: kwargs}
 }
 
 func (p *Parser) parseListLiteral() *ast.ListLiteral {
+	start := p.startPos()
 	p.expect(lexer.LBRACKET)
 
 	var elements []ast.Node
@@ -246,10 +292,11 @@ func (p *Parser) parseListLiteral() *ast.ListLiteral {
 	}
 
 	p.expect(lexer.RBRACKET)
-	return &ast.ListLiteral{Elements: elements}
+	return &ast.ListLiteral{Span: p.spanFrom(start), Elements: elements}
 }
 
 func (p *Parser) parseMapLiteral() *ast.MapLiteral {
+	start := p.startPos()
 	p.expect(lexer.LBRACE)
 
 	var keys []string
@@ -275,20 +322,22 @@ func (p *Parser) parseMapLiteral() *ast.MapLiteral {
 	}
 
 	p.expect(lexer.RBRACE)
-	return &ast.MapLiteral{Keys: keys, Values: values}
+	return &ast.MapLiteral{Span: p.spanFrom(start), Keys: keys, Values: values}
 }
 
 func (p *Parser) parseOrFallback() ast.Node {
+	start := p.startPos()
+
 	// or { block }
 	if p.current.Type == lexer.LBRACE {
 		stmts := p.parseBlock()
-		return &ast.BlockExpr{Statements: stmts}
+		return &ast.BlockExpr{Span: p.spanFrom(start), Statements: stmts}
 	}
 
 	// or continue
 	if p.current.Type == lexer.CONTINUE {
 		p.advance()
-		return &ast.ContinueStmt{}
+		return &ast.ContinueStmt{Span: p.spanFrom(start)}
 	}
 
 	// or return expr
@@ -19,7 +19,9 @@ type Parser struct {
 	tokens  []lexer.Token
 	pos     int
 	current lexer.Token
+	lastEnd ast.Pos // end of the most recently consumed token
 	errors  []ParseError
+	file    string // recorded in every span
 }
 
 // New creates a new Parser from a slice of tokens.
@@ -31,7 +33,16 @@ func New(tokens []lexer.Token) *Parser {
 	return p
 }
 
+// NewFile creates a Parser whose node spans record the file the tokens
+// came from, so errors in imported files can point into them.
+func NewFile(tokens []lexer.Token, file string) *Parser {
+	p := New(tokens)
+	p.file = file
+	return p
+}
+
 func (p *Parser) advance() {
+	p.lastEnd = ast.Pos{Line: p.current.EndLine, Col: p.current.EndCol}
 	p.pos++
 	if p.pos < len(p.tokens) {
 		p.current = p.tokens[p.pos]
@@ -50,6 +61,25 @@ func (p *Parser) peekAt(offset int) lexer.Token {
 	return lexer.Token{Type: lexer.EOF}
 }
 
+// startPos returns the position of the current token.
+func (p *Parser) startPos() ast.Pos {
+	return ast.Pos{Line: p.current.Line, Col: p.current.Col}
+}
+
+// spanFrom returns a span from start to the end of the last consumed token.
+func (p *Parser) spanFrom(start ast.Pos) ast.Span {
+	return ast.Span{Start: start, End: p.lastEnd, File: p.file}
+}
+
+// nodeStart returns where n begins, falling back to the current token
+// when a sub-parse produced no node.
+func (p *Parser) nodeStart(n ast.Node) ast.Pos {
+	if n == nil {
+		return p.startPos()
+	}
+	return n.NodeSpan().Start
+}
+
 func (p *Parser) addError(msg string) {
 	p.errors = append(p.errors, ParseError{
 		Line:    p.current.Line,
@@ -68,6 +98,19 @@ func (p *Parser) expect(t lexer.TokenType) lexer.Token {
 	return tok
 }
 
+// parseProgram fills prog statement by statement, so a recovered panic
+// still leaves the partial program in place.
+func (p *Parser) parseProgram(prog *ast.Program) {
+	start := p.startPos()
+	for p.current.Type != lexer.EOF {
+		stmt := p.parseStatement()
+		if stmt != nil {
+			prog.Statements = append(prog.Statements, stmt)
+		}
+	}
+	prog.Span = p.spanFrom(start)
+}
+
 // ParseWithErrors parses tokens and returns the first error.
 func (p *Parser) ParseWithErrors() (prog *ast.Program, err error) {
 	defer func() {
@@ -79,13 +122,7 @@ func (p *Parser) ParseWithErrors() (prog *ast.Program, err error) {
 		}
 	}()
 	prog = &ast.Program{}
-
-	for p.current.Type != lexer.EOF {
-		stmt := p.parseStatement()
-		if stmt != nil {
-			prog.Statements = append(prog.Statements, stmt)
-		}
-	}
+	p.parseProgram(prog)
 
 	if len(p.errors) > 0 {
 		e := p.errors[0]
@@ -110,13 +147,7 @@ func (p *Parser) ParseAllErrors() (prog *ast.Program, errs []ParseError) {
 		}
 	}()
 	prog = &ast.Program{}
-
-	for p.current.Type != lexer.EOF {
-		stmt := p.parseStatement()
-		if stmt != nil {
-			prog.Statements = append(prog.Statements, stmt)
-		}
-	}
+	p.parseProgram(prog)
 
 	return prog, p.errors
 }
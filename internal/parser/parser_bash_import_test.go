@@ -6,6 +6,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/tasnimzotder/langz/internal/ast"
+	"github.com/tasnimzotder/langz/internal/lexer"
 )
 
 func TestParseBashBlock(t *testing.T) {
@@ -44,3 +45,43 @@ func TestParseImportAndCode(t *testing.T) {
 	_, ok = prog.Statements[1].(*ast.FuncCall)
 	require.True(t, ok, "expected FuncCall")
 }
+
+func TestParseImportAs(t *testing.T) {
+	prog := parse("import \"lib/k8s.lz\" as k8s\nk8s.apply(\"app.yaml\", wait: true)")
+
+	require.Len(t, prog.Statements, 2)
+	imp := prog.Statements[0].(*ast.ImportStmt)
+	assert.Equal(t, "lib/k8s.lz", imp.Path)
+	assert.Equal(t, "k8s", imp.Alias)
+
+	call, ok := prog.Statements[1].(*ast.MethodCall)
+	require.True(t, ok, "expected MethodCall")
+	assert.Equal(t, "apply", call.Method)
+	assert.Len(t, call.Args, 1)
+	require.Len(t, call.KwArgs, 1)
+	assert.Equal(t, "wait", call.KwArgs[0].Key)
+}
+
+func TestParseFromImport(t *testing.T) {
+	prog := parse("from \"lib/util.lz\" import retry, log\nfrom = \"me\"")
+
+	require.Len(t, prog.Statements, 2)
+	imp, ok := prog.Statements[0].(*ast.ImportStmt)
+	require.True(t, ok, "expected ImportStmt")
+	assert.Equal(t, "lib/util.lz", imp.Path)
+	assert.Equal(t, []string{"retry", "log"}, imp.Names)
+
+	// from is only special before a path
+	_, ok = prog.Statements[1].(*ast.Assignment)
+	assert.True(t, ok, "expected Assignment")
+}
+
+func TestParseNewFileRecordsFile(t *testing.T) {
+	prog, err := New(lexer.New("x = 1").Tokenize()).ParseWithErrors()
+	require.NoError(t, err)
+	assert.Equal(t, "", prog.Statements[0].NodeSpan().File)
+
+	prog, err = NewFile(lexer.New("x = 1").Tokenize(), "lib.lz").ParseWithErrors()
+	require.NoError(t, err)
+	assert.Equal(t, "lib.lz", prog.Statements[0].NodeSpan().File)
+}
@@ -34,6 +34,33 @@ func TestAssignInt(t *testing.T) {
 	assert.Equal(t, "42", num.Value)
 }
 
+func TestAssignFloat(t *testing.T) {
+	prog := parse(`ratio = 12.5`)
+
+	require.Len(t, prog.Statements, 1)
+	assign := prog.Statements[0].(*ast.Assignment)
+
+	num, ok := assign.Value.(*ast.FloatLiteral)
+	require.True(t, ok, "expected FloatLiteral")
+	assert.Equal(t, "12.5", num.Value)
+	assert.Equal(t, ast.Pos{Line: 1, Col: 13}, num.Span.End)
+}
+
+func TestAssignMultilineString(t *testing.T) {
+	prog := parse("conf = \"\"\"\n    a\n    b\n    \"\"\"\nraw = r\"\"\"{x}\"\"\"")
+
+	require.Len(t, prog.Statements, 2)
+	text := prog.Statements[0].(*ast.Assignment).Value.(*ast.StringLiteral)
+	assert.Equal(t, "a\nb", text.Value)
+	assert.True(t, text.Multiline)
+	assert.False(t, text.Raw)
+	assert.Equal(t, ast.Pos{Line: 4, Col: 8}, text.Span.End)
+
+	raw := prog.Statements[1].(*ast.Assignment).Value.(*ast.StringLiteral)
+	assert.Equal(t, "{x}", raw.Value)
+	assert.True(t, raw.Raw)
+}
+
 func TestAssignBool(t *testing.T) {
 	prog := parse(`ok = true`)
 
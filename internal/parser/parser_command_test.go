@@ -0,0 +1,48 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tasnimzotder/langz/internal/ast"
+	"github.com/tasnimzotder/langz/internal/lexer"
+)
+
+func TestParseCommandLiteral(t *testing.T) {
+	prog := parse(`$ git commit -m "Release {tag}" '{raw}' {files} x{y}`)
+	require.Len(t, prog.Statements, 1)
+	call, ok := prog.Statements[0].(*ast.FuncCall)
+	require.True(t, ok, "expected FuncCall")
+	assert.Equal(t, "run", call.Name)
+	require.Len(t, call.Args, 7)
+
+	assert.Equal(t, "git", call.Args[0].(*ast.StringLiteral).Value)
+	assert.Equal(t, "Release {tag}", call.Args[3].(*ast.StringLiteral).Value)
+	raw := call.Args[4].(*ast.StringLiteral)
+	assert.True(t, raw.Raw)
+	assert.Equal(t, "{raw}", raw.Value)
+	assert.Equal(t, "files", call.Args[5].(*ast.Identifier).Name)
+	assert.Equal(t, "x{y}", call.Args[6].(*ast.StringLiteral).Value)
+}
+
+func TestParseCommandLiteralAssignment(t *testing.T) {
+	prog := parse("r = $ git status")
+	assign := prog.Statements[0].(*ast.Assignment)
+	call, ok := assign.Value.(*ast.FuncCall)
+	require.True(t, ok, "expected FuncCall")
+	assert.Equal(t, "run", call.Name)
+	assert.Len(t, call.Args, 2)
+}
+
+func TestParseCommandLiteralErrors(t *testing.T) {
+	for input, want := range map[string]string{
+		"$ echo 'open":  "unterminated quote in command",
+		"$ # just this": "expected a command after $",
+	} {
+		_, err := New(lexer.New(input).Tokenize()).ParseWithErrors()
+		require.Error(t, err, input)
+		assert.Contains(t, err.Error(), want, input)
+	}
+}
@@ -192,3 +192,83 @@ func TestOrWithContinue(t *testing.T) {
 	_, ok = orExpr.Fallback.(*ast.ContinueStmt)
 	require.True(t, ok, "expected ContinueStmt fallback")
 }
+
+func TestMatchWithRegexArm(t *testing.T) {
+	input := `match line {
+		matches("^ERR") => print("error")
+		matches("^WARN ([a-z]+)") => print("warn")
+		_ => print("other")
+	}`
+	prog := parse(input)
+
+	require.Len(t, prog.Statements, 1)
+	m, ok := prog.Statements[0].(*ast.MatchStmt)
+	require.True(t, ok, "expected MatchStmt")
+	require.Len(t, m.Cases, 3)
+
+	call, ok := m.Cases[0].Pattern.(*ast.FuncCall)
+	require.True(t, ok, "expected FuncCall pattern")
+	assert.Equal(t, "matches", call.Name)
+	require.Len(t, call.Args, 1)
+	assert.Len(t, m.Cases[0].Body, 1)
+	assert.Len(t, m.Cases[1].Body, 1)
+	assert.Nil(t, m.Cases[2].Pattern)
+}
+
+func TestParseTryCatchFinally(t *testing.T) {
+	prog := parse("try {\n\tx = 1\n} catch err {\n\tprint(err.code)\n} finally {\n\tprint(\"done\")\n}")
+	require.Len(t, prog.Statements, 1)
+
+	try, ok := prog.Statements[0].(*ast.TryStmt)
+	require.True(t, ok, "expected TryStmt")
+	assert.Len(t, try.Body, 1)
+	assert.True(t, try.HasCatch)
+	assert.Equal(t, "err", try.ErrVar)
+	assert.Len(t, try.Catch, 1)
+	assert.Len(t, try.Finally, 1)
+}
+
+func TestParseTryCatchWithoutVariable(t *testing.T) {
+	prog := parse("try {\n\tx = 1\n} catch {\n\tprint(\"failed\")\n}")
+
+	try := prog.Statements[0].(*ast.TryStmt)
+	assert.True(t, try.HasCatch)
+	assert.Equal(t, "", try.ErrVar)
+	assert.Nil(t, try.Finally)
+}
+
+func TestParseDeferStatement(t *testing.T) {
+	prog := parse("defer rm(tmp)")
+	require.Len(t, prog.Statements, 1)
+
+	d, ok := prog.Statements[0].(*ast.DeferStmt)
+	require.True(t, ok, "expected DeferStmt")
+	require.Len(t, d.Body, 1)
+	call, ok := d.Body[0].(*ast.FuncCall)
+	require.True(t, ok, "expected FuncCall")
+	assert.Equal(t, "rm", call.Name)
+}
+
+func TestParseWithStatement(t *testing.T) {
+	prog := parse("with dir = tempdir() {\n\tprint(dir)\n}\nwith = 1\nprint(with)")
+	require.Len(t, prog.Statements, 3)
+
+	w, ok := prog.Statements[0].(*ast.WithStmt)
+	require.True(t, ok, "expected WithStmt")
+	assert.Equal(t, "dir", w.Var)
+	call, ok := w.Value.(*ast.FuncCall)
+	require.True(t, ok, "expected FuncCall")
+	assert.Equal(t, "tempdir", call.Name)
+	assert.Len(t, w.Body, 1)
+
+	_, ok = prog.Statements[1].(*ast.Assignment)
+	assert.True(t, ok, "with stays usable as a name")
+}
+
+func TestParseDeferBlock(t *testing.T) {
+	prog := parse("defer {\n\trm(tmp)\n\tprint(\"done\")\n}\nx = 1")
+	require.Len(t, prog.Statements, 2)
+
+	d := prog.Statements[0].(*ast.DeferStmt)
+	assert.Len(t, d.Body, 2)
+}
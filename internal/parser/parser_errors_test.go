@@ -88,3 +88,11 @@ func TestUnknownTokenInStatementProducesError(t *testing.T) {
 	require.GreaterOrEqual(t, len(errs), 1)
 	assert.Contains(t, errs[0].Message, "unexpected token")
 }
+
+func TestParseTryNeedsCatchOrFinally(t *testing.T) {
+	tokens := lexer.New("try {\n\tx = 1\n}").Tokenize()
+	_, errs := New(tokens).ParseAllErrors()
+
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Message, "expected catch or finally")
+}
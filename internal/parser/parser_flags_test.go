@@ -0,0 +1,58 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tasnimzotder/langz/internal/ast"
+	"github.com/tasnimzotder/langz/internal/lexer"
+)
+
+func TestParseFlagsOneLine(t *testing.T) {
+	prog := parse(`flags { env: str = "staging", replicas: int = 2, dry_run: bool, targets: list }`)
+	require.Len(t, prog.Statements, 1)
+	decl, ok := prog.Statements[0].(*ast.FlagsDecl)
+	require.True(t, ok, "expected FlagsDecl")
+	require.Len(t, decl.Flags, 4)
+
+	assert.Equal(t, "env", decl.Flags[0].Name)
+	assert.Equal(t, "str", decl.Flags[0].Type)
+	assert.Equal(t, "staging", decl.Flags[0].Default.(*ast.StringLiteral).Value)
+	assert.Equal(t, "2", decl.Flags[1].Default.(*ast.IntLiteral).Value)
+	assert.Equal(t, "bool", decl.Flags[2].Type)
+	assert.Nil(t, decl.Flags[2].Default)
+	assert.Equal(t, "list", decl.Flags[3].Type)
+}
+
+func TestParseFlagsModifiers(t *testing.T) {
+	prog := parse(`flags {
+    token: str required env "API_TOKEN" "API token to use"
+    region: str = "eu" "Region" env "REGION"
+}
+print(token)`)
+	require.Len(t, prog.Statements, 2)
+	decl := prog.Statements[0].(*ast.FlagsDecl)
+	require.Len(t, decl.Flags, 2)
+
+	token := decl.Flags[0]
+	assert.True(t, token.Required)
+	assert.Equal(t, "API_TOKEN", token.Env)
+	assert.Equal(t, "API token to use", token.Help)
+	assert.Equal(t, "REGION", decl.Flags[1].Env)
+	assert.Equal(t, "Region", decl.Flags[1].Help)
+}
+
+func TestFlagsIsAnOrdinaryName(t *testing.T) {
+	prog := parse("flags = [\"-v\"]\nprint(flags)")
+	require.Len(t, prog.Statements, 2)
+	assert.Equal(t, "flags", prog.Statements[0].(*ast.Assignment).Name)
+}
+
+func TestParseFlagsErrors(t *testing.T) {
+	tokens := lexer.New("flags { env str }").Tokenize()
+	_, errs := New(tokens).ParseAllErrors()
+	require.NotEmpty(t, errs)
+	assert.Contains(t, errs[0].Message, "expected COLON")
+}
@@ -46,3 +46,54 @@ func TestParseIndexAssign(t *testing.T) {
 	require.True(t, ok, "expected IndexAssignment")
 	assert.Equal(t, "arr", ia.Object)
 }
+
+func TestParseChainedPath(t *testing.T) {
+	prog := parse(`val = data.items[0].name`)
+	require.Len(t, prog.Statements, 1)
+	assign := prog.Statements[0].(*ast.Assignment)
+
+	dot, ok := assign.Value.(*ast.DotExpr)
+	require.True(t, ok, "expected DotExpr")
+	assert.Equal(t, "name", dot.Field)
+
+	idx, ok := dot.Object.(*ast.IndexExpr)
+	require.True(t, ok, "expected IndexExpr")
+	items, ok := idx.Object.(*ast.DotExpr)
+	require.True(t, ok, "expected DotExpr")
+	assert.Equal(t, "items", items.Field)
+}
+
+func TestParsePathAssign(t *testing.T) {
+	prog := parse("data.items[0].name = \"x\"\nprint(data)")
+	require.Len(t, prog.Statements, 2)
+
+	pa, ok := prog.Statements[0].(*ast.PathAssignment)
+	require.True(t, ok, "expected PathAssignment")
+	dot, ok := pa.Target.(*ast.DotExpr)
+	require.True(t, ok, "expected DotExpr")
+	assert.Equal(t, "name", dot.Field)
+
+	val, ok := pa.Value.(*ast.StringLiteral)
+	require.True(t, ok)
+	assert.Equal(t, "x", val.Value)
+}
+
+func TestParsePathAssignFromIndex(t *testing.T) {
+	prog := parse(`data["items"][0] = 1`)
+	require.Len(t, prog.Statements, 1)
+
+	pa, ok := prog.Statements[0].(*ast.PathAssignment)
+	require.True(t, ok, "expected PathAssignment")
+	idx, ok := pa.Target.(*ast.IndexExpr)
+	require.True(t, ok, "expected IndexExpr")
+	_, ok = idx.Object.(*ast.IndexExpr)
+	assert.True(t, ok, "expected nested IndexExpr")
+}
+
+func TestParseMethodCallStatementAfterDot(t *testing.T) {
+	prog := parse(`s.matches("x")`)
+	require.Len(t, prog.Statements, 1)
+
+	_, ok := prog.Statements[0].(*ast.MethodCall)
+	assert.True(t, ok, "expected MethodCall")
+}
@@ -40,3 +40,23 @@ func TestMapLiteralStringKeyInKwarg(t *testing.T) {
 	require.True(t, ok, "expected MapLiteral as kwarg value")
 	assert.Equal(t, "Authorization", m.Keys[0])
 }
+
+func TestEmptyMapLiteral(t *testing.T) {
+	prog := parse(`m = {}`)
+
+	assign := prog.Statements[0].(*ast.Assignment)
+	m, ok := assign.Value.(*ast.MapLiteral)
+	require.True(t, ok, "expected MapLiteral")
+	assert.Empty(t, m.Keys)
+}
+
+func TestForKeyValue(t *testing.T) {
+	prog := parse("for key, value in config {\n\tprint(key)\n}")
+
+	require.Len(t, prog.Statements, 1)
+	f, ok := prog.Statements[0].(*ast.ForStmt)
+	require.True(t, ok, "expected ForStmt")
+	assert.Equal(t, "key", f.Var)
+	assert.Equal(t, "value", f.ValueVar)
+	require.Len(t, f.Body, 1)
+}
@@ -19,6 +19,27 @@ func TestNegation(t *testing.T) {
 	assert.Equal(t, "!", unary.Op)
 }
 
+func TestNegationOfMethodCall(t *testing.T) {
+	prog := parse(`if !name.contains("x") { print("no x") }`)
+
+	ifStmt := prog.Statements[0].(*ast.IfStmt)
+	unary, ok := ifStmt.Condition.(*ast.UnaryExpr)
+	require.True(t, ok, "expected UnaryExpr")
+	method, ok := unary.Operand.(*ast.MethodCall)
+	require.True(t, ok, "expected MethodCall operand")
+	assert.Equal(t, "contains", method.Method)
+}
+
+func TestNegativeNumbers(t *testing.T) {
+	prog := parse("x = -1\ny = -0.5\nz = 3 - -2")
+
+	assert.Equal(t, "-1", prog.Statements[0].(*ast.Assignment).Value.(*ast.IntLiteral).Value)
+	assert.Equal(t, "-0.5", prog.Statements[1].(*ast.Assignment).Value.(*ast.FloatLiteral).Value)
+	bin := prog.Statements[2].(*ast.Assignment).Value.(*ast.BinaryExpr)
+	assert.Equal(t, "-", bin.Op)
+	assert.Equal(t, "-2", bin.Right.(*ast.IntLiteral).Value)
+}
+
 func TestComparisonOperators(t *testing.T) {
 	tests := []struct {
 		input string
@@ -95,3 +95,27 @@ func TestParsePipeWithOr(t *testing.T) {
 	require.True(t, ok, "expected BinaryExpr inside OrExpr")
 	assert.Equal(t, "|>", pipe.Op)
 }
+
+func TestParsePipeStatement(t *testing.T) {
+	prog := parse("read(\"app.log\") |> cmd(\"grep\", \"ERROR\")\n$ journalctl -u app |> $ grep ERROR\nprint(\"x\")")
+	require.Len(t, prog.Statements, 3)
+	for _, stmt := range prog.Statements[:2] {
+		pipe, ok := stmt.(*ast.BinaryExpr)
+		require.True(t, ok, "expected BinaryExpr")
+		assert.Equal(t, "|>", pipe.Op)
+	}
+	right := prog.Statements[1].(*ast.BinaryExpr).Right.(*ast.FuncCall)
+	assert.Equal(t, "run", right.Name)
+	assert.Len(t, right.Args, 2)
+}
+
+func TestParsePipeInConditionAndFor(t *testing.T) {
+	prog := parse("if data |> cmd(\"grep\", \"-q\", \"x\") {\n\tprint(\"y\")\n}\nfor l in data |> lines() {\n\tprint(l)\n}")
+	require.Len(t, prog.Statements, 2)
+	cond, ok := prog.Statements[0].(*ast.IfStmt).Condition.(*ast.BinaryExpr)
+	require.True(t, ok, "expected BinaryExpr condition")
+	assert.Equal(t, "|>", cond.Op)
+	coll, ok := prog.Statements[1].(*ast.ForStmt).Collection.(*ast.BinaryExpr)
+	require.True(t, ok, "expected BinaryExpr collection")
+	assert.Equal(t, "|>", coll.Op)
+}
@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tasnimzotder/langz/internal/ast"
+)
+
+func TestPositionAssignment(t *testing.T) {
+	prog := parse(`name = "hello"`)
+	require.Len(t, prog.Statements, 1)
+
+	assign := prog.Statements[0].(*ast.Assignment)
+	assert.Equal(t, ast.Pos{Line: 1, Col: 1}, assign.Span.Start)
+	assert.Equal(t, ast.Pos{Line: 1, Col: 15}, assign.Span.End)
+
+	str := assign.Value.(*ast.StringLiteral)
+	assert.Equal(t, ast.Pos{Line: 1, Col: 8}, str.Span.Start)
+	assert.Equal(t, ast.Pos{Line: 1, Col: 15}, str.Span.End)
+}
+
+func TestPositionBinaryExprStartsAtLeftOperand(t *testing.T) {
+	prog := parse(`x = a + b * 2`)
+	assign := prog.Statements[0].(*ast.Assignment)
+
+	bin := assign.Value.(*ast.BinaryExpr)
+	assert.Equal(t, ast.Pos{Line: 1, Col: 5}, bin.Span.Start)
+	assert.Equal(t, ast.Pos{Line: 1, Col: 14}, bin.Span.End)
+
+	right := bin.Right.(*ast.BinaryExpr)
+	assert.Equal(t, ast.Pos{Line: 1, Col: 9}, right.Span.Start)
+}
+
+func TestPositionMultilineFuncDecl(t *testing.T) {
+	prog := parse("fn greet(name: str) {\n    print(name)\n}")
+	fn := prog.Statements[0].(*ast.FuncDecl)
+
+	assert.Equal(t, ast.Pos{Line: 1, Col: 1}, fn.Span.Start)
+	assert.Equal(t, ast.Pos{Line: 3, Col: 2}, fn.Span.End)
+	assert.Equal(t, ast.Pos{Line: 1, Col: 10}, fn.Params[0].Span.Start)
+
+	call := fn.Body[0].(*ast.FuncCall)
+	assert.Equal(t, ast.Pos{Line: 2, Col: 5}, call.Span.Start)
+	assert.Equal(t, ast.Pos{Line: 2, Col: 16}, call.Span.End)
+}
+
+func TestPositionKeywordArgAndMethodCall(t *testing.T) {
+	prog := parse("res = fetch(url, method: \"POST\")\nok = name.contains(\"x\")")
+
+	call := prog.Statements[0].(*ast.Assignment).Value.(*ast.FuncCall)
+	require.Len(t, call.KwArgs, 1)
+	assert.Equal(t, ast.Pos{Line: 1, Col: 18}, call.KwArgs[0].Span.Start)
+
+	mc := prog.Statements[1].(*ast.Assignment).Value.(*ast.MethodCall)
+	assert.Equal(t, ast.Pos{Line: 2, Col: 6}, mc.Span.Start)
+	assert.Equal(t, ast.Pos{Line: 2, Col: 24}, mc.Span.End)
+}
+
+func TestPositionOrExprAndMatchCase(t *testing.T) {
+	prog := parse("x = read(f) or \"d\"\nmatch x {\n    \"a\" => print(1)\n    _ => print(2)\n}")
+
+	or := prog.Statements[0].(*ast.Assignment).Value.(*ast.OrExpr)
+	assert.Equal(t, ast.Pos{Line: 1, Col: 5}, or.Span.Start)
+
+	m := prog.Statements[1].(*ast.MatchStmt)
+	require.Len(t, m.Cases, 2)
+	assert.Equal(t, 3, m.Cases[0].Span.Start.Line)
+	assert.Equal(t, ast.Pos{Line: 4, Col: 5}, m.Cases[1].Span.Start)
+	assert.Equal(t, ast.Pos{Line: 5, Col: 2}, m.Span.End)
+}
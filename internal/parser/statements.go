@@ -20,18 +20,33 @@ func (p *Parser) parseStatement() ast.Node {
 	case lexer.RETURN:
 		return p.parseReturn()
 	case lexer.CONTINUE:
+		start := p.startPos()
 		p.advance()
-		return &ast.ContinueStmt{}
+		return &ast.ContinueStmt{Span: p.spanFrom(start)}
 	case lexer.BREAK:
+		start := p.startPos()
 		p.advance()
-		return &ast.BreakStmt{}
+		return &ast.BreakStmt{Span: p.spanFrom(start)}
 	case lexer.WHILE:
 		return p.parseWhile()
 	case lexer.BASH:
 		return p.parseBashBlock()
 	case lexer.IMPORT:
 		return p.parseImport()
+	case lexer.TRY:
+		return p.parseTry()
+	case lexer.DEFER:
+		return p.parseDefer()
 	case lexer.IDENT:
+		if p.current.Value == "from" && p.peek().Type == lexer.STRING {
+			return p.parseFromImport()
+		}
+		if p.current.Value == "flags" && p.peek().Type == lexer.LBRACE {
+			return p.parseFlags()
+		}
+		if p.current.Value == "with" && p.peek().Type == lexer.IDENT {
+			return p.parseWith()
+		}
 		if p.peek().Type == lexer.ASSIGN {
 			return p.parseAssignment()
 		}
@@ -42,11 +57,18 @@ func (p *Parser) parseStatement() ast.Node {
 			return p.parseIndexOrExpr()
 		}
 		if p.peek().Type == lexer.LPAREN {
-			return p.parseFuncCall()
+			call := p.parseFuncCall()
+			if p.current.Type == lexer.PIPE {
+				return p.parsePipeRest(call)
+			}
+			return call
+		}
+		if p.peek().Type == lexer.DOT {
+			return p.parsePathOrExpr()
 		}
-		return p.parseExpression()
-	case lexer.STRING, lexer.INT, lexer.TRUE, lexer.FALSE, lexer.BANG:
-		return p.parseExpression()
+		return p.parsePipeExpr()
+	case lexer.STRING, lexer.MULTILINE_STRING, lexer.RAW_STRING, lexer.INT, lexer.FLOAT, lexer.TRUE, lexer.FALSE, lexer.BANG, lexer.COMMAND:
+		return p.parsePipeExpr()
 	case lexer.ILLEGAL:
 		p.addError(p.current.Value)
 		p.advance()
@@ -59,18 +81,20 @@ func (p *Parser) parseStatement() ast.Node {
 }
 
 func (p *Parser) parseAssignment() *ast.Assignment {
+	start := p.startPos()
 	name := p.expect(lexer.IDENT)
 	p.expect(lexer.ASSIGN)
 
 	value := p.parsePipeExpr()
 
 	if p.current.Type == lexer.OR {
+		orStart := p.nodeStart(value)
 		p.advance()
 		fallback := p.parseOrFallback()
-		value = &ast.OrExpr{Expr: value, Fallback: fallback}
+		value = &ast.OrExpr{Span: p.spanFrom(orStart), Expr: value, Fallback: fallback}
 	}
 
-	return &ast.Assignment{Name: name.Value, Value: value}
+	return &ast.Assignment{Span: p.spanFrom(start), Name: name.Value, Value: value}
 }
 
 func (p *Parser) parseBlock() []ast.Node {
@@ -89,6 +113,7 @@ func (p *Parser) parseBlock() []ast.Node {
 }
 
 func (p *Parser) parseIf() *ast.IfStmt {
+	start := p.startPos()
 	p.expect(lexer.IF)
 
 	condition := p.parseCondition()
@@ -106,31 +131,140 @@ func (p *Parser) parseIf() *ast.IfStmt {
 		}
 	}
 
-	return &ast.IfStmt{Condition: condition, Body: body, ElseBody: elseBody}
+	return &ast.IfStmt{Span: p.spanFrom(start), Condition: condition, Body: body, ElseBody: elseBody}
 }
 
 func (p *Parser) parseFor() *ast.ForStmt {
+	start := p.startPos()
 	p.expect(lexer.FOR)
 
 	varName := p.expect(lexer.IDENT)
+	var valueVar string
+	if p.current.Type == lexer.COMMA {
+		p.advance()
+		valueVar = p.expect(lexer.IDENT).Value
+	}
 	p.expect(lexer.IN)
 
-	collection := p.parseExpression()
+	collection := p.parsePipeExpr()
 	body := p.parseBlock()
 
-	return &ast.ForStmt{Var: varName.Value, Collection: collection, Body: body}
+	return &ast.ForStmt{Span: p.spanFrom(start), Var: varName.Value, ValueVar: valueVar, Collection: collection, Body: body}
 }
 
 func (p *Parser) parseWhile() *ast.WhileStmt {
+	start := p.startPos()
 	p.expect(lexer.WHILE)
 
 	condition := p.parseCondition()
 	body := p.parseBlock()
 
-	return &ast.WhileStmt{Condition: condition, Body: body}
+	return &ast.WhileStmt{Span: p.spanFrom(start), Condition: condition, Body: body}
+}
+
+func (p *Parser) parseTry() *ast.TryStmt {
+	start := p.startPos()
+	p.expect(lexer.TRY)
+	stmt := &ast.TryStmt{Body: p.parseBlock()}
+
+	if p.current.Type == lexer.CATCH {
+		p.advance()
+		stmt.HasCatch = true
+		if p.current.Type == lexer.IDENT {
+			stmt.ErrVar = p.current.Value
+			p.advance()
+		}
+		stmt.Catch = p.parseBlock()
+	}
+	hasFinally := p.current.Type == lexer.FINALLY
+	if hasFinally {
+		p.advance()
+		stmt.Finally = p.parseBlock()
+	}
+	if !stmt.HasCatch && !hasFinally {
+		p.addError("expected catch or finally after try block")
+	}
+
+	stmt.Span = p.spanFrom(start)
+	return stmt
+}
+
+// parseFlags parses a flags block. Like as in imports, flags and the
+// required and env modifiers are only special here, so they stay usable as
+// names elsewhere.
+func (p *Parser) parseFlags() *ast.FlagsDecl {
+	start := p.startPos()
+	p.advance() // skip flags
+	p.expect(lexer.LBRACE)
+
+	var flags []ast.Flag
+	for p.current.Type != lexer.RBRACE && p.current.Type != lexer.EOF {
+		flagStart := p.startPos()
+		flag := ast.Flag{Name: p.expect(lexer.IDENT).Value}
+		p.expect(lexer.COLON)
+		flag.Type = p.expect(lexer.IDENT).Value
+		if p.current.Type == lexer.ASSIGN {
+			p.advance()
+			flag.Default = p.parseExpression()
+		}
+	modifiers:
+		for {
+			switch {
+			case p.current.Type == lexer.STRING:
+				flag.Help = p.current.Value
+				p.advance()
+			case p.current.Type == lexer.IDENT && p.current.Value == "required" && p.peek().Type != lexer.COLON:
+				flag.Required = true
+				p.advance()
+			case p.current.Type == lexer.IDENT && p.current.Value == "env" && p.peek().Type == lexer.STRING:
+				p.advance()
+				flag.Env = p.current.Value
+				p.advance()
+			default:
+				break modifiers
+			}
+		}
+		flag.Span = p.spanFrom(flagStart)
+		flags = append(flags, flag)
+		if p.current.Type == lexer.COMMA {
+			p.advance()
+		} else if p.startPos() == flagStart {
+			p.advance() // skip a token that can't start a flag
+		}
+	}
+
+	p.expect(lexer.RBRACE)
+	return &ast.FlagsDecl{Span: p.spanFrom(start), Flags: flags}
+}
+
+func (p *Parser) parseDefer() *ast.DeferStmt {
+	start := p.startPos()
+	p.expect(lexer.DEFER)
+
+	var body []ast.Node
+	if p.current.Type == lexer.LBRACE {
+		body = p.parseBlock()
+	} else if stmt := p.parseStatement(); stmt != nil {
+		body = []ast.Node{stmt}
+	}
+
+	return &ast.DeferStmt{Span: p.spanFrom(start), Body: body}
+}
+
+func (p *Parser) parseWith() *ast.WithStmt {
+	start := p.startPos()
+	p.advance() // skip with
+
+	name := p.expect(lexer.IDENT)
+	p.expect(lexer.ASSIGN)
+	value := p.parsePipeExpr()
+	body := p.parseBlock()
+
+	return &ast.WithStmt{Span: p.spanFrom(start), Var: name.Value, Value: value, Body: body}
 }
 
 func (p *Parser) parseFuncDecl() *ast.FuncDecl {
+	start := p.startPos()
 	p.expect(lexer.FN)
 
 	name := p.expect(lexer.IDENT)
@@ -138,6 +272,7 @@ func (p *Parser) parseFuncDecl() *ast.FuncDecl {
 
 	var params []ast.Param
 	for p.current.Type != lexer.RPAREN && p.current.Type != lexer.EOF {
+		paramStart := p.startPos()
 		paramName := p.expect(lexer.IDENT)
 		p.expect(lexer.COLON)
 		paramType := p.expect(lexer.IDENT)
@@ -148,7 +283,12 @@ func (p *Parser) parseFuncDecl() *ast.FuncDecl {
 			defaultVal = p.parsePrimary()
 		}
 
-		params = append(params, ast.Param{Name: paramName.Value, Type: paramType.Value, Default: defaultVal})
+		params = append(params, ast.Param{
+			Span:    p.spanFrom(paramStart),
+			Name:    paramName.Value,
+			Type:    paramType.Value,
+			Default: defaultVal,
+		})
 		if p.current.Type == lexer.COMMA {
 			p.advance()
 		}
@@ -165,6 +305,7 @@ func (p *Parser) parseFuncDecl() *ast.FuncDecl {
 	body := p.parseBlock()
 
 	return &ast.FuncDecl{
+		Span:       p.spanFrom(start),
 		Name:       name.Value,
 		Params:     params,
 		ReturnType: returnType,
@@ -173,6 +314,7 @@ func (p *Parser) parseFuncDecl() *ast.FuncDecl {
 }
 
 func (p *Parser) parseMatch() *ast.MatchStmt {
+	start := p.startPos()
 	p.expect(lexer.MATCH)
 
 	expr := p.parseExpression()
@@ -181,6 +323,7 @@ func (p *Parser) parseMatch() *ast.MatchStmt {
 	var cases []ast.MatchCase
 	for p.current.Type != lexer.RBRACE && p.current.Type != lexer.EOF {
 		var pattern ast.Node
+		caseStart := p.startPos()
 
 		if p.current.Type == lexer.UNDERSCORE {
 			// Wildcard: _ => ...
@@ -205,10 +348,13 @@ func (p *Parser) parseMatch() *ast.MatchStmt {
 
 				// Peek ahead to see if this is the start of a new case
 				if (p.current.Type == lexer.STRING || p.current.Type == lexer.INT ||
-					p.current.Type == lexer.TRUE || p.current.Type == lexer.FALSE) &&
+					p.current.Type == lexer.FLOAT || p.current.Type == lexer.TRUE || p.current.Type == lexer.FALSE) &&
 					p.peek().Type == lexer.FATARROW {
 					break
 				}
+				if p.atRegexArm() {
+					break
+				}
 
 				stmt := p.parseStatement()
 				if stmt != nil {
@@ -217,16 +363,39 @@ func (p *Parser) parseMatch() *ast.MatchStmt {
 			}
 		}
 
-		cases = append(cases, ast.MatchCase{Pattern: pattern, Body: body})
+		cases = append(cases, ast.MatchCase{Span: p.spanFrom(caseStart), Pattern: pattern, Body: body})
 	}
 
 	p.expect(lexer.RBRACE)
 
-	return &ast.MatchStmt{Expr: expr, Cases: cases}
+	return &ast.MatchStmt{Span: p.spanFrom(start), Expr: expr, Cases: cases}
+}
+
+// atRegexArm reports whether the tokens ahead start a matches("re") => arm.
+func (p *Parser) atRegexArm() bool {
+	if p.current.Type != lexer.IDENT || p.current.Value != "matches" || p.peek().Type != lexer.LPAREN {
+		return false
+	}
+	depth := 0
+	for i := 1; ; i++ {
+		switch p.peekAt(i).Type {
+		case lexer.LPAREN:
+			depth++
+		case lexer.RPAREN:
+			depth--
+			if depth == 0 {
+				return p.peekAt(i+1).Type == lexer.FATARROW
+			}
+		case lexer.EOF:
+			return false
+		}
+	}
 }
 
 func (p *Parser) parseIndexOrExpr() ast.Node {
+	start := p.startPos()
 	name := p.expect(lexer.IDENT)
+	object := &ast.Identifier{Span: p.spanFrom(start), Name: name.Value}
 	p.expect(lexer.LBRACKET)
 	index := p.parseExpression()
 	p.expect(lexer.RBRACKET)
@@ -235,11 +404,39 @@ func (p *Parser) parseIndexOrExpr() ast.Node {
 		// Index assignment: arr[0] = value
 		p.advance()
 		value := p.parsePipeExpr()
-		return &ast.IndexAssignment{Object: name.Value, Index: index, Value: value}
+		return &ast.IndexAssignment{Span: p.spanFrom(start), Object: name.Value, Index: index, Value: value}
+	}
+
+	var expr ast.Node = &ast.IndexExpr{Span: p.spanFrom(start), Object: object, Index: index}
+	if p.current.Type == lexer.DOT || p.current.Type == lexer.LBRACKET {
+		expr = p.parsePostfix(start, expr)
+		if p.current.Type == lexer.ASSIGN {
+			return p.parsePathAssignment(start, expr)
+		}
 	}
 
 	// Index expression used as statement (rare but valid)
-	return &ast.IndexExpr{Object: &ast.Identifier{Name: name.Value}, Index: index}
+	return expr
+}
+
+// parsePathOrExpr parses a statement starting with ident. — either a
+// path assignment like data.items[0].name = value, or an expression.
+func (p *Parser) parsePathOrExpr() ast.Node {
+	start := p.startPos()
+	expr := p.parseExpression()
+	switch expr.(type) {
+	case *ast.DotExpr, *ast.IndexExpr:
+		if p.current.Type == lexer.ASSIGN {
+			return p.parsePathAssignment(start, expr)
+		}
+	}
+	return expr
+}
+
+func (p *Parser) parsePathAssignment(start ast.Pos, target ast.Node) *ast.PathAssignment {
+	p.expect(lexer.ASSIGN)
+	value := p.parsePipeExpr()
+	return &ast.PathAssignment{Span: p.spanFrom(start), Target: target, Value: value}
 }
 
 func isCompoundAssign(t lexer.TokenType) bool {
@@ -248,15 +445,20 @@ func isCompoundAssign(t lexer.TokenType) bool {
 }
 
 func (p *Parser) parseCompoundAssignment() *ast.Assignment {
+	start := p.startPos()
 	name := p.expect(lexer.IDENT)
+	nameSpan := p.spanFrom(start)
 	op := p.current
 	p.advance()
 	value := p.parsePipeExpr()
 	arithOp := strings.TrimSuffix(op.Value, "=")
+	span := p.spanFrom(start)
 	return &ast.Assignment{
+		Span: span,
 		Name: name.Value,
 		Value: &ast.BinaryExpr{
-			Left:  &ast.Identifier{Name: name.Value},
+			Span:  span,
+			Left:  &ast.Identifier{Span: nameSpan, Name: name.Value},
 			Op:    arithOp,
 			Right: value,
 		},
@@ -264,6 +466,7 @@ func (p *Parser) parseCompoundAssignment() *ast.Assignment {
 }
 
 func (p *Parser) parseReturn() *ast.ReturnStmt {
+	start := p.startPos()
 	p.expect(lexer.RETURN)
 
 	var value ast.Node
@@ -271,17 +474,49 @@ func (p *Parser) parseReturn() *ast.ReturnStmt {
 		value = p.parseExpression()
 	}
 
-	return &ast.ReturnStmt{Value: value}
+	return &ast.ReturnStmt{Span: p.spanFrom(start), Value: value}
 }
 
 func (p *Parser) parseBashBlock() *ast.BashBlock {
+	start := p.startPos()
 	p.expect(lexer.BASH)
 	content := p.expect(lexer.BASH_CONTENT)
-	return &ast.BashBlock{Content: content.Value}
+	return &ast.BashBlock{Span: p.spanFrom(start), Content: content.Value}
 }
 
 func (p *Parser) parseImport() *ast.ImportStmt {
+	start := p.startPos()
 	p.expect(lexer.IMPORT)
 	path := p.expect(lexer.STRING)
-	return &ast.ImportStmt{Path: path.Value}
+	stmt := &ast.ImportStmt{Path: path.Value}
+	// as is only special here, so it stays usable as a name elsewhere
+	if p.current.Type == lexer.IDENT && p.current.Value == "as" {
+		p.advance()
+		stmt.Alias = p.expect(lexer.IDENT).Value
+	}
+	stmt.Span = p.spanFrom(start)
+	return stmt
+}
+
+// parseFromImport parses from "path.lz" import a, b. Like as, from is
+// only recognized in this position.
+func (p *Parser) parseFromImport() *ast.ImportStmt {
+	start := p.startPos()
+	p.advance()
+	path := p.expect(lexer.STRING)
+	p.expect(lexer.IMPORT)
+
+	stmt := &ast.ImportStmt{Path: path.Value}
+	for {
+		name := p.expect(lexer.IDENT)
+		if name.Value != "" {
+			stmt.Names = append(stmt.Names, name.Value)
+		}
+		if p.current.Type != lexer.COMMA {
+			break
+		}
+		p.advance()
+	}
+	stmt.Span = p.spanFrom(start)
+	return stmt
 }
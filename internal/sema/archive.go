@@ -0,0 +1,47 @@
+package sema
+
+import (
+	"strings"
+
+	"github.com/tasnimzotder/langz/internal/ast"
+)
+
+// archiveFormats are the formats archive() writes, by the file name
+// suffixes that imply them, matching the runtime.
+var archiveFormats = map[string][]string{
+	"tar.gz":  {".tar.gz", ".tgz"},
+	"tar.zst": {".tar.zst", ".tzst"},
+	"tar":     {".tar"},
+	"zip":     {".zip"},
+}
+
+// checkArchive checks a literal format: and, for a literal archive name,
+// that its format can be told from the suffix. Names built at runtime are
+// left to the runtime check.
+func (c *checker) checkArchive(call *ast.FuncCall) {
+	if kw, ok := findKwarg(call, "format"); ok {
+		if lit, isLit := kw.(*ast.StringLiteral); isLit && archiveFormats[lit.Value] == nil {
+			c.errorf(kw, "unknown archive format %q, want \"tar.gz\", \"tar.zst\", \"tar\" or \"zip\"", lit.Value)
+		}
+		return
+	}
+	if len(call.Args) == 0 {
+		return
+	}
+	lit, ok := call.Args[0].(*ast.StringLiteral)
+	if !ok || (!lit.Raw && strings.Contains(lit.Value, "{")) {
+		return
+	}
+	for _, suffixes := range archiveFormats {
+		for _, suffix := range suffixes {
+			if strings.HasSuffix(lit.Value, suffix) {
+				return
+			}
+		}
+	}
+	hint := ""
+	if call.Name == "archive" {
+		hint = `; add format: "tar.gz", "tar.zst", "tar" or "zip"`
+	}
+	c.errorf(lit, "cannot tell the archive format of %q from its name, want .tar.gz, .tgz, .tar.zst, .tar or .zip%s", lit.Value, hint)
+}
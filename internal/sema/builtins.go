@@ -0,0 +1,184 @@
+package sema
+
+// signature describes how a builtin may be called.
+type signature struct {
+	params   []Type          // positional parameter types
+	required int             // number of positional args that must be given
+	variadic bool            // extra positional args of any type are allowed
+	kwargs   map[string]Type // accepted keyword arguments
+	returns  Type
+}
+
+// builtins lists every builtin function the code generator knows.
+var builtins = map[string]signature{
+	// I/O
+	"print":  {variadic: true, returns: Void},
+	"write":  {params: []Type{Str, Str}, required: 2, returns: Void},
+	"append": {params: []Type{Str, Str}, required: 2, returns: Void},
+	"read":   {params: []Type{Str}, required: 1, returns: Str},
+
+	// Templates
+	"render":    {params: []Type{Str}, required: 1, kwargs: map[string]Type{"vars": Map}, returns: Str},
+	"render_to": {params: []Type{Str, Str}, required: 2, kwargs: map[string]Type{"vars": Map}, returns: Void},
+
+	// File operations
+	"rm":    {params: []Type{Str}, required: 1, returns: Void},
+	"rmdir": {params: []Type{Str}, required: 1, returns: Void},
+	"mkdir": {params: []Type{Str}, required: 1, returns: Void},
+	"copy":  {params: []Type{Str, Str}, required: 2, returns: Void},
+	"move":  {params: []Type{Str, Str}, required: 2, returns: Void},
+	"chmod": {params: []Type{Str, Str}, required: 2, returns: Void},
+	"chown": {params: []Type{Str, Str}, required: 2, returns: Void},
+	"glob":  {params: []Type{Str}, required: 1, returns: List},
+
+	// Temp files
+	"tempfile": {kwargs: map[string]Type{"suffix": Str}, returns: Str},
+	"tempdir":  {returns: Str},
+
+	// File checks
+	"exists":  {params: []Type{Str}, required: 1, returns: Bool},
+	"is_file": {params: []Type{Str}, required: 1, returns: Bool},
+	"is_dir":  {params: []Type{Str}, required: 1, returns: Bool},
+
+	// Execution and environment
+	"exec":  {params: []Type{Str}, required: 1, returns: Str},
+	"run":   {params: []Type{Str}, required: 1, variadic: true, returns: Map},
+	"cmd":   {params: []Type{Str}, required: 1, variadic: true, returns: Str},
+	"lines": {params: []Type{Str}, required: 1, returns: List},
+	"exit":  {params: []Type{Int}, returns: Void},
+	"sleep": {params: []Type{Float}, required: 1, returns: Void},
+	"env":   {params: []Type{Str}, required: 1, returns: Str},
+	"args":  {returns: List},
+
+	// Settings files
+	"load_env":  {params: []Type{Str}, required: 1, kwargs: map[string]Type{"override": Bool}, returns: Void},
+	"read_ini":  {params: []Type{Str}, required: 1, returns: Map},
+	"write_env": {params: []Type{Str, Map}, required: 2, returns: Void},
+
+	// Archives
+	"archive":      {params: []Type{Str, List}, required: 2, kwargs: map[string]Type{"format": Str, "exclude": List}, returns: Void},
+	"extract":      {params: []Type{Str, Str}, required: 2, kwargs: map[string]Type{"strip": Int}, returns: Void},
+	"list_archive": {params: []Type{Str}, required: 1, returns: List},
+
+	// Prompts
+	"prompt":  {params: []Type{Str}, required: 1, kwargs: map[string]Type{"default": Str}, returns: Str},
+	"confirm": {params: []Type{Str}, required: 1, kwargs: map[string]Type{"default": Bool}, returns: Bool},
+	"choose":  {params: []Type{Str, List}, required: 2, kwargs: map[string]Type{"default": Str}, returns: Str},
+	"secret":  {params: []Type{Str}, required: 1, kwargs: map[string]Type{"default": Str}, returns: Str},
+
+	// Jobs
+	"spawn":      {params: []Type{Str}, required: 1, kwargs: map[string]Type{"log": Str}, returns: Int},
+	"wait":       {params: []Type{Int}, required: 1, returns: Int},
+	"wait_all":   {returns: Void},
+	"kill":       {params: []Type{Int}, required: 1, kwargs: map[string]Type{"signal": Str}, returns: Void},
+	"is_running": {params: []Type{Int}, required: 1, returns: Bool},
+	"pid":        {returns: Int},
+
+	// System info
+	"os":       {returns: Str},
+	"arch":     {returns: Str},
+	"hostname": {returns: Str},
+	"whoami":   {returns: Str},
+
+	// Paths and strings
+	"dirname":  {params: []Type{Str}, required: 1, returns: Str},
+	"basename": {params: []Type{Str}, required: 1, returns: Str},
+	"upper":    {params: []Type{Str}, required: 1, returns: Str},
+	"lower":    {params: []Type{Str}, required: 1, returns: Str},
+	"trim":     {params: []Type{Str}, required: 1, returns: Str},
+	"len":      {params: []Type{List}, required: 1, returns: Int},
+	"range":    {params: []Type{Int, Int}, required: 1, returns: List},
+
+	// Maps
+	"keys":   {params: []Type{Map}, required: 1, returns: List},
+	"values": {params: []Type{Map}, required: 1, returns: List},
+	"has":    {params: []Type{Map, Str}, required: 2, returns: Bool},
+	"delete": {params: []Type{Map, Str}, required: 2, returns: Void},
+
+	// Regex
+	"matches":       {params: []Type{Str, Str}, required: 2, returns: Bool},
+	"regex_find":    {params: []Type{Str, Str}, required: 2, returns: List},
+	"replace_regex": {params: []Type{Str, Str, Str}, required: 3, returns: Str},
+
+	// Numbers
+	"round": {params: []Type{Float, Int}, required: 1, returns: Int},
+	"floor": {params: []Type{Float}, required: 1, returns: Int},
+	"ceil":  {params: []Type{Float}, required: 1, returns: Int},
+
+	// Networking and data
+	"fetch": {
+		params:   []Type{Str},
+		required: 1,
+		kwargs: map[string]Type{
+			"method":  Str,
+			"body":    Str,
+			"headers": Map,
+			"timeout": Int,
+			"retries": Int,
+		},
+		returns: Str,
+	},
+	"json_get":    {params: []Type{Str, Str}, required: 2, returns: Str},
+	"parse_json":  {params: []Type{Str}, required: 1, returns: JSON},
+	"to_json":     {params: []Type{Unknown}, required: 1, returns: JSON},
+	"json_set":    {params: []Type{JSON, Str, Unknown}, required: 3, returns: JSON},
+	"json_delete": {params: []Type{JSON, Str}, required: 2, returns: JSON},
+	"json_keys":   {params: []Type{JSON}, required: 1, returns: List},
+	"json_len":    {params: []Type{JSON}, required: 1, returns: Int},
+	"json_merge":  {params: []Type{JSON, JSON}, required: 2, returns: JSON},
+	"json_valid":  {params: []Type{Str}, required: 1, returns: Bool},
+
+	// Hashing and encoding
+	"sha256":          {params: []Type{Str}, required: 1, returns: Str},
+	"sha1":            {params: []Type{Str}, required: 1, returns: Str},
+	"md5":             {params: []Type{Str}, required: 1, returns: Str},
+	"base64_encode":   {params: []Type{Str}, required: 1, returns: Str},
+	"base64_decode":   {params: []Type{Str}, required: 1, returns: Str},
+	"hex_encode":      {params: []Type{Str}, required: 1, returns: Str},
+	"url_encode":      {params: []Type{Str}, required: 1, returns: Str},
+	"url_decode":      {params: []Type{Str}, required: 1, returns: Str},
+	"verify_checksum": {params: []Type{Str, Str}, required: 2, returns: Bool},
+
+	// Date/time
+	"timestamp": {returns: Int},
+	"date":      {returns: Str},
+}
+
+// jsonValueArgs gives the argument of a builtin that is converted to JSON.
+// Literals there become JSON rather than Bash arrays, so they may nest.
+var jsonValueArgs = map[string]int{"to_json": 0, "json_set": 2}
+
+// jsonPathArgs gives the argument of a builtin that is a jq path.
+var jsonPathArgs = map[string]int{"json_set": 1, "json_delete": 1}
+
+// checksumArgs gives the argument of a builtin that is an expected checksum.
+var checksumArgs = map[string]int{"verify_checksum": 1}
+
+// methodSignature describes a dot-call method.
+type methodSignature struct {
+	receiver Type
+	params   []Type
+	returns  Type
+}
+
+// methods lists the methods supported by genMethodCall.
+var methods = map[string]methodSignature{
+	"replace":     {receiver: Str, params: []Type{Str, Str}, returns: Str},
+	"contains":    {receiver: Str, params: []Type{Str}, returns: Bool},
+	"starts_with": {receiver: Str, params: []Type{Str}, returns: Bool},
+	"ends_with":   {receiver: Str, params: []Type{Str}, returns: Bool},
+	"split":       {receiver: Str, params: []Type{Str}, returns: List},
+	"join":        {receiver: List, params: []Type{Str}, returns: Str},
+	"length":      {receiver: Str, returns: Int},
+	"matches":     {receiver: Str, params: []Type{Str}, returns: Bool},
+}
+
+// logLevels are the methods of the log builtin, log.info(msg, key: value).
+var logLevels = map[string]bool{"debug": true, "info": true, "warn": true, "error": true}
+
+// conventionVars are globals set by generated code rather than assignments.
+var conventionVars = map[string]Type{
+	"_status":  Int,
+	"_body":    Str,
+	"_headers": Str,
+}
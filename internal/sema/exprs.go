@@ -0,0 +1,476 @@
+package sema
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/tasnimzotder/langz/internal/ast"
+)
+
+// valueOf checks node and returns its type, reporting calls that produce
+// no value.
+func (c *checker) valueOf(node ast.Node) Type {
+	t := c.typeOf(node)
+	if t != Void {
+		return t
+	}
+	switch n := node.(type) {
+	case *ast.FuncCall:
+		c.errorf(node, "%s() does not return a value", n.Name)
+	case *ast.MethodCall:
+		c.errorf(node, "log.%s() does not return a value", n.Method)
+	}
+	return Unknown
+}
+
+// typeOf checks node and returns its type, which is Void for calls that
+// produce no value.
+func (c *checker) typeOf(node ast.Node) Type {
+	switch n := node.(type) {
+	case nil:
+		return Unknown
+	case *ast.StringLiteral:
+		return Str
+	case *ast.IntLiteral:
+		return Int
+	case *ast.FloatLiteral:
+		return Float
+	case *ast.BoolLiteral:
+		return Bool
+	case *ast.Identifier:
+		return c.lookup(n)
+	case *ast.FuncCall:
+		return c.checkCall(n)
+	case *ast.MethodCall:
+		return c.checkMethod(n)
+	case *ast.BinaryExpr:
+		return c.checkBinary(n)
+	case *ast.UnaryExpr:
+		c.checkCondition(n.Operand)
+		return Bool
+	case *ast.ListLiteral:
+		for _, e := range n.Elements {
+			c.checkElement(e)
+		}
+		return List
+	case *ast.MapLiteral:
+		for _, v := range n.Values {
+			c.checkElement(v)
+		}
+		return Map
+	case *ast.IndexExpr:
+		switch t := c.valueOf(n.Object); t {
+		case Int, Float, Bool:
+			c.errorf(n.Object, "cannot index %s", t)
+		}
+		c.valueOf(n.Index)
+		return Unknown
+	case *ast.DotExpr:
+		if c.isNamespace(n.Object) {
+			return Unknown
+		}
+		switch t := c.valueOf(n.Object); t {
+		case Unknown, Map, JSON:
+		default:
+			c.errorf(n.Object, "cannot access field .%s on %s", n.Field, t)
+		}
+		return Unknown
+	case *ast.OrExpr:
+		t := c.typeOf(n.Expr)
+		c.checkFallback(n.Fallback)
+		return t
+	case *ast.BlockExpr:
+		c.checkBlock(n.Statements)
+		return Unknown
+	default:
+		return Unknown
+	}
+}
+
+// checkElement checks a list element or map value, which must be scalar
+// because Bash arrays cannot nest.
+func (c *checker) checkElement(node ast.Node) {
+	if t := c.valueOf(node); t == List || t == Map {
+		c.errorf(node, "cannot nest %s inside a collection", t)
+	}
+}
+
+// checkJSONValue checks a value assigned into JSON. Literals there become
+// JSON rather than Bash arrays, so they may nest.
+func (c *checker) checkJSONValue(node ast.Node) {
+	switch n := node.(type) {
+	case *ast.ListLiteral:
+		for _, e := range n.Elements {
+			c.checkJSONValue(e)
+		}
+	case *ast.MapLiteral:
+		for _, v := range n.Values {
+			c.checkJSONValue(v)
+		}
+	default:
+		c.valueOf(node)
+	}
+}
+
+// checksumLengths gives the hex digits in each digest verify_checksum()
+// accepts.
+var checksumLengths = map[string]int{"sha256": 64, "sha1": 40, "md5": 32}
+
+// checkChecksum checks a literal checksum: a hex digest of a known length,
+// optionally prefixed with its algorithm, as in "sha256:...", and followed
+// by a file name as sha256sum prints it.
+func (c *checker) checkChecksum(node ast.Node, name string) {
+	s, ok := node.(*ast.StringLiteral)
+	if !ok || (!s.Raw && strings.Contains(s.Value, "{")) {
+		return
+	}
+	fields := strings.Fields(s.Value)
+	if len(fields) == 0 {
+		c.errorf(node, "empty checksum in %s()", name)
+		return
+	}
+	algo, sum, prefixed := strings.Cut(fields[0], ":")
+	if !prefixed {
+		sum = algo
+		for a, n := range checksumLengths {
+			if len(sum) == n {
+				algo = a
+			}
+		}
+	}
+	want, known := checksumLengths[algo]
+	if !known || len(sum) != want || strings.Trim(sum, "0123456789abcdefABCDEF") != "" {
+		c.errorf(node, "%q in %s() is not a sha256, sha1 or md5 checksum", s.Value, name)
+	}
+}
+
+// checkJSONPath checks a literal jq path, which must start at the root.
+func (c *checker) checkJSONPath(node ast.Node, name string) {
+	if s, ok := node.(*ast.StringLiteral); ok && !strings.HasPrefix(s.Value, ".") {
+		c.errorf(node, "path %q in %s() must start with \".\", e.g. \".%s\"", s.Value, name, s.Value)
+	}
+}
+
+// checkPathAssignment checks data.items[0].name = value, whose root must
+// be a JSON variable.
+func (c *checker) checkPathAssignment(p *ast.PathAssignment) {
+	target := p.Target
+	for {
+		if dot, ok := target.(*ast.DotExpr); ok {
+			target = dot.Object
+		} else if idx, ok := target.(*ast.IndexExpr); ok {
+			c.valueOf(idx.Index)
+			target = idx.Object
+		} else {
+			break
+		}
+	}
+	root, ok := target.(*ast.Identifier)
+	if !ok {
+		c.errorf(target, "cannot assign to a field of an expression")
+	} else if t := c.lookup(root); t != Unknown && t != JSON {
+		c.errorf(root, "cannot assign to a field of %s (see parse_json)", t)
+	}
+	c.checkJSONValue(p.Value)
+}
+
+func (c *checker) checkFallback(node ast.Node) {
+	switch n := node.(type) {
+	case *ast.BlockExpr:
+		c.checkBlock(n.Statements)
+	case *ast.ContinueStmt, *ast.ReturnStmt:
+		c.checkStmt(n)
+	case *ast.FuncCall:
+		// exit(1) and other statement calls are valid fallbacks
+		c.checkCall(n)
+	default:
+		c.valueOf(node)
+	}
+}
+
+func (c *checker) checkBinary(b *ast.BinaryExpr) Type {
+	switch b.Op {
+	case "|>":
+		return c.checkPipe(b)
+	case "and", "or":
+		c.checkCondition(b.Left)
+		c.checkCondition(b.Right)
+		return Bool
+	case "==", "!=":
+		for _, side := range []ast.Node{b.Left, b.Right} {
+			if t := c.valueOf(side); t == List || t == Map {
+				c.errorf(side, "cannot compare %s with %s", t, b.Op)
+			}
+		}
+		return Bool
+	case "+", "-", "*", "/", "%":
+		// One float operand makes the whole expression a float
+		left := c.checkNumeric(b.Left, b.Op)
+		right := c.checkNumeric(b.Right, b.Op)
+		if left == Float || right == Float {
+			return Float
+		}
+		return Int
+	default: // <, >, <=, >=
+		c.checkNumeric(b.Left, b.Op)
+		c.checkNumeric(b.Right, b.Op)
+		return Bool
+	}
+}
+
+// checkNumeric checks an operand of an arithmetic or ordering operator
+// and returns its type.
+func (c *checker) checkNumeric(node ast.Node, op string) Type {
+	t := c.valueOf(node)
+	if !isScalar(t) {
+		c.errorf(node, "invalid operand for %s: %s", op, t)
+		return Unknown
+	}
+	if lit, ok := node.(*ast.StringLiteral); ok {
+		if _, err := strconv.ParseFloat(lit.Value, 64); err != nil {
+			c.errorf(node, "invalid operand for %s: %q is not a number", op, lit.Value)
+		}
+	}
+	return t
+}
+
+// checkPipe checks a |> f(args) as the call f(a, args), mirroring codegen.
+func (c *checker) checkPipe(b *ast.BinaryExpr) Type {
+	if c.isCommand(b.Right) {
+		// Commands read what is piped into them on stdin
+		if t := c.valueOf(b.Left); t == Map && !c.isCommand(b.Left) {
+			c.errorf(b.Left, "cannot pipe a map into a command")
+		}
+		c.checkCall(b.Right.(*ast.FuncCall))
+		return Str
+	}
+	switch right := b.Right.(type) {
+	case *ast.Identifier:
+		call := &ast.FuncCall{Span: right.Span, Name: right.Name, Args: []ast.Node{b.Left}}
+		return c.checkCall(call)
+	case *ast.FuncCall:
+		args := append([]ast.Node{b.Left}, right.Args...)
+		call := &ast.FuncCall{Span: right.Span, Name: right.Name, Args: args, KwArgs: right.KwArgs}
+		return c.checkCall(call)
+	default:
+		c.valueOf(b.Left)
+		c.errorf(b.Right, "pipe target must be a function")
+		return Unknown
+	}
+}
+
+// isCommand reports whether node runs an external command: cmd(), run()
+// or a $ literal.
+func (c *checker) isCommand(node ast.Node) bool {
+	call, ok := node.(*ast.FuncCall)
+	if !ok || (call.Name != "cmd" && call.Name != "run") {
+		return false
+	}
+	_, user := c.funcs[call.Name]
+	return !user
+}
+
+// checkCall checks a call against its builtin or user-defined callee and
+// returns the call's result type.
+func (c *checker) checkCall(call *ast.FuncCall) Type {
+	if sig, ok := builtins[call.Name]; ok {
+		return c.checkBuiltinCall(call, sig)
+	}
+	if decl, ok := c.funcs[call.Name]; ok {
+		return c.checkUserCall(call, decl)
+	}
+	if c.strictNames {
+		c.errorf(call, "undefined function: %s", call.Name)
+	}
+	for _, arg := range call.Args {
+		c.valueOf(arg)
+	}
+	for _, kw := range call.KwArgs {
+		c.valueOf(kw.Value)
+	}
+	return Unknown
+}
+
+func (c *checker) checkBuiltinCall(call *ast.FuncCall, sig signature) Type {
+	if len(call.Args) < sig.required {
+		c.errorf(call, "not enough arguments in call to %s(): got %d, want %d", call.Name, len(call.Args), sig.required)
+	}
+	if len(call.Args) > len(sig.params) && !sig.variadic {
+		c.errorf(call, "too many arguments in call to %s(): got %d, want at most %d", call.Name, len(call.Args), len(sig.params))
+	}
+	for i, arg := range call.Args {
+		if pos, ok := jsonValueArgs[call.Name]; ok && pos == i {
+			c.checkJSONValue(arg)
+			continue
+		}
+		if pos, ok := jsonPathArgs[call.Name]; ok && pos == i {
+			c.checkJSONPath(arg, call.Name)
+		}
+		if pos, ok := checksumArgs[call.Name]; ok && pos == i {
+			c.checkChecksum(arg, call.Name)
+		}
+		t := c.valueOf(arg)
+		if call.Name == "len" && (t == Map || t == JSON) {
+			// len() counts map entries and JSON elements as well as list elements
+			continue
+		}
+		if i < len(sig.params) && !compatible(t, sig.params[i], arg) {
+			c.errorf(arg, "cannot use %s as %s in argument %d to %s()", t, sig.params[i], i+1, call.Name)
+		}
+	}
+	for _, kw := range call.KwArgs {
+		var t Type
+		if lit, ok := kw.Value.(*ast.MapLiteral); ok && isTemplateVars(call, kw) {
+			t = c.checkTemplateVars(lit)
+		} else {
+			t = c.valueOf(kw.Value)
+		}
+		want, ok := sig.kwargs[kw.Key]
+		if !ok {
+			c.errorAt(kw.Span, "unknown keyword argument %q for %s()", kw.Key, call.Name)
+			continue
+		}
+		if !compatible(t, want, kw.Value) {
+			c.errorf(kw.Value, "cannot use %s as %s for %s: in %s()", t, want, kw.Key, call.Name)
+		}
+	}
+	if call.Name == "render" || call.Name == "render_to" {
+		c.checkTemplate(call)
+	}
+	if call.Name == "archive" || call.Name == "extract" || call.Name == "list_archive" {
+		c.checkArchive(call)
+	}
+	if call.Name == "tempfile" {
+		c.checkTempSuffix(call)
+	}
+	if call.Name == "round" && len(call.Args) > 1 {
+		// Rounding to decimal places keeps the fraction
+		return Float
+	}
+	return sig.returns
+}
+
+func (c *checker) checkUserCall(call *ast.FuncCall, decl *ast.FuncDecl) Type {
+	if c.fn == nil && !c.defined[call.Name] {
+		c.errorf(call, "%s() is called before it is defined", call.Name)
+	}
+	if len(call.Args) > len(decl.Params) {
+		c.errorf(call, "too many arguments in call to %s(): got %d, want at most %d", call.Name, len(call.Args), len(decl.Params))
+	}
+
+	given := make(map[string]bool)
+	for i, arg := range call.Args {
+		t := c.valueOf(arg)
+		if i >= len(decl.Params) {
+			continue
+		}
+		p := decl.Params[i]
+		given[p.Name] = true
+		if want := declaredTypes[p.Type]; !compatible(t, want, arg) {
+			c.errorf(arg, "cannot use %s as %s for parameter %s of %s()", t, want, p.Name, call.Name)
+		}
+	}
+	for _, kw := range call.KwArgs {
+		t := c.valueOf(kw.Value)
+		p := findParam(decl, kw.Key)
+		if p == nil {
+			c.errorAt(kw.Span, "unknown keyword argument %q for %s()", kw.Key, call.Name)
+			continue
+		}
+		if given[p.Name] {
+			c.errorAt(kw.Span, "argument %s given twice in call to %s()", p.Name, call.Name)
+		}
+		given[p.Name] = true
+		if want := declaredTypes[p.Type]; !compatible(t, want, kw.Value) {
+			c.errorf(kw.Value, "cannot use %s as %s for parameter %s of %s()", t, want, p.Name, call.Name)
+		}
+	}
+	for _, p := range decl.Params {
+		if p.Default == nil && !given[p.Name] {
+			c.errorf(call, "missing argument %s in call to %s()", p.Name, call.Name)
+		}
+	}
+
+	if decl.ReturnType == "" {
+		return Void
+	}
+	return declaredTypes[decl.ReturnType]
+}
+
+func findParam(decl *ast.FuncDecl, name string) *ast.Param {
+	for i := range decl.Params {
+		if decl.Params[i].Name == name {
+			return &decl.Params[i]
+		}
+	}
+	return nil
+}
+
+// isNamespace reports whether node names an unresolved as import.
+func (c *checker) isNamespace(node ast.Node) bool {
+	id, ok := node.(*ast.Identifier)
+	return ok && c.namespaces[id.Name]
+}
+
+func (c *checker) checkMethod(m *ast.MethodCall) Type {
+	if c.isNamespace(m.Object) {
+		for _, arg := range m.Args {
+			c.valueOf(arg)
+		}
+		for _, kw := range m.KwArgs {
+			c.valueOf(kw.Value)
+		}
+		return Unknown
+	}
+	if c.isLog(m) {
+		c.checkLog(m)
+		return Void
+	}
+	for _, kw := range m.KwArgs {
+		c.errorAt(kw.Span, "unknown keyword argument %q for .%s()", kw.Key, m.Method)
+	}
+	recv := c.valueOf(m.Object)
+	sig, ok := methods[m.Method]
+	if !ok {
+		c.errorf(m, "unknown method %s", m.Method)
+		for _, arg := range m.Args {
+			c.valueOf(arg)
+		}
+		return Unknown
+	}
+	if !compatible(recv, sig.receiver, m.Object) {
+		c.errorf(m.Object, "cannot call .%s() on %s", m.Method, recv)
+	}
+	if len(m.Args) != len(sig.params) {
+		c.errorf(m, "wrong number of arguments to .%s(): got %d, want %d", m.Method, len(m.Args), len(sig.params))
+	}
+	for i, arg := range m.Args {
+		t := c.valueOf(arg)
+		if i < len(sig.params) && !compatible(t, sig.params[i], arg) {
+			c.errorf(arg, "cannot use %s as %s in argument %d to .%s()", t, sig.params[i], i+1, m.Method)
+		}
+	}
+	return sig.returns
+}
+
+// isLog reports whether m is log.<level>(...), the logging builtin.
+func (c *checker) isLog(m *ast.MethodCall) bool {
+	id, ok := m.Object.(*ast.Identifier)
+	return ok && id.Name == "log" && logLevels[m.Method]
+}
+
+// checkLog checks log.info(msg, key: value...). Each keyword argument is
+// a field of the line, so it must be a single value.
+func (c *checker) checkLog(m *ast.MethodCall) {
+	if len(m.Args) != 1 {
+		c.errorf(m, "wrong number of arguments to log.%s(): got %d, want 1", m.Method, len(m.Args))
+	}
+	for _, arg := range m.Args {
+		c.valueOf(arg)
+	}
+	for _, kw := range m.KwArgs {
+		if t := c.valueOf(kw.Value); t == List || t == Map {
+			c.errorf(kw.Value, "cannot use %s as log field %s", t, kw.Key)
+		}
+	}
+}
@@ -0,0 +1,435 @@
+// Package sema resolves names and checks calls and types in a parsed
+// program before code generation.
+package sema
+
+import (
+	"fmt"
+
+	"github.com/tasnimzotder/langz/internal/ast"
+)
+
+// Error is a semantic error located at the offending node.
+type Error struct {
+	Span    ast.Span
+	Message string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("line %d, col %d: %s", e.Span.Start.Line, e.Span.Start.Col, e.Message)
+}
+
+type checker struct {
+	funcs   map[string]*ast.FuncDecl
+	defined map[string]bool // user functions declared so far, in source order
+
+	assigned map[string]bool // every name assigned anywhere in the program
+	globals  map[string]Type // inferred types of globals assigned so far
+
+	// Bash blocks and unresolved imports can define names the checker
+	// cannot see, so undefined-name errors are only reported without them.
+	strictNames bool
+	// namespaces holds the names of unresolved as imports, whose members
+	// the checker cannot see either.
+	namespaces map[string]bool
+
+	fn     *ast.FuncDecl   // enclosing function, nil at top level
+	locals map[string]Type // parameters of the enclosing function
+
+	flags *ast.FlagsDecl // the script's flags block, if any
+
+	errs []Error
+}
+
+// Check resolves identifiers and calls in prog, checks argument counts,
+// keyword arguments and types, and returns every error found.
+func Check(prog *ast.Program) []Error {
+	c := &checker{
+		funcs:       make(map[string]*ast.FuncDecl),
+		defined:     make(map[string]bool),
+		assigned:    make(map[string]bool),
+		globals:     make(map[string]Type),
+		strictNames: true,
+		namespaces:  make(map[string]bool),
+	}
+	for _, stmt := range prog.Statements {
+		if flags, ok := stmt.(*ast.FlagsDecl); ok && c.flags == nil {
+			c.flags = flags
+		}
+	}
+	c.collect(prog.Statements)
+	c.checkBlock(prog.Statements)
+	return c.errs
+}
+
+func (c *checker) errorf(node ast.Node, format string, args ...any) {
+	c.errs = append(c.errs, Error{Span: node.NodeSpan(), Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *checker) errorAt(span ast.Span, format string, args ...any) {
+	c.errs = append(c.errs, Error{Span: span, Message: fmt.Sprintf(format, args...)})
+}
+
+// collect records every function and assigned name up front. Bash globals
+// are visible everywhere once set, so name resolution is not flow-sensitive.
+func (c *checker) collect(stmts []ast.Node) {
+	for _, stmt := range stmts {
+		switch n := stmt.(type) {
+		case *ast.FuncDecl:
+			if prev, ok := c.funcs[n.Name]; ok {
+				c.errorf(n, "function %s redeclared (previous declaration at line %d)", n.Name, prev.Span.Start.Line)
+			} else {
+				c.funcs[n.Name] = n
+			}
+			c.collect(n.Body)
+		case *ast.Assignment:
+			c.assigned[n.Name] = true
+			if or, ok := n.Value.(*ast.OrExpr); ok {
+				if block, ok := or.Fallback.(*ast.BlockExpr); ok {
+					c.collect(block.Statements)
+				}
+			}
+		case *ast.IndexAssignment:
+			c.assigned[n.Object] = true
+		case *ast.FlagsDecl:
+			if n != c.flags {
+				c.errorf(n, "flags must be declared once, at the top level of the script")
+			}
+			for _, f := range n.Flags {
+				c.assigned[f.Name] = true
+			}
+		case *ast.ForStmt:
+			c.assigned[n.Var] = true
+			if n.ValueVar != "" {
+				c.assigned[n.ValueVar] = true
+			}
+			c.collect(n.Body)
+		case *ast.IfStmt:
+			c.collect(n.Body)
+			c.collect(n.ElseBody)
+		case *ast.WhileStmt:
+			c.collect(n.Body)
+		case *ast.TryStmt:
+			c.collect(n.Body)
+			if n.ErrVar != "" {
+				c.assigned[n.ErrVar] = true
+			}
+			c.collect(n.Catch)
+			c.collect(n.Finally)
+		case *ast.DeferStmt:
+			c.collect(n.Body)
+		case *ast.WithStmt:
+			c.assigned[n.Var] = true
+			c.collect(n.Body)
+		case *ast.MatchStmt:
+			for _, mc := range n.Cases {
+				c.collect(mc.Body)
+			}
+		case *ast.ImportStmt:
+			c.strictNames = false
+			if ns := n.Namespace(); ns != "" {
+				c.namespaces[ns] = true
+			}
+		case *ast.BashBlock:
+			c.strictNames = false
+		}
+	}
+}
+
+func (c *checker) checkBlock(stmts []ast.Node) {
+	for _, stmt := range stmts {
+		c.checkStmt(stmt)
+	}
+}
+
+func (c *checker) checkStmt(node ast.Node) {
+	switch n := node.(type) {
+	case *ast.Assignment:
+		c.setVar(n.Name, c.valueOf(n.Value))
+	case *ast.IndexAssignment:
+		c.valueOf(n.Index)
+		if c.lookup(&ast.Identifier{Span: n.Span, Name: n.Object}) == JSON {
+			c.checkJSONValue(n.Value)
+		} else {
+			c.valueOf(n.Value)
+		}
+	case *ast.PathAssignment:
+		c.checkPathAssignment(n)
+	case *ast.FuncCall:
+		c.checkCall(n)
+	case *ast.FuncDecl:
+		c.checkFuncDecl(n)
+	case *ast.FlagsDecl:
+		c.checkFlags(n)
+	case *ast.IfStmt:
+		c.checkCondition(n.Condition)
+		c.checkBlock(n.Body)
+		c.checkBlock(n.ElseBody)
+	case *ast.ForStmt:
+		switch t := c.valueOf(n.Collection); t {
+		case Int, Float, Bool:
+			c.errorf(n.Collection, "cannot iterate over %s", t)
+		}
+		c.setVar(n.Var, Unknown)
+		if n.ValueVar != "" {
+			c.setVar(n.ValueVar, Unknown)
+		}
+		c.checkBlock(n.Body)
+	case *ast.WhileStmt:
+		c.checkCondition(n.Condition)
+		c.checkBlock(n.Body)
+	case *ast.MatchStmt:
+		c.valueOf(n.Expr)
+		for _, mc := range n.Cases {
+			c.checkPattern(mc.Pattern)
+			c.checkBlock(mc.Body)
+		}
+	case *ast.TryStmt:
+		c.checkBlock(n.Body)
+		if n.ErrVar != "" {
+			c.setVar(n.ErrVar, Map)
+		}
+		c.checkBlock(n.Catch)
+		c.checkBlock(n.Finally)
+	case *ast.DeferStmt:
+		c.checkExits(n.Body, "a deferred statement", false)
+		c.checkBlock(n.Body)
+	case *ast.WithStmt:
+		c.checkWith(n)
+	case *ast.ReturnStmt:
+		c.checkReturn(n)
+	case *ast.ContinueStmt, *ast.BreakStmt, *ast.BashBlock, *ast.ImportStmt:
+	default:
+		c.typeOf(node)
+	}
+}
+
+// checkExits reports return, break and continue statements that would
+// leave block: deferred statements run after the enclosing function and
+// a with block must reach its end to remove its path. inLoop is set inside
+// loops nested in the block, which break and continue may leave.
+func (c *checker) checkExits(stmts []ast.Node, block string, inLoop bool) {
+	for _, stmt := range stmts {
+		switch n := stmt.(type) {
+		case *ast.ReturnStmt:
+			c.errorf(n, "cannot return from inside %s", block)
+		case *ast.BreakStmt:
+			if !inLoop {
+				c.errorf(n, "cannot break out of %s", block)
+			}
+		case *ast.ContinueStmt:
+			if !inLoop {
+				c.errorf(n, "cannot continue out of %s", block)
+			}
+		case *ast.Assignment:
+			if or, ok := n.Value.(*ast.OrExpr); ok {
+				switch fb := or.Fallback.(type) {
+				case *ast.BlockExpr:
+					c.checkExits(fb.Statements, block, inLoop)
+				case *ast.ReturnStmt, *ast.ContinueStmt:
+					c.checkExits([]ast.Node{fb}, block, inLoop)
+				}
+			}
+		case *ast.ForStmt:
+			c.checkExits(n.Body, block, true)
+		case *ast.WhileStmt:
+			c.checkExits(n.Body, block, true)
+		case *ast.IfStmt:
+			c.checkExits(n.Body, block, inLoop)
+			c.checkExits(n.ElseBody, block, inLoop)
+		case *ast.MatchStmt:
+			for _, mc := range n.Cases {
+				c.checkExits(mc.Body, block, inLoop)
+			}
+		case *ast.TryStmt:
+			c.checkExits(n.Body, block, inLoop)
+			c.checkExits(n.Catch, block, inLoop)
+			c.checkExits(n.Finally, block, inLoop)
+		case *ast.WithStmt:
+			// checked as a with block of its own
+		}
+	}
+}
+
+func (c *checker) checkFuncDecl(f *ast.FuncDecl) {
+	c.defined[f.Name] = true
+	if _, ok := builtins[f.Name]; ok {
+		c.errorf(f, "function %s shadows the builtin %s()", f.Name, f.Name)
+	}
+	if f.ReturnType != "" {
+		if _, ok := declaredTypes[f.ReturnType]; !ok {
+			c.errorf(f, "unknown return type %q", f.ReturnType)
+		}
+	}
+
+	outerFn, outerLocals := c.fn, c.locals
+	c.fn = f
+	c.locals = make(map[string]Type)
+	defer func() { c.fn, c.locals = outerFn, outerLocals }()
+
+	for _, p := range f.Params {
+		t, ok := declaredTypes[p.Type]
+		if !ok {
+			c.errorAt(p.Span, "unknown type %q for parameter %s", p.Type, p.Name)
+		}
+		if p.Default != nil {
+			if dt := c.valueOf(p.Default); !compatible(dt, t, p.Default) {
+				c.errorf(p.Default, "cannot use %s as default for parameter %s (%s)", dt, p.Name, t)
+			}
+		}
+		c.locals[p.Name] = t
+	}
+	c.checkBlock(f.Body)
+}
+
+// flagTypes are the types a flag may have.
+var flagTypes = map[string]Type{
+	"str":   Str,
+	"int":   Int,
+	"float": Float,
+	"bool":  Bool,
+	"list":  List,
+}
+
+func (c *checker) checkFlags(d *ast.FlagsDecl) {
+	seen := make(map[string]bool)
+	for _, f := range d.Flags {
+		if seen[f.Name] {
+			c.errorAt(f.Span, "flag %s declared twice", f.Name)
+		}
+		seen[f.Name] = true
+		if f.Name == "help" {
+			c.errorAt(f.Span, "flag help is reserved for --help")
+		}
+		t, ok := flagTypes[f.Type]
+		if !ok {
+			c.errorAt(f.Span, "unknown type %q for flag %s", f.Type, f.Name)
+		}
+		if f.Default != nil {
+			if dt := c.valueOf(f.Default); !compatible(dt, t, f.Default) {
+				c.errorf(f.Default, "cannot use %s as default for flag %s (%s)", dt, f.Name, t)
+			}
+			if f.Required {
+				c.errorAt(f.Span, "required flag %s cannot have a default", f.Name)
+			}
+		}
+		if f.Required && t == Bool {
+			c.errorAt(f.Span, "bool flag %s cannot be required", f.Name)
+		}
+		c.setVar(f.Name, t)
+	}
+}
+
+func (c *checker) checkReturn(r *ast.ReturnStmt) {
+	if c.fn == nil {
+		if r.Value != nil {
+			c.valueOf(r.Value)
+		}
+		return
+	}
+	if r.Value == nil {
+		if c.fn.ReturnType != "" && c.fn.ReturnType != "bool" {
+			c.errorf(r, "missing return value in %s() (declared -> %s)", c.fn.Name, c.fn.ReturnType)
+		}
+		return
+	}
+
+	t := c.valueOf(r.Value)
+	if c.fn.ReturnType == "" {
+		// Plain functions return an exit status
+		if t != Bool && !compatible(t, Int, r.Value) {
+			c.errorf(r.Value, "%s() has no return type, so return needs an exit status (int), got %s", c.fn.Name, t)
+		}
+		return
+	}
+	want := declaredTypes[c.fn.ReturnType]
+	if !compatible(t, want, r.Value) {
+		c.errorf(r.Value, "cannot return %s from %s() (declared -> %s)", t, c.fn.Name, c.fn.ReturnType)
+	}
+}
+
+// checkCondition checks an if/while condition. Plain user functions and
+// commands are allowed because their exit status is the condition.
+func (c *checker) checkCondition(node ast.Node) {
+	switch n := node.(type) {
+	case *ast.FuncCall:
+		if decl, ok := c.funcs[n.Name]; ok && decl.ReturnType == "" {
+			c.checkCall(n)
+			return
+		}
+		if c.isCommand(n) {
+			c.valueOf(n)
+			return
+		}
+	case *ast.UnaryExpr:
+		if n.Op == "!" {
+			c.checkCondition(n.Operand)
+			return
+		}
+	case *ast.BinaryExpr:
+		if n.Op == "and" || n.Op == "or" {
+			c.checkCondition(n.Left)
+			c.checkCondition(n.Right)
+			return
+		}
+		if n.Op == "|>" && c.isCommand(n.Right) {
+			c.valueOf(n)
+			return
+		}
+	}
+	if t := c.valueOf(node); !compatible(t, Bool, node) {
+		c.errorf(node, "condition must be bool, got %s", t)
+	}
+}
+
+// setVar records the type assigned to name. A variable assigned values of
+// different types falls back to Unknown.
+func (c *checker) setVar(name string, t Type) {
+	if c.locals != nil {
+		if prev, ok := c.locals[name]; ok {
+			c.locals[name] = mergeTypes(prev, t)
+			return
+		}
+	}
+	if prev, ok := c.globals[name]; ok {
+		c.globals[name] = mergeTypes(prev, t)
+		return
+	}
+	c.globals[name] = t
+}
+
+func mergeTypes(a, b Type) Type {
+	if a == b {
+		return a
+	}
+	return Unknown
+}
+
+func (c *checker) lookup(id *ast.Identifier) Type {
+	if t, ok := c.locals[id.Name]; ok {
+		return t
+	}
+	if t, ok := c.globals[id.Name]; ok {
+		return t
+	}
+	if t, ok := conventionVars[id.Name]; ok {
+		return t
+	}
+	if !c.assigned[id.Name] && c.strictNames {
+		c.errorf(id, "undefined: %s", id.Name)
+	}
+	return Unknown
+}
+
+// checkPattern checks a match arm pattern. A matches("re") arm takes just
+// the regex; the subject is implied.
+func (c *checker) checkPattern(pattern ast.Node) {
+	if call, ok := pattern.(*ast.FuncCall); ok && call.Name == "matches" && len(call.Args) == 1 {
+		if t := c.valueOf(call.Args[0]); !compatible(t, Str, call.Args[0]) {
+			c.errorf(call.Args[0], "cannot use %s as str in regex pattern", t)
+		}
+		return
+	}
+	if pattern != nil {
+		c.valueOf(pattern)
+	}
+}
@@ -0,0 +1,294 @@
+package sema
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tasnimzotder/langz/internal/lexer"
+	"github.com/tasnimzotder/langz/internal/parser"
+)
+
+func TestBuiltinTooFewArgs(t *testing.T) {
+	errs := check(t, `copy("a")`)
+	assert.Equal(t, []string{"not enough arguments in call to copy(): got 1, want 2"}, messages(errs))
+}
+
+func TestBuiltinTooManyArgs(t *testing.T) {
+	errs := check(t, `x = upper("a", "b")`)
+	assert.Equal(t, []string{"too many arguments in call to upper(): got 2, want at most 1"}, messages(errs))
+}
+
+func TestBuiltinOptionalArgs(t *testing.T) {
+	errs := check(t, "exit()\nr = range(5)\ns = range(1, 5)")
+	assert.Empty(t, errs)
+}
+
+func TestPrintIsVariadic(t *testing.T) {
+	errs := check(t, `print("a", 1, true)`)
+	assert.Empty(t, errs)
+}
+
+func TestBuiltinUnknownKwarg(t *testing.T) {
+	errs := check(t, `res = fetch("http://x", methd: "POST")`)
+	require.Len(t, errs, 1)
+	assert.Equal(t, `unknown keyword argument "methd" for fetch()`, errs[0].Message)
+	assert.Equal(t, 25, errs[0].Span.Start.Col)
+}
+
+func TestBuiltinKwargType(t *testing.T) {
+	errs := check(t, `res = fetch("http://x", retries: "many")`)
+	assert.Equal(t, []string{"cannot use str as int for retries: in fetch()"}, messages(errs))
+}
+
+func TestUserFuncArity(t *testing.T) {
+	src := "fn add(a: int, b: int) -> int { return a + b }\n"
+	assert.Equal(t, []string{"missing argument b in call to add()"}, messages(check(t, src+"x = add(1)")))
+	assert.Equal(t, []string{"too many arguments in call to add(): got 3, want at most 2"}, messages(check(t, src+"x = add(1, 2, 3)")))
+}
+
+func TestUserFuncDefaultsMakeArgsOptional(t *testing.T) {
+	errs := check(t, "fn deploy(target: str = \"staging\") { print(target) }\ndeploy()")
+	assert.Empty(t, errs)
+}
+
+func TestUserFuncKwargs(t *testing.T) {
+	src := "fn deploy(app: str, env: str = \"staging\", replicas: int = 2) { print(app) }\n"
+	assert.Empty(t, check(t, src+`deploy("web", replicas: 3)`))
+	assert.Empty(t, check(t, src+`deploy(app: "web", env: "prod")`))
+	assert.Equal(t, []string{`unknown keyword argument "region" for deploy()`}, messages(check(t, src+`deploy("web", region: "eu")`)))
+	assert.Equal(t, []string{"argument app given twice in call to deploy()"}, messages(check(t, src+`deploy("web", app: "api")`)))
+	assert.Equal(t, []string{"missing argument app in call to deploy()"}, messages(check(t, src+`deploy(env: "prod")`)))
+}
+
+func TestMethodArity(t *testing.T) {
+	errs := check(t, "name = \"a\"\nx = name.replace(\"a\")")
+	assert.Equal(t, []string{"wrong number of arguments to .replace(): got 1, want 2"}, messages(errs))
+}
+
+func TestUnknownMethod(t *testing.T) {
+	errs := check(t, "name = \"a\"\nx = name.reverse()")
+	assert.Equal(t, []string{"unknown method reverse"}, messages(errs))
+}
+
+func TestRegexBuiltins(t *testing.T) {
+	errs := check(t, "tag = \"v1.2\"\nok = matches(tag, \"^v\")\nparts = regex_find(tag, \"([0-9]+)\")\nn = len(parts)\ns = replace_regex(tag, \"v\", \"\")\nb = tag.matches(\"^v\")")
+	assert.Empty(t, errs)
+}
+
+func TestRegexBuiltinArity(t *testing.T) {
+	errs := check(t, "ok = matches(\"x\")\ns = replace_regex(\"x\", \"y\")")
+	assert.Equal(t, []string{
+		"not enough arguments in call to matches(): got 1, want 2",
+		"not enough arguments in call to replace_regex(): got 2, want 3",
+	}, messages(errs))
+}
+
+func TestRegexMatchArm(t *testing.T) {
+	errs := check(t, "line = \"x\"\nmatch line {\n  matches(\"^x\") => print(\"x\")\n  matches([\"y\"]) => print(\"y\")\n  _ => print(\"z\")\n}")
+	assert.Equal(t, []string{"cannot use list as str in regex pattern"}, messages(errs))
+}
+
+func TestJobBuiltins(t *testing.T) {
+	errs := check(t, "job = spawn(\"sleep 5\", log: \"out.log\")\ncode = wait(job)\nkill(job, signal: \"KILL\")\nok = is_running(job)\nme = pid()\nwait_all()\nspawn(\"x\", logs: \"y\")")
+	assert.Equal(t, []string{`unknown keyword argument "logs" for spawn()`}, messages(errs))
+}
+
+func TestMapBuiltinsAndParams(t *testing.T) {
+	errs := check(t, "fn show(cfg: map) {\n  for k, v in cfg { print(k, v) }\n}\nconfig = {a: \"1\"}\nshow(config)\nn = len(config)\nks = keys(config)\nif has(config, \"a\") { delete(config, \"a\") }\nitems = [\"x\"]\nks = keys(items)\nshow(items)")
+	assert.Equal(t, []string{
+		"cannot use list as map in argument 1 to keys()",
+		"cannot use list as map for parameter cfg of show()",
+	}, messages(errs))
+}
+
+func TestJSONValues(t *testing.T) {
+	errs := check(t, "fn first(data: json) -> str {\n  return data.items[0].name\n}\ndata = parse_json(\"{}\")\nn = len(data.items)\ndata.items = [{name: \"a\", tags: [\"x\"]}]\nfor item in data.items { print(item.name) }\nprint(first(data), to_json(data))\ncfg = {a: \"1\"}\nprint(to_json(cfg))\nname = \"x\"\nprint(name.first)\nname.first = \"y\"\nparse_json()")
+	assert.Equal(t, []string{
+		"cannot access field .first on str",
+		"cannot assign to a field of str (see parse_json)",
+		"not enough arguments in call to parse_json(): got 0, want 1",
+	}, messages(errs))
+}
+
+func TestJSONDocumentCalls(t *testing.T) {
+	errs := check(t, "doc = json_set(\"{}\", \".spec\", {env: [\"a\", {b: 1}]})\ndoc = doc |> json_delete(\".x\") |> json_merge(\"{}\")\n"+
+		"for k in json_keys(doc) { print(k) }\nif json_valid(doc) and json_len(doc) > 0 { print(doc.spec) }\nprint(to_json({a: [1, [2]]}))")
+	assert.Empty(t, errs)
+
+	errs = check(t, "cfg = {a: \"1\"}\nx = json_set(\"{}\", \"spec\", 1)\ny = json_delete(\"{}\")\nz = json_merge(cfg, \"{}\")")
+	assert.Equal(t, []string{
+		`path "spec" in json_set() must start with ".", e.g. ".spec"`,
+		"not enough arguments in call to json_delete(): got 1, want 2",
+		"cannot use map as json in argument 1 to json_merge()",
+	}, messages(errs))
+}
+
+func TestSettingsFileCalls(t *testing.T) {
+	errs := check(t, "load_env(\".env\", override: true)\ncfg = read_ini(\"app.ini\")\nprint(cfg[\"db.host\"])\nwrite_env(\"out.env\", cfg)\nwrite_env(\"b.env\", {A: \"1\"})")
+	assert.Empty(t, errs)
+
+	errs = check(t, "load_env(\".env\", override: \"yes\")\nwrite_env(\"out.env\", \"A=1\")\nx = load_env(\".env\")")
+	assert.Equal(t, []string{
+		"cannot use str as bool for override: in load_env()",
+		"cannot use str as map in argument 2 to write_env()",
+		"load_env() does not return a value",
+	}, messages(errs))
+}
+
+func TestDigestCalls(t *testing.T) {
+	errs := check(t, "sum = sha256(\"app.tar.gz\")\nif verify_checksum(\"app.tar.gz\", sum) {\n  print(base64_encode(sum), url_decode(url_encode(sum)))\n}\nok = verify_checksum(\"a\", \"sha1:aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d\")\nok = verify_checksum(\"a\", \"5d41402abc4b2a76b9719d911017c592  a\")")
+	assert.Empty(t, errs)
+
+	errs = check(t, "x = sha256([\"a\"])\nok = verify_checksum(\"a\", \"abc\")\nok = verify_checksum(\"a\", \"md5:aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d\")\nok = verify_checksum(\"a\")")
+	assert.Equal(t, []string{
+		"cannot use list as str in argument 1 to sha256()",
+		`"abc" in verify_checksum() is not a sha256, sha1 or md5 checksum`,
+		`"md5:aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d" in verify_checksum() is not a sha256, sha1 or md5 checksum`,
+		"not enough arguments in call to verify_checksum(): got 1, want 2",
+	}, messages(errs))
+}
+
+func TestArchiveCalls(t *testing.T) {
+	errs := check(t, "files = [\"src\"]\narchive(\"app.tar.gz\", files, exclude: [\"*.log\"])\narchive(\"backup\", [\"src\"], format: \"zip\")\nextract(\"app.tgz\", \"out\", strip: 1)\nentries = list_archive(\"app.tar.gz\")\nprint(len(entries))\nname = \"x.bin\"\nextract(name, \"out\")")
+	assert.Empty(t, errs)
+
+	errs = check(t, "archive(\"app.tar.gz\", \"src\")\narchive(\"app\", [\"src\"], format: \"rar\")\nextract(\"app.rar\", \"out\", strip: \"one\")\nentries = list_archive(\"app.7z\")\narchive(\"app.bin\", [\"src\"])")
+	assert.Equal(t, []string{
+		"cannot use str as list in argument 2 to archive()",
+		`unknown archive format "rar", want "tar.gz", "tar.zst", "tar" or "zip"`,
+		"cannot use str as int for strip: in extract()",
+		`cannot tell the archive format of "app.rar" from its name, want .tar.gz, .tgz, .tar.zst, .tar or .zip`,
+		`cannot tell the archive format of "app.7z" from its name, want .tar.gz, .tgz, .tar.zst, .tar or .zip`,
+		`cannot tell the archive format of "app.bin" from its name, want .tar.gz, .tgz, .tar.zst, .tar or .zip; add format: "tar.gz", "tar.zst", "tar" or "zip"`,
+	}, messages(errs))
+}
+
+func TestTryCatch(t *testing.T) {
+	errs := check(t, "try {\n  x = read(\"f\")\n} catch err {\n  print(err.code, x)\n} finally {\n  print(\"done\")\n}")
+	assert.Empty(t, errs)
+}
+
+func TestTryCanLeaveBlock(t *testing.T) {
+	errs := check(t, "fn f() -> int {\n  for i in range(3) {\n    try {\n      if i == 1 { continue }\n      for j in range(2) { break }\n      x = read(\"f\") or return 1\n      return 0\n    } catch {\n      return 2\n    }\n  }\n  return 3\n}")
+	assert.Empty(t, errs)
+}
+
+func TestDefer(t *testing.T) {
+	errs := check(t, "fn f() {\n  dir = exec(\"mktemp -d\")\n  defer rmdir(dir)\n  defer {\n    print(\"done\", dir)\n  }\n}")
+	assert.Empty(t, errs)
+}
+
+func TestDeferCannotLeaveBlock(t *testing.T) {
+	errs := check(t, "fn f() {\n  for i in range(3) {\n    defer {\n      if i == 1 { break }\n      for j in range(2) { continue }\n    }\n    defer return 1\n  }\n}")
+	assert.Equal(t, []string{
+		"cannot break out of a deferred statement",
+		"cannot return from inside a deferred statement",
+	}, messages(errs))
+}
+
+func TestTempCalls(t *testing.T) {
+	errs := check(t, "f = tempfile(suffix: \".json\")\nwrite(f, \"{}\")\nwith dir = tempdir() {\n  with out = tempfile() {\n    copy(out, dir)\n  }\n  for i in range(3) {\n    if i == 1 { continue }\n  }\n}\nprint(dir)")
+	assert.Empty(t, errs)
+
+	errs = check(t, "f = tempfile(\"x\")\ng = tempfile(suffix: \"a/b\")\nd = tempdir(suffix: \".d\")\nwith x = read(\"f\") {\n  print(x)\n}\nfn h() {\n  for i in range(3) {\n    with t = tempfile() {\n      if i == 1 { break }\n      return 1\n    }\n  }\n}")
+	assert.Equal(t, []string{
+		"too many arguments in call to tempfile(): got 1, want at most 0",
+		`tempfile() suffix "a/b" must not contain /`,
+		`unknown keyword argument "suffix" for tempdir()`,
+		"with needs tempfile() or tempdir()",
+		"cannot break out of a with block",
+		"cannot return from inside a with block",
+	}, messages(errs))
+}
+
+func TestUnresolvedNamespaceImport(t *testing.T) {
+	errs := check(t, "import \"lib/k8s.lz\" as k8s\nk8s.apply(\"app.yaml\", wait: true)\nprint(k8s.namespace)")
+	assert.Empty(t, errs)
+}
+
+func TestMethodKeywordArgs(t *testing.T) {
+	errs := check(t, "s = \"a\"\nprint(s.contains(\"a\", exact: true))")
+	assert.Equal(t, []string{`unknown keyword argument "exact" for .contains()`}, messages(errs))
+}
+
+func TestLogCalls(t *testing.T) {
+	errs := check(t, "user = \"bob\"\nlog.info(\"deploying\", user: user, replicas: 3, ok: true)\nlog.error(\"failed\")")
+	assert.Empty(t, errs)
+
+	errs = check(t, "hosts = [\"a\"]\nlog.warn()\nlog.info(\"x\", hosts: hosts)\nx = log.debug(\"y\")\nlog.trace(\"z\")")
+	assert.Equal(t, []string{
+		"wrong number of arguments to log.warn(): got 0, want 1",
+		"cannot use list as log field hosts",
+		"log.debug() does not return a value",
+		"undefined: log",
+		"unknown method trace",
+	}, messages(errs))
+}
+
+func TestPromptCalls(t *testing.T) {
+	errs := check(t, "name = prompt(\"Name\", default: \"x\")\nenv = choose(\"Env\", [\"a\", \"b\"])\n"+
+		"if confirm(\"Go?\", default: true) {\n\tprint(name, env, secret(\"Token\"))\n}")
+	assert.Empty(t, errs)
+
+	errs = check(t, "a = choose(\"Env\", \"a\")\nb = confirm(\"Go?\", default: \"yes\")\nc = prompt(\"Name\", fallback: \"x\")")
+	assert.Equal(t, []string{
+		"cannot use str as list in argument 2 to choose()",
+		"cannot use str as bool for default: in confirm()",
+		`unknown keyword argument "fallback" for prompt()`,
+	}, messages(errs))
+}
+
+func TestRenderCalls(t *testing.T) {
+	errs := check(t, "hosts = [\"a\"]\nout = render(\"missing.tmpl\", vars: {hosts: hosts, port: 80})\nrender_to(\"missing.tmpl\", \"out\")")
+	assert.Empty(t, errs)
+
+	errs = check(t, "render_to(\"a.tmpl\")\nx = render(\"a.tmpl\", vars: \"name\")\ny = render_to(\"a.tmpl\", \"b\")")
+	assert.Equal(t, []string{
+		"not enough arguments in call to render_to(): got 1, want 2",
+		"cannot use str as map for vars: in render()",
+		"render_to() does not return a value",
+	}, messages(errs))
+}
+
+// checkTemplate writes a template next to a script and checks the script
+// as if parsed from that file.
+func checkTemplate(t *testing.T, template, input string) []Error {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.tmpl"), []byte(template), 0644))
+	tokens := lexer.New(input).Tokenize()
+	prog, err := parser.NewFile(tokens, filepath.Join(dir, "deploy.lz")).ParseWithErrors()
+	require.NoError(t, err, "parse error")
+	return Check(prog)
+}
+
+func TestRenderChecksLiteralTemplate(t *testing.T) {
+	tmpl := "name = {name}\n{for h in hosts}\nserver {h}:{port}\n{end}\n{if !debug}\n{ not a var }\n{end}\n"
+
+	errs := checkTemplate(t, tmpl, `x = render("app.tmpl", vars: {name: "a", hosts: ["h"], port: 1, debug: false})`)
+	assert.Empty(t, errs)
+
+	errs = checkTemplate(t, tmpl, `render_to("app.tmpl", "out", vars: {name: "a", hosts: ["h"]})`)
+	assert.Equal(t, []string{
+		"template app.tmpl, line 3: undefined variable port",
+		"template app.tmpl, line 5: undefined variable debug",
+	}, messages(errs))
+
+	// Names in a map variable are only known at runtime
+	errs = checkTemplate(t, tmpl, "cfg = {name: \"a\"}\nx = render(\"app.tmpl\", vars: cfg)")
+	assert.Empty(t, errs)
+}
+
+func TestRenderChecksTemplateBlocks(t *testing.T) {
+	errs := checkTemplate(t, "{for x}\n{end}\n{else}\n{if a}\n{else}\n{else}\n{for y in a}\n", `x = render("app.tmpl", vars: {a: "1"})`)
+	assert.Equal(t, []string{
+		"template app.tmpl, line 1: malformed {for}, want {for item in list}",
+		"template app.tmpl, line 3: unexpected {else}",
+		"template app.tmpl, line 6: unexpected {else}",
+		"template app.tmpl, line 4: {if} without {end}",
+		"template app.tmpl, line 7: {for} without {end}",
+	}, messages(errs))
+}
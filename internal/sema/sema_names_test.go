@@ -0,0 +1,72 @@
+package sema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tasnimzotder/langz/internal/ast"
+)
+
+func TestUndefinedVariable(t *testing.T) {
+	errs := check(t, "x = 1\nprint(y)")
+	require.Len(t, errs, 1)
+	assert.Equal(t, "undefined: y", errs[0].Message)
+	assert.Equal(t, ast.Pos{Line: 2, Col: 7}, errs[0].Span.Start)
+}
+
+func TestVariableAssignedLaterIsDefined(t *testing.T) {
+	// Bash globals are visible to functions regardless of source order
+	errs := check(t, "fn show() {\n    print(count)\n}\ncount = 1\nshow()")
+	assert.Empty(t, errs)
+}
+
+func TestParamsAndForVarsAreDefined(t *testing.T) {
+	errs := check(t, "fn greet(name: str) { print(name) }\nfor item in [\"a\"] { greet(item) }")
+	assert.Empty(t, errs)
+}
+
+func TestConventionVarsAreDefined(t *testing.T) {
+	errs := check(t, "res = fetch(\"http://x\")\nif _status == 200 { print(_body) }")
+	assert.Empty(t, errs)
+}
+
+func TestBashBlockSuppressesUndefinedNames(t *testing.T) {
+	errs := check(t, "bash { VERSION=1 }\nprint(VERSION)\nhelper()")
+	assert.Empty(t, errs)
+}
+
+func TestUndefinedFunction(t *testing.T) {
+	errs := check(t, `deploy("prod")`)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "undefined function: deploy", errs[0].Message)
+}
+
+func TestCallBeforeDefinition(t *testing.T) {
+	errs := check(t, "greet()\nfn greet() { print(\"hi\") }")
+	require.Len(t, errs, 1)
+	assert.Equal(t, "greet() is called before it is defined", errs[0].Message)
+}
+
+func TestCallBeforeDefinitionInsideFunctionIsAllowed(t *testing.T) {
+	errs := check(t, "fn main() { helper() }\nfn helper() { print(\"hi\") }\nmain()")
+	assert.Empty(t, errs)
+}
+
+func TestRedeclaredFunction(t *testing.T) {
+	errs := check(t, "fn a() { }\nfn a() { }")
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Message, "function a redeclared")
+}
+
+func TestFunctionShadowsBuiltin(t *testing.T) {
+	errs := check(t, `fn print(msg: str) { }`)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "function print shadows the builtin print()", errs[0].Message)
+}
+
+func TestPipeTargetResolvesAsFunction(t *testing.T) {
+	errs := check(t, `x = "hi" |> upper |> nope`)
+	assert.Equal(t, []string{"undefined function: nope"}, messages(errs))
+}
@@ -0,0 +1,26 @@
+package sema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/tasnimzotder/langz/internal/lexer"
+	"github.com/tasnimzotder/langz/internal/parser"
+)
+
+func check(t *testing.T, input string) []Error {
+	t.Helper()
+	tokens := lexer.New(input).Tokenize()
+	prog, err := parser.New(tokens).ParseWithErrors()
+	require.NoError(t, err, "parse error")
+	return Check(prog)
+}
+
+func messages(errs []Error) []string {
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Message
+	}
+	return msgs
+}
@@ -0,0 +1,180 @@
+package sema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArgumentTypeMismatch(t *testing.T) {
+	errs := check(t, "fn scale(n: int) { print(n) }\nscale(\"lots\")")
+	assert.Equal(t, []string{"cannot use str as int for parameter n of scale()"}, messages(errs))
+}
+
+func TestNumericStringLiteralIsInt(t *testing.T) {
+	errs := check(t, "fn scale(n: int) { print(n) }\nscale(\"3\")")
+	assert.Empty(t, errs)
+}
+
+func TestStrVariableAcceptedAsInt(t *testing.T) {
+	// Env values are strings that often hold numbers
+	errs := check(t, "fn scale(n: int) { print(n) }\nport = env(\"PORT\")\nscale(port)")
+	assert.Empty(t, errs)
+}
+
+func TestListArgumentToStrParam(t *testing.T) {
+	errs := check(t, "fn greet(name: str) { print(name) }\nnames = [\"a\"]\ngreet(names)")
+	assert.Equal(t, []string{"cannot use list as str for parameter name of greet()"}, messages(errs))
+}
+
+func TestBuiltinArgumentType(t *testing.T) {
+	errs := check(t, `n = len("abc")`)
+	assert.Equal(t, []string{"cannot use str as list in argument 1 to len()"}, messages(errs))
+}
+
+func TestReturnTypeMismatch(t *testing.T) {
+	errs := check(t, `fn count() -> int { return "many" }`)
+	assert.Equal(t, []string{"cannot return str from count() (declared -> int)"}, messages(errs))
+}
+
+func TestPlainFuncReturnsExitStatus(t *testing.T) {
+	assert.Empty(t, check(t, "fn ok() { return 0 }\nfn ready() { return true }"))
+
+	errs := check(t, `fn name() { return "bob" }`)
+	assert.Equal(t, []string{"name() has no return type, so return needs an exit status (int), got str"}, messages(errs))
+}
+
+func TestMissingReturnValue(t *testing.T) {
+	errs := check(t, `fn count() -> int { return }`)
+	assert.Equal(t, []string{"missing return value in count() (declared -> int)"}, messages(errs))
+}
+
+func TestVoidCallUsedAsValue(t *testing.T) {
+	errs := check(t, "fn log(msg: str) { print(msg) }\nx = log(\"hi\")")
+	assert.Equal(t, []string{"log() does not return a value"}, messages(errs))
+}
+
+func TestPlainFuncAsCondition(t *testing.T) {
+	errs := check(t, "fn ready() { return 0 }\nif ready() { print(\"go\") }")
+	assert.Empty(t, errs)
+}
+
+func TestRunAsCondition(t *testing.T) {
+	errs := check(t, "if $ test -f x {\n\tprint(\"x\")\n}\nr = run(\"true\")\nif !run(\"false\") and r.ok {\n\tprint(r.stdout)\n}")
+	assert.Empty(t, errs)
+}
+
+func TestPipeIntoCommands(t *testing.T) {
+	errs := check(t, "files = [\"a\"]\nfiles |> cmd(\"sort\")\nn = $ ls |> $ wc -l |> trim()\nif read(\"f\") |> cmd(\"grep\", \"-q\", \"x\") {\n\tprint(n)\n}\nfor l in n |> lines() {\n\tprint(l)\n}")
+	assert.Empty(t, errs)
+}
+
+func TestPipeMapIntoCommand(t *testing.T) {
+	errs := check(t, "m = {a: \"b\"}\nm |> cmd(\"cat\")")
+	assert.Equal(t, []string{"cannot pipe a map into a command"}, messages(errs))
+}
+
+func TestRunNeedsCommand(t *testing.T) {
+	errs := check(t, "run()")
+	assert.Len(t, errs, 1)
+}
+
+func TestValueFuncAsCondition(t *testing.T) {
+	errs := check(t, "fn count() -> int { return 1 }\nif count() { print(\"go\") }")
+	assert.Equal(t, []string{"condition must be bool, got int"}, messages(errs))
+}
+
+func TestInferredVariableTypes(t *testing.T) {
+	errs := check(t, "items = [\"a\"]\nn = items + 1\ncfg = {a: 1}\nfor x in cfg { print(x) }\nflag = true\nfor x in flag { print(x) }")
+	assert.Equal(t, []string{"invalid operand for +: list", "cannot iterate over bool"}, messages(errs))
+}
+
+func TestReassignedVariableBecomesUnknown(t *testing.T) {
+	errs := check(t, "x = [\"a\"]\nx = \"b\"\ny = x + 1")
+	assert.Empty(t, errs)
+}
+
+func TestNonNumericLiteralInArithmetic(t *testing.T) {
+	errs := check(t, `x = "abc" * 2`)
+	assert.Equal(t, []string{`invalid operand for *: "abc" is not a number`}, messages(errs))
+}
+
+func TestMethodReturnTypes(t *testing.T) {
+	errs := check(t, "name = \"a b\"\nparts = name.split(\" \")\nn = parts + 1\nif name.contains(\"a\") { print(n) }")
+	assert.Equal(t, []string{"invalid operand for +: list"}, messages(errs))
+}
+
+func TestUnknownParamType(t *testing.T) {
+	errs := check(t, `fn greet(name: string) { print(name) }`)
+	assert.Equal(t, []string{`unknown type "string" for parameter name`}, messages(errs))
+}
+
+func TestNestedCollection(t *testing.T) {
+	errs := check(t, `x = [[1], 2]`)
+	assert.Equal(t, []string{"cannot nest list inside a collection"}, messages(errs))
+}
+
+func TestFloatArithmetic(t *testing.T) {
+	errs := check(t, "fn pct(n: float) -> float { return n * 100 }\nused = 3\ntotal = 4\nr = pct(used / total * 1.0)")
+	assert.Empty(t, errs)
+}
+
+func TestIntPromotesToFloat(t *testing.T) {
+	errs := check(t, "fn half(n: float) -> float { return n / 2 }\nh = half(5)")
+	assert.Empty(t, errs)
+}
+
+func TestFloatDoesNotNarrowToInt(t *testing.T) {
+	errs := check(t, "fn repeat(n: int) { print(n) }\nrepeat(2.5)\nratio = 1 * 0.5\nrepeat(ratio)")
+	assert.Equal(t, []string{
+		"cannot use float as int for parameter n of repeat()",
+		"cannot use float as int for parameter n of repeat()",
+	}, messages(errs))
+}
+
+func TestRoundReturnsInt(t *testing.T) {
+	errs := check(t, "fn repeat(n: int) { print(n) }\nrepeat(round(2.5))\nrepeat(round(2.5, 1))")
+	assert.Equal(t, []string{"cannot use float as int for parameter n of repeat()"}, messages(errs))
+}
+
+func TestFloatStringLiteral(t *testing.T) {
+	errs := check(t, "fn scale(f: float) { print(f) }\nscale(\"1.5\")\nx = \"2.5\" * 2")
+	assert.Empty(t, errs)
+}
+
+func TestFlagsDefineTypedVariables(t *testing.T) {
+	errs := check(t, "flags { env: str = \"staging\", replicas: int = 2, dry_run: bool, targets: list }\n"+
+		"if dry_run and replicas > 1 {\n\tprint(env, len(targets))\n}")
+	assert.Empty(t, errs)
+
+	errs = check(t, "flags { replicas: int }\nif replicas {\n\tprint(replicas)\n}")
+	assert.Equal(t, []string{"condition must be bool, got int"}, messages(errs))
+}
+
+func TestFlagsErrors(t *testing.T) {
+	errs := check(t, `flags {
+    env: str
+    env: str
+    help: bool
+    count: number
+    replicas: int = "two"
+    token: str = "x" required
+    force: bool required
+}`)
+	assert.Equal(t, []string{
+		"flag env declared twice",
+		"flag help is reserved for --help",
+		`unknown type "number" for flag count`,
+		"cannot use str as default for flag replicas (int)",
+		"required flag token cannot have a default",
+		"bool flag force cannot be required",
+	}, messages(errs))
+}
+
+func TestFlagsDeclaredOnce(t *testing.T) {
+	errs := check(t, "flags { a: str }\nflags { b: str }\nfn f() {\n\tflags { c: str }\n}")
+	assert.Equal(t, []string{
+		"flags must be declared once, at the top level of the script",
+		"flags must be declared once, at the top level of the script",
+	}, messages(errs))
+}
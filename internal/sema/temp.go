@@ -0,0 +1,31 @@
+package sema
+
+import (
+	"strings"
+
+	"github.com/tasnimzotder/langz/internal/ast"
+)
+
+// checkTempSuffix checks a literal suffix: for tempfile(), which names the
+// file in its own directory and so can't take a path.
+func (c *checker) checkTempSuffix(call *ast.FuncCall) {
+	kw, ok := findKwarg(call, "suffix")
+	if !ok {
+		return
+	}
+	if lit, isLit := kw.(*ast.StringLiteral); isLit && strings.Contains(lit.Value, "/") {
+		c.errorf(kw, "tempfile() suffix %q must not contain /", lit.Value)
+	}
+}
+
+// checkWith checks with name = tempfile() { ... }. The path is only
+// removed when the block runs to its end, so nothing may leave it early.
+func (c *checker) checkWith(w *ast.WithStmt) {
+	call, ok := w.Value.(*ast.FuncCall)
+	if !ok || (call.Name != "tempfile" && call.Name != "tempdir") {
+		c.errorf(w.Value, "with needs tempfile() or tempdir()")
+	}
+	c.setVar(w.Var, c.valueOf(w.Value))
+	c.checkExits(w.Body, "a with block", false)
+	c.checkBlock(w.Body)
+}
@@ -0,0 +1,172 @@
+package sema
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/tasnimzotder/langz/internal/ast"
+)
+
+// Template syntax, matching the render() runtime: {name} substitutes a
+// variable, and {for item in list}, {if name}, {if !name}, {else} and
+// {end} on lines of their own open and close blocks.
+var (
+	templateTag = regexp.MustCompile(`^\s*\{(else|end|(for|if)\s+([^}]*))\}\s*$`)
+	templateFor = regexp.MustCompile(`^([A-Za-z_]\w*)\s+in\s+([A-Za-z_]\w*)\s*$`)
+	templateIf  = regexp.MustCompile(`^!?\s*([A-Za-z_]\w*)\s*$`)
+	templateVar = regexp.MustCompile(`\{([A-Za-z_]\w*)\}`)
+)
+
+// isTemplateVars reports whether kw is the vars: argument of render() or
+// render_to().
+func isTemplateVars(call *ast.FuncCall, kw ast.KeywordArg) bool {
+	return kw.Key == "vars" && (call.Name == "render" || call.Name == "render_to")
+}
+
+// checkTemplateVars checks a map literal given as template variables. A
+// list value is passed one element per line for {for} to loop over, so
+// unlike other map literals it may hold lists.
+func (c *checker) checkTemplateVars(lit *ast.MapLiteral) Type {
+	for _, v := range lit.Values {
+		if t := c.valueOf(v); t == Map {
+			c.errorf(v, "cannot nest %s inside a collection", t)
+		}
+	}
+	return Map
+}
+
+// checkTemplate checks a template named by a string literal at compile
+// time. A template that can't be found here is left to the runtime check,
+// and so are variable names when vars: isn't a literal.
+func (c *checker) checkTemplate(call *ast.FuncCall) {
+	if len(call.Args) == 0 {
+		return
+	}
+	lit, ok := call.Args[0].(*ast.StringLiteral)
+	if !ok || (!lit.Raw && templateVar.MatchString(lit.Value)) {
+		return
+	}
+	src, ok := readTemplate(lit.Value, lit.Span.File)
+	if !ok {
+		return
+	}
+	known := map[string]bool{}
+	if kw, ok := findKwarg(call, "vars"); ok {
+		m, ok := kw.(*ast.MapLiteral)
+		if !ok {
+			known = nil
+		} else {
+			for _, key := range m.Keys {
+				known[key] = true
+			}
+		}
+	}
+	for _, e := range templateErrors(src, known) {
+		c.errorf(lit, "template %s, line %d: %s", lit.Value, e.line, e.msg)
+	}
+}
+
+func findKwarg(call *ast.FuncCall, key string) (ast.Node, bool) {
+	for _, kw := range call.KwArgs {
+		if kw.Key == key {
+			return kw.Value, true
+		}
+	}
+	return nil, false
+}
+
+// readTemplate reads a template the way the script will, relative to the
+// working directory, or else relative to the source file.
+func readTemplate(path, file string) (string, bool) {
+	candidates := []string{path}
+	if file != "" && !filepath.IsAbs(path) {
+		candidates = append(candidates, filepath.Join(filepath.Dir(file), path))
+	}
+	for _, name := range candidates {
+		if data, err := os.ReadFile(name); err == nil {
+			return string(data), true
+		}
+	}
+	return "", false
+}
+
+type templateError struct {
+	line int
+	msg  string
+}
+
+// templateErrors checks the blocks of a template and, unless known is nil,
+// that every variable it uses is in known or is a loop variable in scope.
+func templateErrors(src string, known map[string]bool) []templateError {
+	type block struct {
+		kind, loopVar string
+		line          int
+		sawElse       bool
+	}
+	var (
+		errs  []templateError
+		stack []*block
+	)
+	fail := func(line int, msg string) {
+		errs = append(errs, templateError{line, msg})
+	}
+	use := func(line int, name string) {
+		if known == nil || known[name] {
+			return
+		}
+		for _, b := range stack {
+			if b.loopVar == name {
+				return
+			}
+		}
+		fail(line, "undefined variable "+name)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(src, "\n"), "\n")
+	for i, text := range lines {
+		line := i + 1
+		m := templateTag.FindStringSubmatch(text)
+		if m == nil {
+			for _, v := range templateVar.FindAllStringSubmatch(text, -1) {
+				use(line, v[1])
+			}
+			continue
+		}
+		switch kind := m[1]; {
+		case kind == "end":
+			if len(stack) == 0 {
+				fail(line, "unexpected {end}")
+				continue
+			}
+			stack = stack[:len(stack)-1]
+		case kind == "else":
+			if len(stack) == 0 || stack[len(stack)-1].kind != "if" || stack[len(stack)-1].sawElse {
+				fail(line, "unexpected {else}")
+				continue
+			}
+			stack[len(stack)-1].sawElse = true
+		case m[2] == "for":
+			b := &block{kind: "for", line: line}
+			if f := templateFor.FindStringSubmatch(m[3]); f == nil {
+				fail(line, "malformed {for}, want {for item in list}")
+			} else {
+				use(line, f[2])
+				b.loopVar = f[1]
+			}
+			stack = append(stack, b)
+		default:
+			if f := templateIf.FindStringSubmatch(m[3]); f == nil {
+				fail(line, "malformed {if}, want {if name} or {if !name}")
+			} else {
+				use(line, f[1])
+			}
+			stack = append(stack, &block{kind: "if", line: line})
+		}
+	}
+	for _, b := range stack {
+		fail(b.line, "{"+b.kind+"} without {end}")
+	}
+	return errs
+}
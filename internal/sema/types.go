@@ -0,0 +1,88 @@
+package sema
+
+import (
+	"strconv"
+
+	"github.com/tasnimzotder/langz/internal/ast"
+)
+
+// Type is the inferred type of an expression.
+type Type string
+
+const (
+	Unknown Type = ""     // not inferable; compatible with everything
+	Void    Type = "void" // no value (statement-only calls)
+	Str     Type = "str"
+	Int     Type = "int"
+	Float   Type = "float"
+	Bool    Type = "bool"
+	List    Type = "list"
+	Map     Type = "map"
+	JSON    Type = "json" // JSON text, read and updated with jq
+)
+
+// declaredTypes are the type names accepted in parameter and return
+// annotations.
+var declaredTypes = map[string]Type{
+	"str":   Str,
+	"int":   Int,
+	"float": Float,
+	"bool":  Bool,
+	"list":  List,
+	"map":   Map,
+	"json":  JSON,
+}
+
+// compatible reports whether a value of type actual, produced by node, may be
+// used where expected is required. Everything in Bash is a string, so scalars
+// convert to str, and str variables may hold numbers or booleans at runtime;
+// only literals are checked strictly. An int promotes to float, but a float
+// never narrows to int.
+func compatible(actual, expected Type, node ast.Node) bool {
+	if actual == Unknown || expected == Unknown || actual == expected {
+		return true
+	}
+	switch expected {
+	case Str:
+		return actual == Int || actual == Float || actual == Bool || actual == JSON
+	case JSON:
+		// JSON is text, so any string may hold it
+		return actual == Str
+	case Float:
+		if actual == Int {
+			return true
+		}
+		if actual != Str {
+			return false
+		}
+		if lit, ok := node.(*ast.StringLiteral); ok {
+			_, err := strconv.ParseFloat(lit.Value, 64)
+			return err == nil
+		}
+		return true
+	case Int:
+		if actual != Str {
+			return false
+		}
+		if lit, ok := node.(*ast.StringLiteral); ok {
+			_, err := strconv.Atoi(lit.Value)
+			return err == nil
+		}
+		return true
+	case Bool:
+		if actual != Str {
+			return false
+		}
+		if lit, ok := node.(*ast.StringLiteral); ok {
+			return lit.Value == "true" || lit.Value == "false"
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// isScalar reports whether t can appear in arithmetic or comparisons.
+func isScalar(t Type) bool {
+	return t == Unknown || t == Str || t == Int || t == Float
+}
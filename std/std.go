@@ -0,0 +1,15 @@
+// Package std embeds the LangZ standard library: the modules scripts import
+// as "std/<name>". The library is built into the compiler, so a script
+// always gets the version that matches the compiler building it.
+package std
+
+import "embed"
+
+//go:embed *.lz
+var files embed.FS
+
+// Source returns the source of the module imported as "std/<name>".
+func Source(name string) ([]byte, bool) {
+	data, err := files.ReadFile(name + ".lz")
+	return data, err == nil
+}
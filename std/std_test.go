@@ -0,0 +1,48 @@
+package std_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tasnimzotder/langz/internal/codegen"
+	"github.com/tasnimzotder/langz/internal/lexer"
+	"github.com/tasnimzotder/langz/internal/modules"
+	"github.com/tasnimzotder/langz/internal/parser"
+	"github.com/tasnimzotder/langz/internal/sema"
+	"github.com/tasnimzotder/langz/std"
+)
+
+// Compiling each module as a program of its own checks every function,
+// used or not.
+func TestModulesCompile(t *testing.T) {
+	entries, err := os.ReadDir(".")
+	require.NoError(t, err)
+	for _, entry := range entries {
+		file := entry.Name()
+		if !strings.HasSuffix(file, ".lz") {
+			continue
+		}
+		t.Run(file, func(t *testing.T) {
+			source, ok := std.Source(strings.TrimSuffix(file, ".lz"))
+			require.True(t, ok)
+			prog, parseErrs := parser.NewFile(lexer.New(string(source)).Tokenize(), file).ParseAllErrors()
+			require.Empty(t, parseErrs)
+			require.Empty(t, modules.Resolve(prog, file, &modules.SearchPath{}))
+			require.Empty(t, sema.Check(prog))
+			_, genErrs := codegen.Generate(prog)
+			assert.Empty(t, genErrs)
+		})
+	}
+}
+
+func TestSourceUnknownModule(t *testing.T) {
+	_, ok := std.Source("nope")
+	assert.False(t, ok)
+	_, ok = std.Source(filepath.Join("..", "std", "log"))
+	assert.False(t, ok)
+}
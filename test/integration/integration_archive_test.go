@@ -0,0 +1,239 @@
+package integration_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// needTools skips the test unless every tool is installed.
+func needTools(t *testing.T, tools ...string) {
+	t.Helper()
+	for _, tool := range tools {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s not installed", tool)
+		}
+	}
+}
+
+// writeTree creates files under dir from a map of relative path to content.
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+// readTree returns the regular files under dir, as relative path to content.
+func readTree(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	require.NoError(t, filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files[filepath.ToSlash(rel)] = mustReadFile(t, path)
+		return nil
+	}))
+	return files
+}
+
+var projectTree = map[string]string{
+	"app/main.sh":             "echo main\n",
+	"app/lib/util sh.txt":     "util with spaces\n",
+	"app/debug.log":           "noise\n",
+	"app/.git/HEAD":           "ref: refs/heads/main\n",
+	"app/lib/.git/keep":       "nested\n",
+	"README.md":               "# readme\n",
+	"app/lib/deep/nested.txt": "deep\n",
+}
+
+func TestE2E_ArchiveRoundTrip(t *testing.T) {
+	cases := []struct {
+		name  string
+		tools []string
+	}{
+		{"app.tar.gz", []string{"tar", "gzip"}},
+		{"app.tgz", []string{"tar", "gzip"}},
+		{"app.tar.zst", []string{"tar", "zstd"}},
+		{"app.tar", []string{"tar"}},
+		{"app.zip", []string{"zip", "unzip"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			needTools(t, tc.tools...)
+			dir := t.TempDir()
+			writeTree(t, dir, projectTree)
+
+			source := strings.ReplaceAll(`dest = "NAME"
+archive(dest, ["app", "README.md"], exclude: ["*.log", ".git"])
+entries = list_archive(dest)
+for e in entries {
+	print(e)
+}
+extract(dest, "out")
+extract(dest, "stripped", strip: 1)
+`, "NAME", tc.name)
+			output, code := runInDir(t, dir, source)
+			require.Equal(t, 0, code, output)
+
+			var files []string
+			for _, line := range strings.Split(output, "\n") {
+				if !strings.HasSuffix(line, "/") {
+					files = append(files, line)
+				}
+			}
+			sort.Strings(files)
+			assert.Equal(t, []string{"README.md", "app/lib/deep/nested.txt", "app/lib/util sh.txt", "app/main.sh"}, files)
+
+			assert.Equal(t, map[string]string{
+				"README.md":               "# readme\n",
+				"app/main.sh":             "echo main\n",
+				"app/lib/util sh.txt":     "util with spaces\n",
+				"app/lib/deep/nested.txt": "deep\n",
+			}, readTree(t, filepath.Join(dir, "out")))
+			assert.Equal(t, map[string]string{
+				"main.sh":             "echo main\n",
+				"lib/util sh.txt":     "util with spaces\n",
+				"lib/deep/nested.txt": "deep\n",
+			}, readTree(t, filepath.Join(dir, "stripped")))
+		})
+	}
+}
+
+func TestE2E_BreakOutOfListArchive(t *testing.T) {
+	for _, name := range []string{"out.zip", "out.tar.gz"} {
+		t.Run(name, func(t *testing.T) {
+			needTools(t, "tar", "gzip", "zip", "unzip")
+			dir := t.TempDir()
+			// Enough entries that the listing is still being written when
+			// the loop stops reading it
+			files := map[string]string{}
+			for i := 0; i < 2000; i++ {
+				files[fmt.Sprintf("src/a/file-with-a-long-name-%04d.txt", i)] = "x"
+			}
+			writeTree(t, dir, files)
+
+			source := strings.ReplaceAll(`archive("NAME", ["src"])
+for e in list_archive("NAME") {
+	if e == "src/a/" {
+		break
+	}
+	print(e)
+}
+print("after")
+`, "NAME", name)
+			output, code := runInDir(t, dir, source)
+
+			assert.Equal(t, 0, code)
+			assert.Equal(t, "src/\nafter", output)
+		})
+	}
+}
+
+func TestE2E_ArchiveReadableByOtherTools(t *testing.T) {
+	needTools(t, "tar", "gzip", "zip")
+	dir := t.TempDir()
+	writeTree(t, dir, projectTree)
+
+	output, code := runInDir(t, dir, `archive("app.tar.gz", ["app/main.sh"])
+archive("app.pkg", ["app/main.sh"], format: "zip")`)
+	require.Equal(t, 0, code, output)
+
+	f, err := os.Open(filepath.Join(dir, "app.tar.gz"))
+	require.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+	hdr, err := tr.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "app/main.sh", hdr.Name)
+	content, _ := io.ReadAll(tr)
+	assert.Equal(t, "echo main\n", string(content))
+
+	zr, err := zip.OpenReader(filepath.Join(dir, "app.pkg"))
+	require.NoError(t, err)
+	defer zr.Close()
+	require.Len(t, zr.File, 1)
+	assert.Equal(t, "app/main.sh", zr.File[0].Name)
+}
+
+func TestE2E_ExtractForeignArchives(t *testing.T) {
+	needTools(t, "tar", "gzip", "unzip")
+	dir := t.TempDir()
+
+	f, err := os.Create(filepath.Join(dir, "release.tgz"))
+	require.NoError(t, err)
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	body := "#!/bin/sh\necho tool\n"
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "tool-1.0/bin/tool", Mode: 0755, Size: int64(len(body))}))
+	_, err = tw.Write([]byte(body))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	require.NoError(t, f.Close())
+
+	zf, err := os.Create(filepath.Join(dir, "release.zip"))
+	require.NoError(t, err)
+	zw := zip.NewWriter(zf)
+	w, err := zw.Create("tool-1.0/share/doc.txt")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("docs\n"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	require.NoError(t, zf.Close())
+
+	output, code := runInDir(t, dir, `extract("release.tgz", "opt", strip: 1)
+extract("release.zip", "opt", strip: 1)
+$ ./opt/bin/tool`)
+
+	require.Equal(t, 0, code, output)
+	assert.Equal(t, "tool", output)
+	assert.Equal(t, map[string]string{"bin/tool": body, "share/doc.txt": "docs\n"}, readTree(t, filepath.Join(dir, "opt")))
+}
+
+func TestE2E_ArchiveErrors(t *testing.T) {
+	needTools(t, "tar", "gzip")
+	cases := map[string]string{
+		`archive("out.tar.gz", ["README.md", "missing"])`:                                      "archive: out.tar.gz: missing does not exist",
+		"name = \"out.rar\"\narchive(name, [\"README.md\"])":                                   "archive: cannot tell the format of out.rar from its name; want .tar.gz, .tgz, .tar.zst, .tar or .zip",
+		"name = \"out.bin\"\nformat = \"rar\"\narchive(name, [\"README.md\"], format: format)": `archive: unknown format "rar", want tar.gz, tar.zst, tar or zip`,
+		`extract("missing.tar.gz", "out")`:                                                     "extract: cannot read missing.tar.gz",
+		"files = []\narchive(\"out.tar.gz\", files)":                                           "archive: out.tar.gz: no sources given",
+	}
+	for source, want := range cases {
+		dir := t.TempDir()
+		writeTree(t, dir, projectTree)
+
+		output, code := runInDir(t, dir, source+"\nprint(\"not reached\")")
+
+		assert.Equal(t, 1, code, source)
+		assert.Equal(t, want, output, source)
+		assert.NoFileExists(t, filepath.Join(dir, "out.tar.gz"), source)
+	}
+}
+
+func TestE2E_ArchiveNeedsTool(t *testing.T) {
+	script := compileSource(t, "print(\"start\")\narchive(\"app.tar.zst\", [\"deploy\"])\nprint(\"not reached\")")
+
+	output, code := runWithTools(t, script, "tar")
+
+	assert.Equal(t, 127, code)
+	assert.Equal(t, "start\narchive: app.tar.zst needs zstd, which was not found; install it and try again", output)
+}
@@ -128,3 +128,103 @@ func TestE2E_ShebangAutoDetect(t *testing.T) {
 	require.NoError(t, err, "auto-detect failed: %s", string(out))
 	assert.Equal(t, "shebang auto", strings.TrimSpace(string(out)))
 }
+
+func TestE2E_NamespacedImportsViaCLI(t *testing.T) {
+	root := projectRoot(t)
+	dir := t.TempDir()
+
+	require.NoError(t, os.MkdirAll(dir+"/lib", 0755))
+	require.NoError(t, os.WriteFile(dir+"/lib/log.lz", []byte(`print("log loaded")
+fn log(msg: str) {
+    print("[log] {msg}")
+}
+`), 0644))
+	require.NoError(t, os.WriteFile(dir+"/lib/k8s.lz", []byte(`import "log.lz"
+namespace = "default"
+fn apply(file: str) {
+    log("apply {file} in {namespace}")
+}
+`), 0644))
+	require.NoError(t, os.WriteFile(dir+"/lib/util.lz", []byte(`from "log.lz" import log
+fn retry(n: int) {
+    log("retry {n}")
+}
+`), 0644))
+	require.NoError(t, os.WriteFile(dir+"/main.lz", []byte(`import "lib/k8s.lz" as k8s
+from "lib/util.lz" import retry
+fn log(msg: str) {
+    print("[main] {msg}")
+}
+k8s.apply("app.yaml")
+retry(2)
+ns = k8s.namespace
+log("done in {ns}")
+`), 0644))
+
+	cmd := exec.Command("go", "run", root+"/cmd/langz", "run", dir+"/main.lz")
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, "run failed: %s", string(out))
+	assert.Equal(t, "log loaded\n[log] apply app.yaml in default\n[log] retry 2\n[main] done in default", strings.TrimSpace(string(out)))
+}
+
+func TestE2E_ImportFromLangzPath(t *testing.T) {
+	shared := t.TempDir()
+	require.NoError(t, os.MkdirAll(shared+"/infra", 0755))
+	require.NoError(t, os.WriteFile(shared+"/infra/k8s.lz", []byte(`fn apply(file: str) {
+    print("apply {file}")
+}
+`), 0644))
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(dir+"/main.lz", []byte(`import "infra/k8s.lz" as k8s
+k8s.apply("app.yaml")
+`), 0644))
+	t.Setenv("LANGZ_PATH", shared)
+
+	output, code := runBash(t, compileFile(t, dir+"/main.lz"))
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "apply app.yaml", output)
+}
+
+func TestE2E_VendorViaCLI(t *testing.T) {
+	root := projectRoot(t)
+	shared := t.TempDir()
+	require.NoError(t, os.MkdirAll(shared+"/infra", 0755))
+	require.NoError(t, os.WriteFile(shared+"/infra/k8s.lz", []byte(`import "log.lz"
+fn apply(file: str) {
+    log("apply {file}")
+}
+`), 0644))
+	require.NoError(t, os.WriteFile(shared+"/infra/log.lz", []byte(`fn log(msg: str) {
+    print("[shared] {msg}")
+}
+`), 0644))
+
+	project := t.TempDir()
+	require.NoError(t, os.WriteFile(project+"/langz.mod", []byte("path "+shared+"\n"), 0644))
+	require.NoError(t, os.MkdirAll(project+"/scripts", 0755))
+	require.NoError(t, os.WriteFile(project+"/scripts/deploy.lz", []byte(`import "infra/k8s.lz" as k8s
+k8s.apply("app.yaml")
+`), 0644))
+
+	// vendor works on the project in the current directory, where go run
+	// can't find the module, so build the CLI first
+	langz := t.TempDir() + "/langz"
+	out, err := exec.Command("go", "build", "-o", langz, root+"/cmd/langz").CombinedOutput()
+	require.NoError(t, err, "build failed: %s", string(out))
+	cmd := exec.Command(langz, "vendor")
+	cmd.Dir = project
+	out, err = cmd.CombinedOutput()
+	require.NoError(t, err, "vendor failed: %s", string(out))
+	assert.Equal(t, "Vendored vendor/infra/k8s.lz\nVendored vendor/infra/log.lz", strings.TrimSpace(string(out)))
+
+	// The vendored copy is what the project builds with now
+	require.NoError(t, os.WriteFile(shared+"/infra/log.lz", []byte(`fn log(msg: str) {
+    print("[changed] {msg}")
+}
+`), 0644))
+	cmd = exec.Command(langz, "run", project+"/scripts/deploy.lz")
+	out, err = cmd.CombinedOutput()
+	require.NoError(t, err, "run failed: %s", string(out))
+	assert.Equal(t, "[shared] apply app.yaml", strings.TrimSpace(string(out)))
+}
@@ -0,0 +1,134 @@
+package integration_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestE2E_DeferRunsInReverseOrderAtExit(t *testing.T) {
+	source := `
+defer print("first")
+defer {
+	print("second")
+	print("third")
+}
+print("body")
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "body\nsecond\nthird\nfirst", output)
+}
+
+func TestE2E_DeferUnwindsOnFunctionReturn(t *testing.T) {
+	source := `
+fn inner() {
+	defer print("inner cleanup")
+	print("inner")
+}
+
+fn outer() {
+	defer print("outer cleanup")
+	inner()
+	print("outer")
+}
+
+fn name() -> str {
+	defer print("name cleanup")
+	return "langz"
+}
+
+defer print("script cleanup")
+outer()
+n = name()
+print(n)
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "inner\ninner cleanup\nouter\nouter cleanup\nname cleanup\nlangz\nscript cleanup", output)
+}
+
+func TestE2E_DeferCapturesValues(t *testing.T) {
+	source := `
+items = ["a", "b", "c"]
+for item in items {
+	defer print("remove {item}")
+}
+item = "changed"
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "remove c\nremove b\nremove a", output)
+}
+
+func TestE2E_DeferRunsOnError(t *testing.T) {
+	source := `
+fn deploy() {
+	defer print("release lock")
+	exec("exit 4")
+	print("not reached")
+}
+
+defer print("remove temp dir")
+deploy()
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 4, code)
+	assert.Equal(t, "release lock\nremove temp dir", output)
+}
+
+func TestE2E_DeferRunsOnSignal(t *testing.T) {
+	source := `
+defer print("cleanup")
+exec("kill -TERM $$")
+print("not reached")
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 143, code)
+	assert.Equal(t, "cleanup", output)
+}
+
+func TestE2E_DeferInsideTry(t *testing.T) {
+	source := `
+fn work() {
+	try {
+		defer print("deferred in try")
+		exec("false")
+	} catch {
+		print("caught")
+	}
+	print("after try")
+}
+
+work()
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "caught\nafter try\ndeferred in try", output)
+}
+
+func TestE2E_DeferAndSpawnCompose(t *testing.T) {
+	source := `
+job = spawn("sleep 30")
+defer print("deferred")
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	// The job holds the output pipe open, so this only returns promptly
+	// if the job was killed after the deferred print
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "deferred", output)
+}
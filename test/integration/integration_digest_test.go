@@ -0,0 +1,211 @@
+package integration_test
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runWithTools runs script with PATH holding only the named tools, and
+// skips the test when one of them isn't installed.
+func runWithTools(t *testing.T, script string, tools ...string) (string, int) {
+	t.Helper()
+	bash, err := exec.LookPath("bash")
+	require.NoError(t, err)
+	bin := t.TempDir()
+	for _, tool := range tools {
+		path, err := exec.LookPath(tool)
+		if err != nil {
+			t.Skipf("%s not installed", tool)
+		}
+		require.NoError(t, os.Symlink(path, filepath.Join(bin, tool)))
+	}
+	path := filepath.Join(t.TempDir(), "deploy")
+	require.NoError(t, os.WriteFile(path, []byte(script), 0755))
+
+	cmd := exec.Command(bash, path)
+	cmd.Dir = filepath.Dir(path)
+	cmd.Env = []string{"PATH=" + bin}
+	out, err := cmd.CombinedOutput()
+	code := 0
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		code = exitErr.ExitCode()
+	}
+	return strings.TrimSpace(string(out)), code
+}
+
+func TestE2E_Digests(t *testing.T) {
+	dir := t.TempDir()
+	artifact := filepath.Join(dir, "app.tar.gz")
+	data := []byte("release 1.2.3\n\x00\xff binary")
+	require.NoError(t, os.WriteFile(artifact, data, 0644))
+	sum := fmt.Sprintf("%x", sha256.Sum256(data))
+
+	source := strings.ReplaceAll(`print(sha256("ARTIFACT"))
+print(sha1("ARTIFACT"))
+print(md5("ARTIFACT"))
+print(sha256("hello"))
+print(md5("not a file"))
+`, "ARTIFACT", artifact)
+	output, code := runBash(t, compileSource(t, source))
+
+	assert.Equal(t, 0, code, output)
+	assert.Equal(t, strings.Join([]string{
+		sum,
+		fmt.Sprintf("%x", sha1.Sum(data)),
+		fmt.Sprintf("%x", md5.Sum(data)),
+		fmt.Sprintf("%x", sha256.Sum256([]byte("hello"))),
+		fmt.Sprintf("%x", md5.Sum([]byte("not a file"))),
+	}, "\n"), output)
+}
+
+func TestE2E_VerifyChecksum(t *testing.T) {
+	dir := t.TempDir()
+	artifact := filepath.Join(dir, "app.tar.gz")
+	data := []byte("release 1.2.3\n")
+	require.NoError(t, os.WriteFile(artifact, data, 0644))
+	sum := fmt.Sprintf("%x", sha256.Sum256(data))
+
+	source := strings.ReplaceAll(strings.ReplaceAll(`f = "ARTIFACT"
+sums = ["SUM", "sha256:SUM", "SUM  app.tar.gz", "SHA1", "MD5", "0000000000000000000000000000000000000000000000000000000000000000", "sha1:SUM", "nonsense"]
+for sum in sums {
+	if verify_checksum(f, sum) {
+		print("match")
+	} else {
+		print("mismatch")
+	}
+}
+missing = "DIR/missing.tar.gz"
+ok = verify_checksum(missing, "SUM")
+print(ok)
+`, "SUM", sum), "ARTIFACT", artifact)
+	source = strings.NewReplacer("SHA1", fmt.Sprintf("%X", sha1.Sum(data)), "MD5", fmt.Sprintf("md5:%x", md5.Sum(data)), "DIR", dir).Replace(source)
+	output, code := runBash(t, compileSource(t, source))
+
+	assert.Equal(t, 0, code, output)
+	assert.Equal(t, strings.Join([]string{
+		"match", "match", "match", "match", "match", "mismatch",
+		fmt.Sprintf(`verify_checksum: "sha1:%s" is not a sha256, sha1 or md5 checksum`, sum),
+		"mismatch",
+		`verify_checksum: "nonsense" is not a sha256, sha1 or md5 checksum`,
+		"mismatch",
+		"verify_checksum: cannot read " + dir + "/missing.tar.gz",
+		"false",
+	}, "\n"), output)
+}
+
+func TestE2E_Encoding(t *testing.T) {
+	source := `text = "héllo wörld & more/stuff?x=1"
+encoded = base64_encode(text)
+print(encoded)
+print(base64_decode(encoded))
+print(hex_encode(text))
+escaped = url_encode(text)
+print(escaped)
+print(url_decode(escaped))
+print(url_decode("a+b%2Bc%5cd\\n"))
+long = base64_encode("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")
+print(long)
+print(base64_decode(long))
+`
+	output, code := runBash(t, compileSource(t, source))
+
+	assert.Equal(t, 0, code, output)
+	assert.Equal(t, strings.Join([]string{
+		"aMOpbGxvIHfDtnJsZCAmIG1vcmUvc3R1ZmY/eD0x",
+		"héllo wörld & more/stuff?x=1",
+		"68c3a96c6c6f2077c3b6726c642026206d6f72652f73747566663f783d31",
+		"h%C3%A9llo%20w%C3%B6rld%20%26%20more%2Fstuff%3Fx%3D1",
+		"héllo wörld & more/stuff?x=1",
+		`a+b+c\d\n`,
+		"YWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFh",
+		strings.Repeat("a", 90),
+	}, "\n"), output)
+}
+
+func TestE2E_DecodeErrors(t *testing.T) {
+	cases := map[string]string{
+		`x = base64_decode("not base64!")`: `base64_decode: invalid base64 "not base64!"`,
+		`x = base64_decode("YWJj=")`:       `base64_decode: invalid base64 "YWJj="`,
+		`x = url_decode("100%")`:           `url_decode: invalid escape "%" in "100%"`,
+		`x = url_decode("a%zzb")`:          `url_decode: invalid escape "%z" in "a%zzb"`,
+	}
+	for source, want := range cases {
+		output, code := runBash(t, compileSource(t, source+"\nprint(\"not reached\")"))
+
+		assert.Equal(t, 1, code, source)
+		assert.Equal(t, want, output, source)
+	}
+}
+
+func TestE2E_DigestFallbacks(t *testing.T) {
+	data := "release 1.2.3"
+	want := strings.Join([]string{
+		fmt.Sprintf("%x", sha256.Sum256([]byte(data))),
+		fmt.Sprintf("%x", sha1.Sum([]byte(data))),
+		"cmVsZWFzZSAxLjIuMw==",
+		data,
+		"verified",
+	}, "\n")
+	script := compileSource(t, fmt.Sprintf(`s = %q
+sum = sha256(s)
+print(sum)
+print(sha1(s))
+encoded = base64_encode(s)
+print(encoded)
+print(base64_decode(encoded))
+write("artifact", s)
+sum = sha256("artifact")
+if verify_checksum("artifact", sum) {
+	print("verified")
+}
+`, data))
+
+	for _, tools := range [][]string{
+		{"sha256sum", "sha1sum", "base64"},
+		{"shasum", "base64"},
+		{"openssl"},
+	} {
+		t.Run(strings.Join(tools, ","), func(t *testing.T) {
+			output, code := runWithTools(t, script, tools...)
+
+			assert.Equal(t, 0, code, output)
+			assert.Equal(t, want, output)
+		})
+	}
+}
+
+func TestE2E_MD5Fallbacks(t *testing.T) {
+	script := compileSource(t, `print(md5("release"))`)
+	want := fmt.Sprintf("%x", md5.Sum([]byte("release")))
+
+	for _, tool := range []string{"md5sum", "md5", "openssl"} {
+		t.Run(tool, func(t *testing.T) {
+			output, code := runWithTools(t, script, tool)
+
+			assert.Equal(t, 0, code, output)
+			assert.Equal(t, want, output)
+		})
+	}
+}
+
+func TestE2E_DigestNeedsATool(t *testing.T) {
+	output, code := runWithTools(t, compileSource(t, "print(\"start\")\nprint(sha256(\"x\"))"))
+
+	assert.Equal(t, 127, code)
+	assert.Equal(t, "deploy: sha256() needs sha256sum, shasum or openssl, but none was found", output)
+
+	output, code = runWithTools(t, compileSource(t, "print(\"start\")\nprint(base64_encode(\"x\"))"))
+
+	assert.Equal(t, 127, code)
+	assert.Equal(t, "deploy: base64 encoding needs base64 or openssl, but neither was found", output)
+}
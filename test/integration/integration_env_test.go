@@ -0,0 +1,167 @@
+package integration_test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// printEnv prints each variable as NAME=[value].
+func printEnv(names ...string) string {
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "value = env(\"%s\")\nprint(\"%s=[{value}]\")\n", name, name)
+	}
+	return b.String()
+}
+
+// runInDir compiles source and runs it in dir with the extra environment.
+func runInDir(t *testing.T, dir, source string, env ...string) (string, int) {
+	t.Helper()
+	path := filepath.Join(dir, "script.sh")
+	require.NoError(t, os.WriteFile(path, []byte(compileSource(t, source)), 0755))
+
+	cmd := exec.Command("bash", path)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	out, err := cmd.CombinedOutput()
+	code := 0
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		code = exitErr.ExitCode()
+	}
+	return strings.TrimSpace(string(out)), code
+}
+
+const dotenv = `# settings for the app
+export APP_NAME=web   # inline comment
+  SPACED = hello world
+EMPTY=
+NO_COMMENT=a#b
+COMMENT=a #b
+SINGLE='literal $HOME \n # kept'   # dropped
+DOUBLE="tab\there \"quoted\" \$HOME \\ back"
+MULTI="first
+second"
+CMD=$(touch pwned) ` + "`touch pwned2`" + `
+PRESET=from-file
+`
+
+func TestE2E_LoadEnv(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte(dotenv+"WINDOWS=crlf\r\n"), 0644))
+	source := "load_env(\".env\")\n" +
+		printEnv("APP_NAME", "SPACED", "EMPTY", "NO_COMMENT", "COMMENT", "SINGLE", "DOUBLE", "MULTI", "CMD", "PRESET", "WINDOWS") +
+		"load_env(\".env\", override: true)\nprint(env(\"PRESET\"))"
+	output, code := runInDir(t, dir, source, "PRESET=from-env")
+
+	assert.Equal(t, 0, code, output)
+	assert.Equal(t, strings.Join([]string{
+		"APP_NAME=[web]",
+		"SPACED=[hello world]",
+		"EMPTY=[]",
+		"NO_COMMENT=[a#b]",
+		"COMMENT=[a]",
+		`SINGLE=[literal $HOME \n # kept]`,
+		"DOUBLE=[tab\there \"quoted\" $HOME \\ back]",
+		"MULTI=[first\nsecond]",
+		"CMD=[$(touch pwned) `touch pwned2`]",
+		"PRESET=[from-env]",
+		"WINDOWS=[crlf]",
+		"from-file",
+	}, "\n"), output)
+	assert.NoFileExists(t, filepath.Join(dir, "pwned"))
+	assert.NoFileExists(t, filepath.Join(dir, "pwned2"))
+}
+
+func TestE2E_LoadEnvExportsToCommands(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("export TOKEN='s3cr3t value'\n"), 0644))
+
+	output, code := runInDir(t, dir, "load_env(\".env\")\n$ bash -c 'echo \"child sees $TOKEN\"'")
+
+	assert.Equal(t, 0, code, output)
+	assert.Equal(t, "child sees s3cr3t value", output)
+}
+
+func TestE2E_LoadEnvErrors(t *testing.T) {
+	cases := map[string]string{
+		"A=1\nnot a setting\n": "load_env: .env, line 2: expected KEY=value",
+		"A=\"open\nB=2\n":      `load_env: .env, line 1: missing closing "`,
+		"A='x'y\n":             "load_env: .env, line 1: unexpected text after closing '",
+		"1A=x\n":               "load_env: .env, line 1: expected KEY=value",
+	}
+	for content, want := range cases {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte(content), 0644))
+
+		output, code := runInDir(t, dir, "load_env(\".env\")\nprint(\"not reached\")")
+
+		assert.Equal(t, 1, code, content)
+		assert.Equal(t, want, output, content)
+	}
+}
+
+func TestE2E_ReadIni(t *testing.T) {
+	dir := t.TempDir()
+	ini := `; global settings
+name = top
+[database]
+host = db.local   ; comment
+port=5432
+# another comment
+password = "p;a#ss word"
+[ server ]
+url = http://example.com/?a=b
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.ini"), []byte(ini), 0644))
+	source := `cfg = read_ini("app.ini")
+print(len(cfg))
+keys = ["name", "database.host", "database.port", "database.password", "server.url"]
+for key in keys {
+	value = cfg[key]
+	print("{key}=[{value}]")
+}
+`
+	output, code := runInDir(t, dir, source)
+
+	assert.Equal(t, 0, code, output)
+	assert.Equal(t, "5\nname=[top]\ndatabase.host=[db.local]\ndatabase.port=[5432]\ndatabase.password=[p;a#ss word]\nserver.url=[http://example.com/?a=b]", output)
+}
+
+func TestE2E_WriteEnvRoundTrips(t *testing.T) {
+	dir := t.TempDir()
+	source := `settings = {PLAIN: "abc", URL: "https://x.io/a?b=c", SPACE: "a b", QUOTE: "it's", DOLLAR: "$HOME \"x\" ` + "`id`" + `", NL: "a\nb", HASH: "x #y", EMPTY: ""}
+write_env("out.env", settings)
+load_env("out.env")
+` + printEnv("PLAIN", "URL", "SPACE", "QUOTE", "DOLLAR", "NL", "HASH", "EMPTY")
+	output, code := runInDir(t, dir, source)
+
+	want := strings.Join([]string{
+		"PLAIN=[abc]",
+		"URL=[https://x.io/a?b=c]",
+		"SPACE=[a b]",
+		"QUOTE=[it's]",
+		"DOLLAR=[$HOME \"x\" `id`]",
+		"NL=[a\nb]",
+		"HASH=[x #y]",
+		"EMPTY=[]",
+	}, "\n")
+	assert.Equal(t, 0, code, output)
+	assert.Equal(t, want, output)
+	assert.Equal(t, "DOLLAR='$HOME \"x\" `id`'\nEMPTY=\nHASH='x #y'\nNL=\"a\nb\"\nPLAIN=abc\nQUOTE=\"it's\"\nSPACE='a b'\nURL='https://x.io/a?b=c'\n",
+		mustReadFile(t, filepath.Join(dir, "out.env")))
+
+	// Bash reads the file back the same way
+	check := `set -a; . ./out.env; for n in PLAIN URL SPACE QUOTE DOLLAR NL HASH EMPTY; do printf '%s=[%s]\n' "$n" "${!n}"; done`
+	cmd := exec.Command("bash", "-c", check)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	assert.Equal(t, want, strings.TrimSpace(string(out)))
+}
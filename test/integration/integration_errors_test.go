@@ -36,7 +36,7 @@ func TestE2E_CodegenErrorCausesNonEmptyErrors(t *testing.T) {
 
 	_, errs := codegen.Generate(prog)
 	require.NotEmpty(t, errs, "codegen should report write() arity error")
-	assert.Contains(t, errs[0], "write()")
+	assert.Contains(t, errs[0].Message, "write()")
 }
 
 func TestE2E_EmptyFileCompiles(t *testing.T) {
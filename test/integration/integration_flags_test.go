@@ -0,0 +1,107 @@
+package integration_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const flagsScript = `
+flags {
+    env: str = "staging" env "DEPLOY_ENV" "Environment to deploy to"
+    replicas: int = 2
+    dry_run: bool
+    targets: list required env "TARGETS"
+}
+print(env, replicas, dry_run, len(targets))
+for t in targets {
+    print("target {t}")
+}
+for a in args() {
+    print("arg {a}")
+}
+`
+
+// runFlags runs the compiled flagsScript as deploy with args and the
+// extra environment, returning its combined output and exit code.
+func runFlags(t *testing.T, env []string, args ...string) (string, int) {
+	t.Helper()
+	script := filepath.Join(t.TempDir(), "deploy")
+	require.NoError(t, os.WriteFile(script, []byte(compileSource(t, flagsScript)), 0755))
+
+	cmd := exec.Command("bash", append([]string{script}, args...)...)
+	cmd.Env = append(os.Environ(), env...)
+	out, err := cmd.CombinedOutput()
+	code := 0
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		code = exitErr.ExitCode()
+	}
+	return strings.TrimSpace(string(out)), code
+}
+
+func TestE2E_FlagsParseArguments(t *testing.T) {
+	output, code := runFlags(t, nil,
+		"--env", "prod", "--replicas=3", "--dry-run", "--targets", "a", "--targets=b c", "one", "--", "--two")
+
+	assert.Equal(t, 0, code, output)
+	assert.Equal(t, "prod 3 true 2\ntarget a\ntarget b c\narg one\narg --two", output)
+}
+
+func TestE2E_FlagsFallBackToEnvAndDefaults(t *testing.T) {
+	output, code := runFlags(t, []string{"DEPLOY_ENV=qa", "TARGETS=x,y"}, "--no-dry-run")
+	assert.Equal(t, 0, code, output)
+	assert.Equal(t, "qa 2 false 2\ntarget x\ntarget y", output)
+
+	output, code = runFlags(t, []string{"DEPLOY_ENV=qa"}, "--env=prod", "--targets", "x")
+	assert.Equal(t, 0, code, output)
+	assert.Equal(t, "prod 2 false 1\ntarget x", output)
+}
+
+func TestE2E_FlagsErrors(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, "deploy: missing required flag --targets (or set TARGETS)"},
+		{[]string{"--targets", "a", "--replicas", "two"}, "deploy: --replicas must be an int, got 'two'"},
+		{[]string{"--targets", "a", "--dry-run=maybe"}, "deploy: --dry-run must be true or false, got 'maybe'"},
+		{[]string{"--targets", "a", "--force"}, "deploy: unknown flag: --force"},
+		{[]string{"--env"}, "deploy: --env needs a value"},
+	}
+	for _, tt := range tests {
+		output, code := runFlags(t, []string{"TARGETS="}, tt.args...)
+		assert.Equal(t, 2, code, output)
+		assert.Equal(t, tt.want+"\nRun 'deploy --help' for usage.", output)
+	}
+}
+
+func TestE2E_FlagsHelp(t *testing.T) {
+	output, code := runFlags(t, nil, "--help")
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, `Usage: deploy [options] [args...]
+
+Options:
+  --env <str>           Environment to deploy to (default: staging, env: DEPLOY_ENV)
+  --replicas <int>      (default: 2)
+  --[no-]dry-run
+  --targets <value>...  (required, env: TARGETS)
+  -h, --help            Show this help`, output)
+}
+
+func TestE2E_CLIRunPassesArguments(t *testing.T) {
+	lzFile := filepath.Join(t.TempDir(), "deploy.lz")
+	require.NoError(t, os.WriteFile(lzFile, []byte(flagsScript), 0644))
+
+	cmd := exec.Command("go", "run", "./cmd/langz", "run", lzFile, "--env", "prod", "--targets", "web", "extra")
+	cmd.Dir = projectRoot(t)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, "run failed: %s", string(out))
+
+	assert.Equal(t, "prod 2 false 1\ntarget web\narg extra", strings.TrimSpace(string(out)))
+}
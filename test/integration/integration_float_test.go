@@ -0,0 +1,70 @@
+package integration_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestE2E_FloatPercentage(t *testing.T) {
+	source := `
+used = 37
+total = 112
+ratio = used / total * 100.0
+print(ratio)
+print(round(ratio, 1))
+if ratio > 33.0 {
+	print("over")
+}
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	lines := strings.Split(output, "\n")
+	assert.Equal(t, "33.03571429", lines[0])
+	assert.Equal(t, "33", lines[1])
+	assert.Equal(t, "over", lines[2])
+}
+
+func TestE2E_IntDivisionTruncates(t *testing.T) {
+	bash := compileSource(t, `print(7 / 2)`)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "3", output)
+}
+
+func TestE2E_RoundFloorCeil(t *testing.T) {
+	source := `
+neg = 0 - 2.5
+print(round(2.5), round(neg), floor(2.7), floor(neg), ceil(2.1), ceil(neg))
+print(round(3.14159, 2))
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	lines := strings.Split(output, "\n")
+	assert.Equal(t, "3 -3 2 -3 3 -2", lines[0])
+	assert.Equal(t, "3.14", lines[1])
+}
+
+func TestE2E_FloatFunction(t *testing.T) {
+	source := `
+fn average(a: float, b: float) -> float {
+	return (a + b) / 2
+}
+avg = average(1, 2)
+print(avg)
+if avg == 1.5 {
+	print("equal")
+}
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "1.5\nequal", output)
+}
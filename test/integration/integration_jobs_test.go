@@ -0,0 +1,124 @@
+package integration_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestE2E_SpawnWaitExitCode(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "job.log")
+	source := `
+job = spawn("sh -c 'echo working; exit 3'", log: "` + log + `")
+code = wait(job)
+print("code {code}")
+print(read("` + log + `"))
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "code 3\nworking", output)
+}
+
+func TestE2E_KillAndIsRunning(t *testing.T) {
+	source := `
+fn stop(job: int) {
+	kill(job)
+	wait_all()
+}
+
+server = spawn("sleep 30")
+if is_running(server) {
+	print("running")
+}
+stop(server)
+if !is_running(server) {
+	print("stopped")
+}
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "running\nstopped", output)
+}
+
+func TestE2E_SpawnInLoopAndWaitAll(t *testing.T) {
+	dir := t.TempDir()
+	source := `
+for i in range(1, 3) {
+	spawn("touch ` + dir + `/{i}")
+}
+wait_all()
+files = glob("` + dir + `/*")
+print(len(files))
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "3", output)
+}
+
+func TestE2E_ExitKillsRunningJobs(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	source := `
+fn start() -> int {
+	job = spawn("sleep 30")
+	return job
+}
+job = start()
+write("` + pidFile + `", job)
+`
+	bash := compileSource(t, source)
+	_, code := runBash(t, bash)
+	assert.Equal(t, 0, code)
+
+	data, err := os.ReadFile(pidFile)
+	assert.NoError(t, err)
+	// An orphaned job may linger as a zombie until init reaps it
+	pid := strings.TrimSpace(string(data))
+	_, code = runBash(t, "sleep 0.1; ! kill -0 "+pid+" 2>/dev/null || grep -qs '^State:\\s*Z' /proc/"+pid+"/status")
+	assert.Equal(t, 0, code, "job should be killed when the script exits")
+}
+
+func TestE2E_SpawnRunsListAndPipelineAsOneJob(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "job.log")
+	source := `
+job = spawn("echo one; echo two | tr a-z A-Z; exit 4", log: "` + log + `")
+print("running", is_running(job))
+code = wait(job)
+print("code {code}", is_running(job))
+for line in lines(read("` + log + `")) {
+	print(line)
+}
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "running true\ncode 4 false\none\nTWO", output)
+}
+
+func TestE2E_KillStopsWholeJob(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "marker")
+	source := `
+job = spawn("sh -c 'sleep 0.5; touch ` + marker + `' | cat")
+sleep(0.2)
+kill(job)
+wait_all()
+sleep(1)
+if !exists("` + marker + `") {
+	print("stopped")
+}
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "stopped", output)
+}
@@ -0,0 +1,225 @@
+package integration_test
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestE2E_JSONPathAccess(t *testing.T) {
+	source := `
+body = """{"items": [{"name": "web 1", "port": 80}, {"name": "db", "port": 5432}], "ok": true}"""
+data = parse_json(body)
+print(data.items[1].name)
+print(len(data.items))
+for item in data.items {
+	print("{item}")
+}
+if data.ok {
+	print("ok")
+}
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "db\n2\n{\"name\":\"web 1\",\"port\":80}\n{\"name\":\"db\",\"port\":5432}\nok", output)
+}
+
+func TestE2E_JSONIterateFields(t *testing.T) {
+	source := `
+data = parse_json("""{"name": "web", "port": 80, "tags": ["a"]}""")
+for key, value in data {
+	print("{key}={value}")
+}
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "name=web\nport=80\ntags=[\"a\"]", output)
+}
+
+func TestE2E_JSONIterateMultilineStrings(t *testing.T) {
+	source := `
+data = parse_json("""{"notes": ["one\\ntwo", "three"], "env": {"MOTD": "hi\\nthere", "PORT": 80}}""")
+for note in data.notes {
+	print("[{note}]")
+}
+for key, value in data.env {
+	print("{key}=[{value}]")
+}
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "[one\ntwo]\n[three]\nMOTD=[hi\nthere]\nPORT=[80]", output)
+}
+
+func TestE2E_JSONLoopBodyKeepsStdin(t *testing.T) {
+	// A command in the body that reads stdin must not eat the items
+	source := `
+data = parse_json("""{"items": ["a", "b", "c"]}""")
+for item in data.items {
+	exec("cat > /dev/null")
+	print(item)
+}
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "a\nb\nc", output)
+}
+
+func TestE2E_JSONLoopOverNonArrayFails(t *testing.T) {
+	source := `
+data = parse_json("""{"items": 5}""")
+for item in data.items {
+	print(item)
+}
+print("not reached")
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.NotEqual(t, 0, code)
+	assert.Contains(t, output, "Cannot iterate over number")
+	assert.NotContains(t, output, "not reached")
+}
+
+func TestE2E_JSONBuildAndMutate(t *testing.T) {
+	source := `
+data = parse_json("{}")
+data.name = "it's \"quoted\""
+data.servers = [{host: "a", port: 80}]
+data.servers[0].port = 8080
+data["count"] = len(data.servers)
+i = 0
+host = data.servers[i].host
+data.servers[i].host = "{host}-primary"
+print(to_json(data))
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, `{"name":"it's \"quoted\"","servers":[{"host":"a-primary","port":8080}],"count":1}`, output)
+}
+
+func TestE2E_ToJSON(t *testing.T) {
+	source := `
+config = {host: "localhost"}
+items = ["a b", "c"]
+data = parse_json("""{"n": 1}""")
+print(to_json(config))
+print(to_json(items))
+print(to_json(data.n))
+print(to_json("plain"))
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "{\"host\":\"localhost\"}\n[\"a b\",\"c\"]\n1\n\"plain\"", output)
+}
+
+func TestE2E_ToJSONKeepsTypesOfCollections(t *testing.T) {
+	source := `
+body = {replicas: 3, debug: true, ratio: 0.5, name: "web", tag: "42"}
+body["debug"] = "off"
+body["port"] = 8080
+ports = [80, 443]
+print(to_json(body))
+print(to_json(ports))
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	require.Equal(t, 0, code, output)
+	lines := strings.Split(output, "\n")
+	require.Len(t, lines, 2)
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &doc))
+	assert.Equal(t, map[string]any{
+		"replicas": 3.0, "debug": "off", "ratio": 0.5, "name": "web", "tag": "42", "port": 8080.0,
+	}, doc)
+	assert.Equal(t, "[80,443]", lines[1])
+}
+
+func TestE2E_JSONParam(t *testing.T) {
+	source := `
+fn host(server: json) -> str {
+	return server.host
+}
+data = parse_json("""{"servers": [{"host": "a"}, {"host": "b"}]}""")
+for server in data.servers {
+	print(host(server))
+}
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "a\nb", output)
+}
+
+func TestE2E_JSONDocuments(t *testing.T) {
+	source := `
+_body = """{"name": "web", "debug": true, "spec": {"replicas": 1}}"""
+image = "nginx \"1.25\" $HOME"
+doc = _body |> json_set(".spec.replicas", 3) |> json_set(".spec.image", image) |> json_delete(".debug")
+doc = json_set(doc, ".spec.env", {LOG: "debug", ports: [80, 443]})
+print(doc)
+for key in json_keys(doc.spec) {
+	print(key)
+}
+keys = json_keys(doc)
+print(len(keys), json_len(doc.spec))
+merged = json_merge(doc, """{"spec": {"replicas": 5}, "team": "ops"}""")
+print(merged.spec.replicas, merged.spec.image, merged.team)
+if json_valid(merged) and !json_valid("{oops") and !json_valid("") {
+	print("valid")
+}
+fixed = json_set("{oops", ".a", 1) or "{}"
+print(fixed)
+print(to_json({msg: "line\nbreak \"quoted\" \\ tab\t", tags: ["a", {b: false}]}))
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code, output)
+	assert.Equal(t, strings.Join([]string{
+		`{"name":"web","spec":{"replicas":3,"image":"nginx \"1.25\" $HOME","env":{"LOG":"debug","ports":[80,443]}}}`,
+		"replicas", "image", "env",
+		"2 3",
+		`5 nginx "1.25" $HOME ops`,
+		"valid",
+		"{}",
+		`{"msg":"line\nbreak \"quoted\" \\ tab\t","tags":["a",{"b":false}]}`,
+	}, "\n"), output)
+}
+
+func TestE2E_JSONNeedsJq(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "deploy")
+	require.NoError(t, os.WriteFile(path, []byte(compileSource(t, `print("start")
+print(json_len("[1]"))`)), 0755))
+
+	cmd := exec.Command(bash, path)
+	cmd.Env = []string{"PATH=" + t.TempDir()}
+	out, err := cmd.CombinedOutput()
+
+	var exitErr *exec.ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 127, exitErr.ExitCode())
+	assert.Equal(t, "deploy: jq is required for JSON but was not found; install jq and try again\n", string(out))
+}
@@ -0,0 +1,73 @@
+package integration_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const logScript = `
+user = "bob smith"
+log.debug("checking")
+log.info("deploying", user: user, replicas: 3, note: "say \"hi\"\tnow")
+log.warn("slow")
+log.error("failed", code: 2)
+print("done")
+`
+
+func TestE2E_LogText(t *testing.T) {
+	output, code := runBash(t, compileSource(t, logScript))
+
+	assert.Equal(t, 0, code)
+	assert.Regexp(t, `^\d{4}-\d\d-\d\dT\d\d:\d\d:\d\dZ INFO  deploying user="bob smith" replicas=3 note="say \\"hi\\"\\tnow"
+\S+Z WARN  slow
+\S+Z ERROR failed code=2
+done$`, output)
+	assert.NotContains(t, output, "\x1b[", "colors without a terminal")
+}
+
+func TestE2E_LogLevel(t *testing.T) {
+	bash := compileSource(t, logScript)
+
+	output, _ := runBash(t, "LOG_LEVEL=debug\n"+bash)
+	assert.Contains(t, output, "DEBUG checking")
+
+	output, _ = runBash(t, "LOG_LEVEL=warn\n"+bash)
+	assert.NotContains(t, output, "deploying")
+	assert.Contains(t, output, "WARN  slow")
+}
+
+func TestE2E_LogJSON(t *testing.T) {
+	output, code := runBash(t, "LOG_FORMAT=json\n"+compileSource(t, logScript))
+
+	assert.Equal(t, 0, code)
+	lines := strings.Split(output, "\n")
+	require.Len(t, lines, 4)
+	var entry map[string]string
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry), lines[0])
+	assert.Equal(t, "info", entry["level"])
+	assert.Equal(t, "deploying", entry["msg"])
+	assert.Equal(t, "bob smith", entry["user"])
+	assert.Equal(t, "3", entry["replicas"])
+	assert.Equal(t, "say \"hi\"\tnow", entry["note"])
+	assert.Regexp(t, `^\d{4}-\d\d-\d\dT\d\d:\d\d:\d\dZ$`, entry["time"])
+	assert.Equal(t, "done", lines[3])
+}
+
+func TestE2E_LogJSONEscapesControlCharacters(t *testing.T) {
+	source := `
+log.info(env("MSG"), raw: env("RAW"))
+`
+	prefix := "LOG_FORMAT=json\nMSG=$'bell\\a back\\\\ \"q\"'\nRAW=$'a\\001b\\033[31mc\\fd\\re\\tf\\ng\\x7f'\n"
+	output, code := runBash(t, prefix+compileSource(t, source))
+
+	assert.Equal(t, 0, code)
+	require.NotContains(t, output, "\n", "one line per entry")
+	var entry map[string]string
+	require.NoError(t, json.Unmarshal([]byte(output), &entry), output)
+	assert.Equal(t, "bell\a back\\ \"q\"", entry["msg"])
+	assert.Equal(t, "a\x01b\x1b[31mc\fd\re\tf\ng\x7f", entry["raw"])
+}
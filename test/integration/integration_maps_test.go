@@ -0,0 +1,135 @@
+package integration_test
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// sortedLines sorts output lines, since map iteration order is unspecified.
+func sortedLines(output string) []string {
+	lines := strings.Split(output, "\n")
+	sort.Strings(lines)
+	return lines
+}
+
+func TestE2E_MapIteration(t *testing.T) {
+	source := `
+config = {host: "localhost", port: 8080, "log-level": "debug"}
+for key, value in config {
+	print("{key}={value}")
+}
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, []string{"host=localhost", "log-level=debug", "port=8080"}, sortedLines(output))
+}
+
+func TestE2E_MapRuntimeKeys(t *testing.T) {
+	source := `
+counts = {}
+words = ["a", "b", "a", "c", "a"]
+for w in words {
+	if has(counts, w) {
+		counts[w] = counts[w] + 1
+	} else {
+		counts[w] = 1
+	}
+}
+print(len(counts), counts["a"])
+delete(counts, "b")
+print(len(counts))
+if !has(counts, "b") {
+	print("b removed")
+}
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "3 3\n2\nb removed", output)
+}
+
+func TestE2E_MapQuotedKeys(t *testing.T) {
+	source := `
+m = {}
+keys = ["it's", "a b", "$x", "a]b"]
+for k in keys {
+	m[k] = "set"
+}
+for k in keys {
+	found = has(m, k)
+	delete(m, k)
+	print(k, found, has(m, k))
+}
+print(len(m))
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "it's true false\na b true false\n$x true false\na]b true false\n0", output)
+}
+
+func TestE2E_MapKeysAndValues(t *testing.T) {
+	source := `
+ports = {web: "80", api: "8080"}
+for k in keys(ports) {
+	print("key {k}")
+}
+for v in values(ports) {
+	print("value {v}")
+}
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, []string{"key api", "key web", "value 80", "value 8080"}, sortedLines(output))
+}
+
+func TestE2E_MapPassedToFunction(t *testing.T) {
+	source := `
+fn set_default(cfg: map, key: str, value: str) {
+	if !has(cfg, key) {
+		cfg[key] = value
+	}
+}
+
+fn apply_defaults(config: map) {
+	set_default(config, "port", "80")
+	set_default(config, "host", "0.0.0.0")
+}
+
+config = {host: "localhost"}
+apply_defaults(config)
+print(config["host"], config["port"])
+
+other = {}
+apply_defaults(other)
+print(other["host"], other["port"])
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "localhost 80\n0.0.0.0 80", output)
+}
+
+func TestE2E_ListIndexValueLoop(t *testing.T) {
+	source := `
+items = ["x", "y"]
+for i, item in items {
+	print("{i}: {item}")
+}
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "0: x\n1: y", output)
+}
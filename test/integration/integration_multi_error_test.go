@@ -46,3 +46,69 @@ func TestE2E_ErrorCapAt10(t *testing.T) {
 	assert.Contains(t, string(out), "... and")
 	assert.Contains(t, string(out), "more error")
 }
+
+func TestE2E_CodegenErrorHasPosition(t *testing.T) {
+	root := projectRoot(t)
+	dir := t.TempDir()
+	errFile := dir + "/codegen.lz"
+
+	require.NoError(t, os.WriteFile(errFile, []byte("x = 1\nif x > 0 {\n    print({a: 1})\n}\n"), 0644))
+
+	cmd := exec.Command("go", "run", root+"/cmd/langz", "build", errFile)
+	out, err := cmd.CombinedOutput()
+	require.Error(t, err)
+	assert.Contains(t, string(out), errFile+":3:5: map literals")
+}
+
+func TestE2E_SemanticErrorHasPosition(t *testing.T) {
+	root := projectRoot(t)
+	dir := t.TempDir()
+	errFile := dir + "/sema.lz"
+
+	source := "fn add(a: int, b: int) -> int {\n    return a + b\n}\ntotal = add(1)\nprint(missing)\n"
+	require.NoError(t, os.WriteFile(errFile, []byte(source), 0644))
+
+	cmd := exec.Command("go", "run", root+"/cmd/langz", "run", errFile)
+	out, err := cmd.CombinedOutput()
+	require.Error(t, err)
+	assert.Contains(t, string(out), errFile+":4:9: missing argument b in call to add()")
+	assert.Contains(t, string(out), errFile+":5:7: undefined: missing")
+}
+
+func TestE2E_ImportErrorHasPosition(t *testing.T) {
+	root := projectRoot(t)
+	dir := t.TempDir()
+	mainFile := dir + "/main.lz"
+	libFile := dir + "/lib.lz"
+
+	require.NoError(t, os.WriteFile(mainFile, []byte("print(\"start\")\nimport \"missing.lz\"\n"), 0644))
+
+	cmd := exec.Command("go", "run", root+"/cmd/langz", "build", mainFile)
+	out, err := cmd.CombinedOutput()
+	require.Error(t, err)
+	assert.Contains(t, string(out), mainFile+":2:1: import \"missing.lz\"")
+
+	// Parse errors inside the imported file point into that file
+	require.NoError(t, os.WriteFile(mainFile, []byte("import \"lib.lz\"\n"), 0644))
+	require.NoError(t, os.WriteFile(libFile, []byte("x = 1\ny = @\n"), 0644))
+
+	cmd = exec.Command("go", "run", root+"/cmd/langz", "build", mainFile)
+	out, err = cmd.CombinedOutput()
+	require.Error(t, err)
+	assert.Contains(t, string(out), "lib.lz:2:5:")
+}
+
+func TestE2E_ImportedFileSemaErrorHasPath(t *testing.T) {
+	root := projectRoot(t)
+	dir := t.TempDir()
+	mainFile := dir + "/main.lz"
+	libFile := dir + "/lib.lz"
+
+	require.NoError(t, os.WriteFile(mainFile, []byte("import \"lib.lz\" as lib\nlib.greet()\n"), 0644))
+	require.NoError(t, os.WriteFile(libFile, []byte("fn greet() {\n    print(missing)\n}\n"), 0644))
+
+	cmd := exec.Command("go", "run", root+"/cmd/langz", "build", mainFile)
+	out, err := cmd.CombinedOutput()
+	require.Error(t, err)
+	assert.Contains(t, string(out), libFile+":2:11: undefined: missing")
+}
@@ -0,0 +1,68 @@
+package integration_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestE2E_MultilineStringToFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "site.conf")
+	source := `
+fn render(host: str) {
+	write("` + path + `", """
+		server {
+		    server_name {host};
+		    location ~ \\.php$ {
+		        return 403;
+		    }
+		}
+		""")
+}
+render("example.com")
+`
+	bash := compileSource(t, source)
+	_, code := runBash(t, bash)
+	require.Equal(t, 0, code)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	expected := "server {\n    server_name example.com;\n    location ~ \\.php$ {\n        return 403;\n    }\n}\n"
+	assert.Equal(t, expected, string(content))
+}
+
+func TestE2E_RawStringIsByteForByte(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "raw.txt")
+	source := `
+write("` + path + `", r"""
+    -n {name} $HOME \t 'quoted' "double"
+      indented \
+    """)
+`
+	bash := compileSource(t, source)
+	_, code := runBash(t, bash)
+	require.Equal(t, 0, code)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "-n {name} $HOME \\t 'quoted' \"double\"\n  indented \\\n", string(content))
+}
+
+func TestE2E_PrintLeadingDash(t *testing.T) {
+	source := `
+print("""
+    -n not a flag
+    -e \\n
+    """)
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "-n not a flag\n-e \\n", output)
+}
@@ -1,9 +1,13 @@
 package integration_test
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestE2E_PipeToUpper(t *testing.T) {
@@ -56,3 +60,75 @@ print(result)
 	assert.Equal(t, 0, code)
 	assert.Equal(t, "WORLD", output)
 }
+
+func TestE2E_PipeThroughCommands(t *testing.T) {
+	dir := t.TempDir()
+	log := filepath.Join(dir, "app.log")
+	require.NoError(t, os.WriteFile(log, []byte("INFO start\nERROR disk full\nINFO retry\nERROR disk still full\n"), 0644))
+	source := `
+log = "` + log + `"
+errors = read(log) |> cmd("grep", "ERROR") |> lines()
+print(len(errors), errors[1])
+count = $ cat {log} |> $ grep -c INFO |> trim()
+print("info: {count}")
+for line in read(log) |> cmd("grep", "ERROR") |> cmd("cut", "-d", " ", "-f", "2-") |> lines() {
+	print("- {line}")
+}
+if read(log) |> cmd("grep", "-q", "still") {
+	print("still failing")
+}
+names = ["b", "a"]
+names |> cmd("sort") |> cmd("tr", "\n", " ")
+print("")
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "2 ERROR disk still full\ninfo: 2\n- disk full\n- disk still full\nstill failing\na b", strings.TrimSpace(output))
+}
+
+func TestE2E_PipelineFailureStopsScript(t *testing.T) {
+	source := `
+for l in $ sh -c "echo one; exit 3" |> lines() {
+	print(l)
+}
+print("unreachable")
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 3, code)
+	assert.Equal(t, "one", strings.TrimSpace(output))
+}
+
+func TestE2E_BreakOutOfPipedLines(t *testing.T) {
+	source := `
+for l in cmd("seq", "1", "200000") |> lines() {
+	if l == "3" {
+		break
+	}
+	print(l)
+}
+print("after")
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "1\n2\nafter", strings.TrimSpace(output))
+}
+
+func TestE2E_BreakKeepsPipelineFailure(t *testing.T) {
+	source := `
+for l in $ sh -c "echo one; exit 3" |> lines() {
+	break
+}
+print("unreachable")
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 3, code)
+	assert.Empty(t, strings.TrimSpace(output))
+}
@@ -0,0 +1,169 @@
+package integration_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const promptScript = `
+envs = ["staging", "prod"]
+name = prompt("Release name", default: "v1")
+target = choose("Environment?", envs, default: "staging")
+print("{name} -> {target}")
+if confirm("Deploy {name}?") {
+    print("deploying")
+} else {
+    print("skipped")
+}
+for a in args() {
+    print("arg {a}")
+}
+`
+
+// runPrompts runs the compiled source with stdin and the extra
+// environment. With tty set it runs under script(1), so that stdin is a
+// terminal fed from stdin.
+func runPrompts(t *testing.T, source string, stdin io.Reader, tty bool, env []string, args ...string) (string, int) {
+	t.Helper()
+	cmd := promptCommand(t, source, tty, env, args...)
+	cmd.Stdin = stdin
+	out, err := cmd.CombinedOutput()
+	return promptResult(out, err)
+}
+
+// promptCommand builds the command runPrompts runs.
+func promptCommand(t *testing.T, source string, tty bool, env []string, args ...string) *exec.Cmd {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "release")
+	require.NoError(t, os.WriteFile(path, []byte(compileSource(t, source)), 0755))
+
+	cmd := exec.Command("bash", append([]string{path}, args...)...)
+	if tty {
+		if _, err := exec.LookPath("script"); err != nil {
+			t.Skip("script(1) is needed to run under a terminal")
+		}
+		cmd = exec.Command("script", "-qec", strings.Join(append([]string{"bash", path}, args...), " "), "/dev/null")
+	}
+	cmd.Env = append(os.Environ(), env...)
+	return cmd
+}
+
+func promptResult(out []byte, err error) (string, int) {
+	code := 0
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		code = exitErr.ExitCode()
+	}
+	return strings.ReplaceAll(strings.TrimSpace(string(out)), "\r", ""), code
+}
+
+// answerOnPrompt collects a terminal's output and types answer into it
+// once prompt has been shown, so the answer can't arrive before the
+// script is reading it.
+type answerOnPrompt struct {
+	out            bytes.Buffer
+	prompt, answer string
+	stdin          *io.PipeWriter
+	sent           bool
+}
+
+func (a *answerOnPrompt) Write(p []byte) (int, error) {
+	a.out.Write(p)
+	if !a.sent && strings.Contains(a.out.String(), a.prompt) {
+		a.sent = true
+		go func() {
+			io.WriteString(a.stdin, a.answer)
+			a.stdin.Close()
+		}()
+	}
+	return len(p), nil
+}
+
+// runAnswering runs source under a terminal, answering the one prompt it
+// asks. A prompt that never shows up ends its input after a while rather
+// than leaving the test waiting.
+func runAnswering(t *testing.T, source, prompt, answer string) (string, int) {
+	t.Helper()
+	cmd := promptCommand(t, source, true, nil)
+	r, w := io.Pipe()
+	out := &answerOnPrompt{prompt: prompt, answer: answer, stdin: w}
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = r, out, out
+	timer := time.AfterFunc(10*time.Second, func() { w.Close() })
+	defer timer.Stop()
+	err := cmd.Run()
+	return promptResult(out.out.Bytes(), err)
+}
+
+func TestE2E_PromptsWithoutTerminalTakeDefaults(t *testing.T) {
+	// piped input is never read as answers
+	output, code := runPrompts(t, promptScript, strings.NewReader("v9\n2\ny\n"), false, nil, "a")
+
+	assert.Equal(t, 0, code, output)
+	assert.Equal(t, "v1 -> staging\nDeploy v1? [y/N] no (not a terminal; pass --yes to confirm)\nskipped\narg a", output)
+}
+
+func TestE2E_PromptsYes(t *testing.T) {
+	want := "v1 -> staging\nDeploy v1? [y/N] yes (--yes)\ndeploying\narg a"
+
+	output, code := runPrompts(t, promptScript, nil, false, nil, "a", "--yes")
+	assert.Equal(t, 0, code, output)
+	assert.Equal(t, want, output)
+
+	// --yes is an answer even with a terminal to ask on
+	output, code = runPrompts(t, promptScript, strings.NewReader(""), true, []string{"LANGZ_YES=1"}, "a")
+	assert.Equal(t, 0, code, output)
+	assert.Equal(t, want, output)
+}
+
+func TestE2E_PromptWithoutDefaultFails(t *testing.T) {
+	source := "token = secret(\"API token:\")\nprint(\"not reached\")"
+	output, code := runPrompts(t, source, nil, false, nil)
+
+	assert.Equal(t, 1, code)
+	assert.Equal(t, `release: no answer for "API token:": not running interactively and no default`, output)
+
+	source = "c = choose(\"Pick\", [\"a\", \"b\"], default: \"c\")\nprint(\"not reached\")"
+	output, code = runPrompts(t, source, nil, false, nil)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, `release: default "c" for "Pick" is not one of the options`, output)
+}
+
+func TestE2E_PromptsThroughTerminal(t *testing.T) {
+	output, code := runPrompts(t, promptScript, strings.NewReader("v2\nbogus\n2\nyes\n"), true, nil, "a")
+
+	assert.Equal(t, 0, code, output)
+	assert.Contains(t, output, "Release name [v1] ")
+	assert.Contains(t, output, "Environment?\n  1) staging\n  2) prod\nChoose 1-2 [staging]: ")
+	assert.Contains(t, output, "Please enter a number from 1 to 2.")
+	assert.Contains(t, output, "v2 -> prod\n")
+	assert.Contains(t, output, "Deploy v2? [y/N] deploying\narg a")
+}
+
+func TestE2E_PromptDefaultsThroughTerminal(t *testing.T) {
+	output, code := runPrompts(t, promptScript, strings.NewReader("\n\n\n"), true, nil)
+
+	assert.Equal(t, 0, code, output)
+	assert.Contains(t, output, "v1 -> staging\n")
+	assert.Contains(t, output, "skipped")
+}
+
+func TestE2E_SecretIsNotEchoed(t *testing.T) {
+	source := `
+pw = secret("Password:")
+if pw == "hunter2" {
+    print("got it")
+}
+`
+	output, code := runAnswering(t, source, "Password:", "hunter2\n")
+
+	assert.Equal(t, 0, code, output)
+	assert.Equal(t, "Password: \ngot it", output)
+}
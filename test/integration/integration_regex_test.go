@@ -0,0 +1,76 @@
+package integration_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestE2E_RegexFindVersion(t *testing.T) {
+	source := `
+tag = "v1.22.3-rc1"
+parts = regex_find(tag, "^v([0-9]+)\.([0-9]{1,3})\.([0-9]+)")
+print(len(parts), parts[0], parts[1], parts[2])
+none = regex_find(tag, "^nope(.)")
+print(len(none))
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	lines := strings.Split(output, "\n")
+	assert.Equal(t, "3 1 22 3", lines[0])
+	assert.Equal(t, "0", lines[1])
+}
+
+func TestE2E_MatchesCondition(t *testing.T) {
+	source := `
+tag = "v1.2.3-rc1"
+if matches(tag, "^v[0-9]+") {
+	print("version")
+}
+if tag.matches("-rc[0-9]+$") and !matches(tag, "^x") {
+	print("rc")
+}
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "version\nrc", output)
+}
+
+func TestE2E_ReplaceRegex(t *testing.T) {
+	source := `
+print(replace_regex("v1.2.3-rc1", "-rc[0-9]+$", ""))
+print(replace_regex("/usr/local/bin", "/usr/(local)", "/opt/\1"))
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "v1.2.3\n/opt/local/bin", output)
+}
+
+func TestE2E_RegexMatchArms(t *testing.T) {
+	source := `
+pat = "^ERR"
+lines = ["ERROR disk", "WARN cpu", "INFO ok", "debug"]
+for line in lines {
+	match line {
+		matches(pat) => print("error")
+		matches("^WARN [a-z]{3}$") => {
+			print("warn")
+		}
+		"INFO*" => print("info")
+		_ => print("other")
+	}
+}
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "error\nwarn\ninfo\nother", output)
+}
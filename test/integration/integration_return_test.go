@@ -0,0 +1,175 @@
+package integration_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestE2E_ReturnInt(t *testing.T) {
+	source := `
+fn add(a: int, b: int) -> int {
+	return a + b
+}
+total = add(1, 2)
+print(total)
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "3", output)
+}
+
+func TestE2E_ReturnStringEarly(t *testing.T) {
+	source := `
+fn label(n: int) -> str {
+	if n > 10 {
+		return "big"
+	}
+	return "small {n}"
+}
+print(label(50))
+print(label(3))
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, []string{"big", "small 3"}, strings.Split(output, "\n"))
+}
+
+func TestE2E_ReturnList(t *testing.T) {
+	source := `
+fn hosts() -> list {
+	return ["web 1", "db"]
+}
+h = hosts()
+for host in h {
+	print(host)
+}
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, []string{"web 1", "db"}, strings.Split(output, "\n"))
+}
+
+func TestE2E_ReturnListRoundTrip(t *testing.T) {
+	source := `
+fn copy_list(items: list) -> list {
+	return items
+}
+fn pass_on(items: list) -> list {
+	kept = copy_list(items)
+	return kept
+}
+fn none() -> list {
+	return []
+}
+xs = ["web 1", "db", "cache"]
+ys = pass_on(xs)
+empty = []
+e = copy_list(empty)
+n = none()
+print(len(ys), len(e), len(n))
+for y in ys {
+	print(y)
+}
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code, output)
+	assert.Equal(t, []string{"3 0 0", "web 1", "db", "cache"}, strings.Split(output, "\n"))
+}
+
+func TestE2E_ReturnListFailureStopsScript(t *testing.T) {
+	source := `
+fn hosts() -> list {
+	exec("exit 4")
+	return ["web"]
+}
+h = hosts()
+print("not reached")
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 4, code)
+	assert.Empty(t, output)
+}
+
+func TestE2E_ReturnValueKeepsSpaces(t *testing.T) {
+	dir := t.TempDir()
+	source := `
+fn two() -> str {
+	return "a b"
+}
+fn star() -> str {
+	print("making a star")
+	return "*"
+}
+fn show(s: str, t: str = "none") {
+	print("s={s} t={t}")
+}
+show(two())
+if two() == "a b" {
+	print("equal")
+}
+xs = [two(), "c"]
+print(len(xs))
+write("out.txt", two())
+print(read("out.txt"))
+s = star()
+print(s, star())
+`
+	output, code := runInDir(t, dir, source)
+
+	assert.Equal(t, 0, code, output)
+	assert.Equal(t, []string{
+		"s=a b t=none", "equal", "2", "a b", "making a star", "making a star", "* *",
+	}, strings.Split(output, "\n"))
+}
+
+func TestE2E_ReturnValueChained(t *testing.T) {
+	source := `
+fn double(n: int) -> int {
+	return n * 2
+}
+fn quad(n: int) -> int {
+	d = double(n)
+	return double(d)
+}
+print(quad(3))
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "12", output)
+}
+
+func TestE2E_PlainFuncExitStatus(t *testing.T) {
+	source := `
+fn is_even(n: int) -> bool {
+	if n % 2 == 0 {
+		return true
+	}
+	return false
+}
+if is_even(4) {
+	print("even")
+}
+if !is_even(3) {
+	print("odd")
+}
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, []string{"even", "odd"}, strings.Split(output, "\n"))
+}
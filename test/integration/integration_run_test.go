@@ -0,0 +1,70 @@
+package integration_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestE2E_RunDoesNotReparseArguments(t *testing.T) {
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "injected")
+	source := `
+tag = "v1; touch ` + marker + `"
+opt = "--version $(touch ` + marker + `)"
+files = ["a b", "c"]
+run("printf", "[%s]", tag)
+print("")
+$ printf [%s] {opt} {files} '{tag}' *
+print("")
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	lines := strings.Split(output, "\n")
+	assert.Equal(t, "[v1; touch "+marker+"]", lines[0])
+	assert.Equal(t, "[--version $(touch "+marker+")][a b][c][{tag}][*]", lines[1])
+	_, err := os.Stat(marker)
+	assert.True(t, os.IsNotExist(err), "argument was run as a command")
+}
+
+func TestE2E_RunResult(t *testing.T) {
+	source := `
+r = run("sh", "-c", "echo out; echo err >&2; exit 3")
+print(r.stdout, r.stderr, r.code, r.ok)
+if !r.ok {
+	print("failed")
+}
+ok = $ echo fine
+if ok.ok {
+	print(ok.stdout, ok.code)
+}
+if $ test -d / {
+	print("dir")
+}
+fallback = run("false") or {
+	print("fallback")
+}
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "out err 3 false\nfailed\nfine 0\ndir\nfallback", strings.TrimSpace(output))
+}
+
+func TestE2E_RunStatementFailureStopsScript(t *testing.T) {
+	source := `
+$ sh -c "exit 4"
+print("unreachable")
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 4, code)
+	assert.NotContains(t, output, "unreachable")
+}
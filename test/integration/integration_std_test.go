@@ -0,0 +1,101 @@
+package integration_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// compileStd compiles source as a file, so that its std imports resolve.
+func compileStd(t *testing.T, source string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "main.lz")
+	require.NoError(t, os.WriteFile(path, []byte(source), 0644))
+	return compileFile(t, path)
+}
+
+func TestE2E_StdSemver(t *testing.T) {
+	source := `
+import "std/semver"
+
+print(semver.compare("1.2.3", "1.10.0"))
+print(semver.compare("v2.0.0", "1.99.99"))
+print(semver.compare("1.0.0", "1.0.0+build.5"))
+print(semver.compare("1.0.0-rc.1", "1.0.0"))
+print(semver.compare("1.0.0-alpha", "1.0.0-beta"))
+if semver.newer("0.10.0", "0.9.1") {
+    print("newer")
+}
+if !semver.valid("1.2") {
+    print("1.2 is not a version")
+}
+print(semver.bump("v1.4.2-rc.1", "minor"))
+`
+	output, code := runBash(t, compileStd(t, source))
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "-1\n1\n0\n-1\n-1\nnewer\n1.2 is not a version\n1.5.0", output)
+}
+
+func TestE2E_StdSemverPrerelease(t *testing.T) {
+	source := `
+import "std/semver"
+
+print(semver.compare("1.2.3-rc.10", "1.2.3-rc.9"))
+print(semver.compare("1.0.0-alpha", "1.0.0-alpha.1"))
+print(semver.compare("1.0.0-alpha.1", "1.0.0-alpha.beta"))
+print(semver.compare("1.0.0-beta.11", "1.0.0-beta.2"))
+print(semver.compare("1.0.0-rc.1", "1.0.0-rc.1+build.2"))
+`
+	output, code := runBash(t, compileStd(t, source))
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "1\n-1\n-1\n1\n0", output)
+}
+
+func TestE2E_StdRetry(t *testing.T) {
+	counter := filepath.Join(t.TempDir(), "count")
+	source := `
+import "std/retry"
+
+if retry.shell("echo x >> ` + counter + `; [ $(wc -l < ` + counter + `) -ge 3 ]", attempts: 5, max_delay: 0) {
+    print("succeeded")
+}
+if !retry.shell("false", attempts: 2, max_delay: 0) {
+    print("gave up")
+}
+retry.shell("exit 3", attempts: 1)
+print("not reached")
+`
+	output, code := runBash(t, compileStd(t, source))
+
+	assert.Equal(t, 1, code)
+	assert.Contains(t, output, "WARN  echo x")
+	assert.Contains(t, output, "(attempt 2/5), retrying in 0s")
+	assert.Contains(t, output, "succeeded\n")
+	assert.Contains(t, output, "ERROR false failed after 2 attempts\ngave up")
+	assert.NotContains(t, output, "not reached")
+	assert.Equal(t, "x\nx\nx\n", mustReadFile(t, counter))
+}
+
+func TestE2E_StdLogLevel(t *testing.T) {
+	source := `
+import "std/log"
+
+log.debug("hidden")
+log.info("starting")
+log.error("failed")
+`
+	bash := compileStd(t, source)
+
+	output, code := runBash(t, bash)
+	assert.Equal(t, 0, code)
+	assert.Regexp(t, `^\d{4}-\d\d-\d\dT\d\d:\d\d:\d\dZ INFO  starting\n.*Z ERROR failed$`, output)
+
+	output, _ = runBash(t, "LOG_LEVEL=error\n"+bash)
+	assert.NotContains(t, output, "starting")
+	assert.Contains(t, output, "ERROR failed")
+}
@@ -0,0 +1,179 @@
+package integration_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runWithTmpdir runs source with TMPDIR pointing at a fresh directory and
+// returns that directory along with the output and exit code.
+func runWithTmpdir(t *testing.T, source string, env ...string) (string, string, int) {
+	t.Helper()
+	dir, tmp := t.TempDir(), t.TempDir()
+	output, code := runInDir(t, dir, source, append(env, "TMPDIR="+tmp)...)
+	return tmp, output, code
+}
+
+func assertEmptyDir(t *testing.T, dir string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.Empty(t, names, "left behind in %s", dir)
+}
+
+func TestE2E_TempPathsRemovedAtExit(t *testing.T) {
+	source := `
+f = tempfile()
+conf = tempfile(suffix: ".json")
+d = tempdir()
+write(conf, "{}")
+write("{d}/inner.txt", "x")
+fn scratch() -> str {
+	return tempfile(suffix: ".log")
+}
+s = scratch()
+print(f)
+print(conf)
+print(d)
+print(s)
+if is_file(f) and is_file(s) and is_dir(d) {
+	print("created")
+}
+`
+	tmp, output, code := runWithTmpdir(t, source)
+
+	require.Equal(t, 0, code, output)
+	lines := strings.Split(output, "\n")
+	require.Len(t, lines, 5)
+	for _, path := range lines[:4] {
+		assert.True(t, strings.HasPrefix(path, tmp+string(filepath.Separator)), path)
+	}
+	assert.True(t, strings.HasSuffix(lines[1], ".json"), lines[1])
+	assert.True(t, strings.HasSuffix(lines[3], ".log"), lines[3])
+	assert.Len(t, map[string]bool{lines[0]: true, lines[1]: true, lines[2]: true, lines[3]: true}, 4)
+	assert.Equal(t, "created", lines[4])
+	assertEmptyDir(t, tmp)
+}
+
+func TestE2E_TempPathsRemovedOnError(t *testing.T) {
+	source := `
+f = tempfile()
+write(f, "data")
+d = tempdir()
+exec("exit 3")
+print("not reached")
+`
+	tmp, output, code := runWithTmpdir(t, source)
+
+	assert.Equal(t, 3, code)
+	assert.Empty(t, output)
+	assertEmptyDir(t, tmp)
+}
+
+func TestE2E_TempPathsRemovedOnSignal(t *testing.T) {
+	source := `
+d = tempdir()
+write("{d}/partial", "x")
+exec("kill -TERM $$")
+print("not reached")
+`
+	tmp, output, code := runWithTmpdir(t, source)
+
+	assert.Equal(t, 143, code)
+	assert.Empty(t, output)
+	assertEmptyDir(t, tmp)
+}
+
+func TestE2E_WithRemovesPathAtBlockEnd(t *testing.T) {
+	source := `
+keep = tempfile()
+with dir = tempdir() {
+	write("{dir}/a.txt", "x")
+	with f = tempfile(suffix: ".txt") {
+		write(f, "y")
+		if is_file(f) {
+			print("inner open")
+		}
+	}
+	if !is_file(f) {
+		print("inner removed")
+	}
+	if is_file("{dir}/a.txt") {
+		print("outer open")
+	}
+}
+if !is_dir(dir) and is_file(keep) {
+	print("outer removed")
+}
+`
+	tmp, output, code := runWithTmpdir(t, source)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "inner open\ninner removed\nouter open\nouter removed", output)
+	assertEmptyDir(t, tmp)
+}
+
+func TestE2E_WithInFailedTry(t *testing.T) {
+	// The failure ends the block before its end, so the exit cleanup
+	// removes the directory instead
+	source := `
+try {
+	with dir = tempdir() {
+		write("{dir}/a.txt", "x")
+		exec("false")
+	}
+} catch {
+	print("caught")
+}
+if is_dir(dir) {
+	print("kept until exit")
+}
+print("after")
+`
+	tmp, output, code := runWithTmpdir(t, source)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "caught\nkept until exit\nafter", output)
+	assertEmptyDir(t, tmp)
+}
+
+func TestE2E_FetchFailureLeavesNoTempFiles(t *testing.T) {
+	// A curl that removes the body file makes reading it fail under set -e,
+	// between creating the temp files and removing them
+	bin := t.TempDir()
+	curl := "#!/bin/sh\nwhile [ $# -gt 0 ]; do\n  [ \"$1\" = -o ] && rm -f \"$2\"\n  shift\ndone\nprintf 200\n"
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "curl"), []byte(curl), 0755))
+
+	source := `
+resp = fetch("http://example.invalid")
+print("not reached")
+`
+	tmp, output, code := runWithTmpdir(t, source, "PATH="+bin+":"+os.Getenv("PATH"))
+
+	assert.NotEqual(t, 0, code)
+	assert.NotContains(t, output, "not reached")
+	assertEmptyDir(t, tmp)
+}
+
+func TestE2E_RunAndSpawnFilesRemovedOnSignal(t *testing.T) {
+	source := `
+job = spawn("sleep 30")
+me = pid()
+r = run("sh", "-c", "kill {me}; sleep 1")
+print("not reached")
+`
+	tmp, output, code := runWithTmpdir(t, source)
+
+	assert.Equal(t, 143, code)
+	assert.Empty(t, output)
+	assertEmptyDir(t, tmp)
+}
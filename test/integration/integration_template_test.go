@@ -0,0 +1,103 @@
+package integration_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const nginxTemplate = `# {app}, managed by deploy
+upstream {app} {
+{for h in hosts}
+    server {h}:{port};
+{end}
+}
+{if tls}
+listen 443 ssl;
+{else}
+listen 80;
+{end}
+{if !debug}
+error_log off;
+{end}
+`
+
+// writeTemplate writes a template into a temp dir and returns the dir.
+func writeTemplate(t *testing.T, name, template string) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(template), 0644))
+	return dir
+}
+
+func TestE2E_RenderLoopsAndConditionals(t *testing.T) {
+	dir := writeTemplate(t, "nginx.tmpl", nginxTemplate)
+	source := strings.ReplaceAll(`hosts = ["10.0.0.1", "10.0.0.2"]
+print(render("DIR/nginx.tmpl", vars: {app: "web", hosts: hosts, port: 8080, tls: true, debug: false}))
+cfg = {app: "api", hosts: "10.0.0.3", port: "9000", tls: "", debug: "true"}
+render_to("DIR/nginx.tmpl", "DIR/api.conf", vars: cfg)
+`, "DIR", dir)
+
+	output, code := runBash(t, compileSource(t, source))
+
+	assert.Equal(t, 0, code, output)
+	assert.Equal(t, "# web, managed by deploy\nupstream web {\n    server 10.0.0.1:8080;\n    server 10.0.0.2:8080;\n}\nlisten 443 ssl;\nerror_log off;", output)
+	assert.Equal(t, "# api, managed by deploy\nupstream api {\n    server 10.0.0.3:9000;\n}\nlisten 80;\n", mustReadFile(t, filepath.Join(dir, "api.conf")))
+}
+
+func TestE2E_RenderKeepsMetacharactersLiteral(t *testing.T) {
+	dir := writeTemplate(t, "msg.tmpl", "say {msg}\n")
+	value := "$(touch " + dir + "/pwned) `id` \"q\" 'x' $HOME; {msg} \\n *"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "value"), []byte(value), 0644))
+	source := strings.ReplaceAll(`print(render("DIR/msg.tmpl", vars: {msg: read("DIR/value")}))`, "DIR", dir)
+
+	output, code := runBash(t, compileSource(t, source))
+
+	assert.Equal(t, 0, code, output)
+	assert.Equal(t, "say "+value, output)
+	assert.NoFileExists(t, filepath.Join(dir, "pwned"))
+}
+
+func TestE2E_RenderMissingVariableFails(t *testing.T) {
+	dir := writeTemplate(t, "app.tmpl", "name = {name}\nport = {port}\n")
+	source := strings.ReplaceAll(`path = "DIR/app.tmpl"
+render_to(path, "DIR/app.conf", vars: {name: "web"})
+print("not reached")
+`, "DIR", dir)
+
+	output, code := runBash(t, compileSource(t, source))
+
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "render: "+dir+"/app.tmpl, line 2: undefined variable port", output)
+	assert.NoFileExists(t, filepath.Join(dir, "app.conf"))
+}
+
+func TestE2E_RenderValueFailureStopsScript(t *testing.T) {
+	dir := writeTemplate(t, "app.tmpl", "name = {name}\nports = {ports}\n")
+	source := strings.ReplaceAll(`path = "DIR/app.tmpl"
+write("DIR/out.conf", render(path, vars: {name: "x"}))
+print("not reached")
+`, "DIR", dir)
+
+	output, code := runBash(t, compileSource(t, source))
+
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "render: "+dir+"/app.tmpl, line 2: undefined variable ports", output)
+	assert.NoFileExists(t, filepath.Join(dir, "out.conf"))
+}
+
+func TestE2E_RenderFailureTakesOrFallback(t *testing.T) {
+	dir := writeTemplate(t, "app.tmpl", "{missing}\n")
+	source := strings.ReplaceAll(`text = render("DIR/app.tmpl") or "fallback"
+print(text)
+`, "DIR", dir)
+
+	output, code := runBash(t, compileSource(t, source))
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "fallback", output)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/tasnimzotder/langz/internal/codegen"
 	"github.com/tasnimzotder/langz/internal/lexer"
+	"github.com/tasnimzotder/langz/internal/modules"
 	"github.com/tasnimzotder/langz/internal/parser"
 )
 
@@ -37,6 +38,20 @@ func compileSource(t *testing.T, source string) string {
 	return output
 }
 
+// compileFile compiles the file at path, resolving its imports the way the
+// CLI does.
+func compileFile(t *testing.T, path string) string {
+	t.Helper()
+	tokens := lexer.New(mustReadFile(t, path)).Tokenize()
+	prog, err := parser.NewFile(tokens, path).ParseWithErrors()
+	require.NoError(t, err, "parse error")
+	resolver, err := modules.NewResolver(path)
+	require.NoError(t, err)
+	require.Empty(t, modules.Resolve(prog, path, resolver), "import errors")
+	output, _ := codegen.Generate(prog)
+	return output
+}
+
 func runBash(t *testing.T, script string) (string, int) {
 	t.Helper()
 	tmpFile, err := os.CreateTemp("", "langz-test-*.sh")
@@ -0,0 +1,253 @@
+package integration_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestE2E_TryCatchFinally(t *testing.T) {
+	source := `
+status = "pending"
+try {
+	status = "started"
+	exec("test -f /nonexistent/file")
+	status = "unreachable"
+} catch err {
+	print("caught", err.code, err.line, err.command)
+} finally {
+	print("finally", status)
+}
+print("after")
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "caught 1 5 test -f /nonexistent/file\nfinally started\nafter", output)
+}
+
+func TestE2E_TrySuccessRunsFinally(t *testing.T) {
+	source := `
+items = []
+config = {}
+try {
+	items = ["a", "b"]
+	config["mode"] = "fast"
+} catch err {
+	print("not reached")
+} finally {
+	print("finally")
+}
+print(len(items), config["mode"])
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "finally\n2 fast", output)
+}
+
+func TestE2E_TryFinallyRethrows(t *testing.T) {
+	source := `
+try {
+	exec("sh -c 'exit 3'")
+} finally {
+	print("cleanup")
+}
+print("not reached")
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 3, code)
+	assert.Equal(t, "cleanup", output)
+}
+
+func TestE2E_TryInFunctionAndLoop(t *testing.T) {
+	source := `
+fn check(n: int) -> str {
+	result = "ok"
+	try {
+		exec("test {n} -lt 2")
+	} catch e {
+		code = e.code
+		result = "failed {code}"
+	}
+	return result
+}
+
+for i in range(1, 3) {
+	try {
+		print(check(i))
+		if i == 2 {
+			exec("false")
+		}
+	} catch {
+		print("loop caught {i}")
+		continue
+	}
+	print("end {i}")
+}
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "ok\nend 1\nfailed 1\nloop caught 2\nfailed 1\nend 3", output)
+}
+
+func TestE2E_NestedTry(t *testing.T) {
+	source := `
+try {
+	try {
+		exec("sh -c 'exit 7'")
+	} finally {
+		print("inner finally")
+	}
+} catch err {
+	print("outer caught", err.code, err.line, err.command)
+}
+try {
+	try {
+		exec("false")
+	} catch {
+		print("inner caught")
+	}
+	print("outer continues")
+} catch {
+	print("not reached")
+}
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "inner finally\nouter caught 7 4 sh -c 'exit 7'\ninner caught\nouter continues", output)
+}
+
+func TestE2E_TryCatchesFailureInCalledFunction(t *testing.T) {
+	source := `
+fn deploy() {
+	print("deploying")
+	exec("false")
+	print("not reached")
+}
+try {
+	deploy()
+} catch err {
+	print("deploy failed", err.code, err.command)
+}
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "deploying\ndeploy failed 1 false", output)
+}
+
+func TestE2E_TryKeepsGlobalsSetByCalledFunction(t *testing.T) {
+	source := `
+count = 0
+fn bump() {
+	count = count + 1
+}
+try {
+	bump()
+	bump()
+} catch {
+	print("not reached")
+}
+print("count={count}")
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "count=2", output)
+}
+
+func TestE2E_TrySpawnedJobCanBeWaitedFor(t *testing.T) {
+	source := `
+try {
+	job = spawn("sh -c 'exit 4'")
+} catch {
+	print("not reached")
+}
+code = wait(job)
+print("job exited {code}")
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "job exited 4", output)
+}
+
+func TestE2E_TryBodyCanLeaveBlock(t *testing.T) {
+	source := `
+fn first_even(limit: int) -> int {
+	for n in range(1, limit) {
+		try {
+			if n == 3 {
+				break
+			}
+			exec("test {n} -ne 1")
+			if n % 2 == 1 {
+				continue
+			}
+			return n
+		} catch {
+			print("skip {n}")
+		} finally {
+			print("checked {n}")
+		}
+	}
+	return 0
+}
+print("found", first_even(5))
+
+for n in range(1, 5) {
+	try {
+		if n == 2 {
+			continue
+		}
+		if n == 3 {
+			break
+		}
+	} finally {
+		print("loop {n}")
+	}
+}
+
+fn last() -> int {
+	try {
+		return first_even(2)
+	} finally {
+		print("last finally")
+	}
+}
+print("last", last())
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "skip 1\nchecked 1\nchecked 2\nfound 2\nloop 1\nloop 2\nloop 3\nskip 1\nchecked 1\nchecked 2\nlast finally\nlast 2", output)
+}
+
+func TestE2E_TryExitRunsFinally(t *testing.T) {
+	source := `
+try {
+	exit(5)
+} finally {
+	print("cleanup")
+}
+print("not reached")
+`
+	bash := compileSource(t, source)
+	output, code := runBash(t, bash)
+
+	assert.Equal(t, 5, code)
+	assert.Equal(t, "cleanup", output)
+}